@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"librarease/internal/usecase"
+
+	"github.com/google/uuid"
+)
+
+// runCreateSuperAdmin registers a super admin, reading the password from
+// standard input so it stays out of the shell history.
+func runCreateSuperAdmin(ctx context.Context, args []string) error {
+	fs := newFlagSet("create-superadmin")
+	name := fs.String("name", "", "name of the super admin")
+	email := fs.String("email", "", "email the super admin signs in with")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" || *email == "" {
+		fs.Usage()
+		return errors.New("-name and -email are required")
+	}
+
+	fmt.Fprint(os.Stderr, "password: ")
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && password == "" {
+		return fmt.Errorf("read password: %w", err)
+	}
+	password = strings.TrimRight(password, "\r\n")
+	if password == "" {
+		return errors.New("a password is required")
+	}
+
+	uc, err := newAuthUsecase()
+	if err != nil {
+		return err
+	}
+	user, err := uc.RegisterUser(ctx, usecase.RegisterUser{
+		Name:       *name,
+		Email:      *email,
+		Password:   password,
+		GlobalRole: usecase.GlobalRoleSuperAdmin,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("created super admin %s (%s)\n", user.ID, user.Email)
+	return nil
+}
+
+func runCreateLibrary(ctx context.Context, args []string) error {
+	fs := newFlagSet("create-library")
+	name := fs.String("name", "", "name of the library")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" {
+		fs.Usage()
+		return errors.New("-name is required")
+	}
+
+	lib, err := newUsecase().CreateLibrary(ctx, usecase.Library{Name: *name})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("created library %s (%s)\n", lib.ID, lib.Name)
+	return nil
+}
+
+// runCreateStaff makes an existing user staff of a library.
+func runCreateStaff(ctx context.Context, args []string) error {
+	fs := newFlagSet("create-staff")
+	libraryID := fs.String("library", "", "id of the library")
+	email := fs.String("email", "", "email of the user to make staff")
+	name := fs.String("name", "", "name of the staff; the user's name when empty")
+	role := fs.String("role", usecase.StaffRoleStaff, "STAFF or ADMIN")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	libID, err := uuid.Parse(*libraryID)
+	if err != nil {
+		return fmt.Errorf("-library: %w", err)
+	}
+	if *email == "" {
+		fs.Usage()
+		return errors.New("-email is required")
+	}
+	if *role != usecase.StaffRoleStaff && *role != usecase.StaffRoleAdmin {
+		return fmt.Errorf("-role: unknown role %q", *role)
+	}
+
+	uc := newUsecase()
+	if _, err := uc.GetLibraryByID(ctx, libID.String()); err != nil {
+		return fmt.Errorf("library %s: %w", libID, err)
+	}
+	users, _, err := uc.ListUsers(ctx, usecase.ListUsersOption{Email: *email, Limit: 1})
+	if err != nil {
+		return err
+	}
+	if len(users) == 0 {
+		return fmt.Errorf("no user has the email %s", *email)
+	}
+	if *name == "" {
+		*name = users[0].Name
+	}
+
+	staff, err := uc.CreateStaff(ctx, usecase.Staff{
+		Name:      *name,
+		LibraryID: libID,
+		UserID:    users[0].ID,
+		Role:      *role,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("created %s staff %s (%s)\n", staff.Role, staff.ID, staff.Name)
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"librarease/internal/catalog"
+	"librarease/internal/usecase"
+
+	"github.com/google/uuid"
+)
+
+func runExport(ctx context.Context, args []string) error {
+	fs := newFlagSet("export")
+	libraryID := fs.String("library", "", "id of the library to export")
+	format := fs.String("format", "", "csv, marcxml or dcjson; guessed from -o when empty, else csv")
+	q := fs.String("q", "", "export only the books whose title or author match")
+	out := fs.String("o", "", "file to write; standard output when empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	libID, err := uuid.Parse(*libraryID)
+	if err != nil {
+		return fmt.Errorf("-library: %w", err)
+	}
+	if *format == "" {
+		*format = catalog.FormatFromFilename(*out)
+	}
+	if *format == "" {
+		*format = catalog.FormatCSV
+	}
+
+	var (
+		dst  io.Writer = os.Stdout
+		file *os.File
+	)
+	if *out != "" {
+		file, err = os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		dst = file
+	}
+
+	w, err := catalog.NewWriter(dst, *format)
+	if err != nil {
+		return err
+	}
+	var n int
+	err = newUsecase().ExportBooks(ctx, usecase.ListBooksOption{
+		LibraryIDs: uuid.UUIDs{libID},
+		Query:      *q,
+	}, func(b usecase.Book) error {
+		n++
+		return w.Write(b)
+	})
+	if err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if file != nil {
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "exported %d books\n", n)
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"librarease/internal/catalog"
+	"librarease/internal/usecase"
+
+	"github.com/google/uuid"
+)
+
+func runImport(ctx context.Context, args []string) error {
+	fs := newFlagSet("import")
+	libraryID := fs.String("library", "", "id of the library to import into")
+	format := fs.String("format", "", "csv, marc or marcxml; guessed from the file name when empty")
+	dryRun := fs.Bool("dry-run", false, "validate the file without importing it")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("a file is required")
+	}
+	libID, err := uuid.Parse(*libraryID)
+	if err != nil {
+		return fmt.Errorf("-library: %w", err)
+	}
+
+	name := fs.Arg(0)
+	if *format == "" {
+		*format = catalog.FormatFromFilename(name)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	rows, err := catalog.Read(f, *format)
+	if err != nil {
+		return err
+	}
+
+	res, err := newUsecase().ImportBooks(ctx, usecase.ImportBooksOption{LibraryID: libID, DryRun: *dryRun}, rows)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range res.Errors {
+		fmt.Printf("%s:%d: %s %s\n", filepath.Base(name), e.Row, e.Field, e.Message)
+	}
+	if len(res.Errors) > 0 {
+		return fmt.Errorf("%d of %d rows are invalid, nothing was imported", len(res.Errors), res.Rows)
+	}
+
+	verb := "imported"
+	if res.DryRun {
+		verb = "would import"
+	}
+	fmt.Printf("%s %d copies of %d new works from %d rows\n", verb, res.BooksCreated, res.WorksCreated, res.Rows)
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// runAccrueFines brings the fines of every overdue borrowing up to date.
+func runAccrueFines(ctx context.Context, args []string) error {
+	fs := newFlagSet("accrue-fines")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	n, err := newUsecase().AccrueFines(ctx)
+	fmt.Printf("charged %d overdue borrowings\n", n)
+	return err
+}
+
+// runExpireSubscriptions tells members their subscription expires soon,
+// then expires the lapsed subscriptions and releases their holds, as the
+// API does every hour.
+func runExpireSubscriptions(ctx context.Context, args []string) error {
+	fs := newFlagSet("expire-subscriptions")
+	days := fs.Int("notice-days", 7, "tell members of subscriptions expiring within this many days")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	uc := newNotifyUsecase()
+	n, notifyErr := uc.NotifyExpiringSubscriptions(ctx, *days)
+	fmt.Printf("told %d members their subscription expires soon\n", n)
+	n, err := uc.ExpireSubscriptions(ctx)
+	fmt.Printf("expired %d subscriptions\n", n)
+	return errors.Join(notifyErr, err)
+}
+
+// runSuspendDelinquent suspends the members over the fine or overdue
+// thresholds of their library, as the API does every hour.
+func runSuspendDelinquent(ctx context.Context, args []string) error {
+	fs := newFlagSet("suspend-delinquent")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	n, err := newUsecase().SuspendDelinquentSubscriptions(ctx)
+	fmt.Printf("suspended %d subscriptions\n", n)
+	return err
+}
+
+// runSendReminders reminds members of their due and overdue loans, as
+// the API does every hour.
+func runSendReminders(ctx context.Context, args []string) error {
+	fs := newFlagSet("send-reminders")
+	days := fs.Int("days", 2, "remind of loans due within this many days")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	n, err := newNotifyUsecase().SendReminders(ctx, *days)
+	fmt.Printf("sent %d reminders\n", n)
+	return err
+}
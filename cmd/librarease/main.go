@@ -0,0 +1,114 @@
+// Command librarease runs administrative tasks against the librarease
+// database, configured by the same environment as cmd/api.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+
+	"librarease/internal/config"
+	"librarease/internal/database"
+	"librarease/internal/firebase"
+	"librarease/internal/localauth"
+	"librarease/internal/notify"
+	"librarease/internal/usecase"
+)
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, args []string) error
+}
+
+// commands is filled in init, as the commands refer to it for their usage.
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"create-superadmin":    {"create-superadmin -name <name> -email <email> < password", runCreateSuperAdmin},
+		"create-library":       {"create-library -name <name>", runCreateLibrary},
+		"create-staff":         {"create-staff -library <id> -email <user email> [-name <name>] [-role STAFF|ADMIN]", runCreateStaff},
+		"import":               {"import -library <id> [-format csv|marc|marcxml] [-dry-run] <file>", runImport},
+		"export":               {"export -library <id> [-format csv|marcxml|dcjson] [-q <query>] [-o <file>]", runExport},
+		"migrate":              {"migrate up | down [-n steps] | status", runMigrate},
+		"accrue-fines":         {"accrue-fines", runAccrueFines},
+		"expire-subscriptions": {"expire-subscriptions [-notice-days n]", runExpireSubscriptions},
+		"send-reminders":       {"send-reminders [-days n]", runSendReminders},
+		"suspend-delinquent":   {"suspend-delinquent", runSuspendDelinquent},
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := cmd.run(ctx, os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "librarease %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "usage:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  librarease %s\n", commands[name].usage)
+	}
+}
+
+// newFlagSet returns a flag set for the command that prints its usage.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: librarease %s\n", commands[name].usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func newUsecase() usecase.Usecase {
+	return usecase.New(database.New(), nil, nil)
+}
+
+// newNotifyUsecase is newUsecase with the notifier cmd/api uses.
+func newNotifyUsecase() usecase.Usecase {
+	return usecase.New(database.New(), nil, notify.New())
+}
+
+// newAuthUsecase is newUsecase with the identity provider cmd/api uses,
+// for the commands that create accounts.
+func newAuthUsecase() (usecase.Usecase, error) {
+	repo := database.New()
+
+	var ip usecase.IdentityProvider
+	switch os.Getenv(config.ENV_KEY_IDENTITY_PROVIDER) {
+	case config.IDENTITY_PROVIDER_LOCAL:
+		la, err := localauth.New(repo, os.Getenv(config.ENV_KEY_LOCAL_AUTH_SECRET))
+		if err != nil {
+			return usecase.Usecase{}, err
+		}
+		ip = la
+	default:
+		ip = firebase.New()
+	}
+	return usecase.New(repo, ip, nil), nil
+}
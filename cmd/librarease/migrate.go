@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"librarease/internal/database"
+)
+
+// runMigrate applies, rolls back or lists the schema migrations. It does
+// not go through database.New, which applies them all on connecting.
+func runMigrate(ctx context.Context, args []string) error {
+	fs := newFlagSet("migrate")
+	steps := fs.Int("n", 1, "number of migrations to roll back with down")
+	if len(args) == 0 {
+		fs.Usage()
+		return errors.New("missing up, down or status")
+	}
+	action := args[0]
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	m, err := database.NewMigrator()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	switch action {
+	case "up":
+		done, err := m.Up(ctx)
+		for _, mig := range done {
+			fmt.Printf("applied %04d_%s\n", mig.Version, mig.Name)
+		}
+		if err == nil && len(done) == 0 {
+			fmt.Println("no pending migrations")
+		}
+		return err
+
+	case "down":
+		if *steps < 1 {
+			return errors.New("-n must be at least 1")
+		}
+		done, err := m.Down(ctx, *steps)
+		for _, mig := range done {
+			fmt.Printf("rolled back %04d_%s\n", mig.Version, mig.Name)
+		}
+		return err
+
+	case "status":
+		status, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, mig := range status {
+			applied := "pending"
+			if mig.AppliedAt != nil {
+				applied = mig.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d_%-32s %s\n", mig.Version, mig.Name, applied)
+		}
+		return nil
+
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown action %q", action)
+	}
+}
@@ -0,0 +1,86 @@
+// Package catalog reads and writes book catalogues in the file formats
+// libraries exchange them in.
+package catalog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"librarease/internal/usecase"
+)
+
+const (
+	FormatCSV     = "csv"
+	FormatMARC    = "marc"
+	FormatMARCXML = "marcxml"
+	// FormatDublinCoreJSON is a JSON array of Dublin Core records; it
+	// can be written but not read.
+	FormatDublinCoreJSON = "dcjson"
+)
+
+// FormatFromFilename guesses the format of a file from its extension.
+func FormatFromFilename(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return FormatCSV
+	case ".mrc", ".marc":
+		return FormatMARC
+	case ".xml":
+		return FormatMARCXML
+	}
+	return ""
+}
+
+// Read reads the rows of a catalogue in the format. Rows are returned as
+// they appear in the file; usecase.ImportBooks validates them.
+func Read(r io.Reader, format string) ([]usecase.ImportRow, error) {
+	switch format {
+	case FormatCSV:
+		return readCSV(r)
+	case FormatMARC:
+		return readMARC(r)
+	case FormatMARCXML:
+		return readMARCXML(r)
+	}
+	return nil, fmt.Errorf("%w: unknown catalogue format %q", usecase.ErrInvalidArgument, format)
+}
+
+// Writer writes books one at a time. Close writes what ends the file and
+// must be called even when no book was written.
+type Writer interface {
+	Write(usecase.Book) error
+	Close() error
+}
+
+// NewWriter returns a Writer of the format. Output is buffered, so
+// nothing reaches w before a few books are written or the writer closes.
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	bw := bufio.NewWriter(w)
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(bw), nil
+	case FormatMARCXML:
+		return newMARCXMLWriter(bw), nil
+	case FormatDublinCoreJSON:
+		return newDublinCoreWriter(bw), nil
+	}
+	return nil, fmt.Errorf("%w: unknown catalogue format %q", usecase.ErrInvalidArgument, format)
+}
+
+// ContentType returns the media type and file extension of the format.
+func ContentType(format string) (string, string) {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8", ".csv"
+	case FormatMARC:
+		return "application/marc", ".mrc"
+	case FormatMARCXML:
+		return "application/marcxml+xml", ".xml"
+	case FormatDublinCoreJSON:
+		return "application/json", ".json"
+	}
+	return "application/octet-stream", ""
+}
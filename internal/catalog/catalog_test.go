@@ -0,0 +1,169 @@
+package catalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"librarease/internal/usecase"
+
+	"github.com/google/uuid"
+)
+
+// encodeMARC builds a binary MARC21 record from tag and field data pairs.
+func encodeMARC(fields ...string) []byte {
+	var dir, data bytes.Buffer
+	for i := 0; i < len(fields); i += 2 {
+		f := fields[i+1] + string(rune(fieldTerminator))
+		fmt.Fprintf(&dir, "%s%04d%05d", fields[i], len(f), data.Len())
+		data.WriteString(f)
+	}
+	dir.WriteByte(fieldTerminator)
+	base := 24 + dir.Len()
+	length := base + data.Len() + 1
+
+	var rec bytes.Buffer
+	fmt.Fprintf(&rec, "%05dnam a22%05d   4500", length, base)
+	rec.Write(dir.Bytes())
+	rec.Write(data.Bytes())
+	rec.WriteByte(recordTerminator)
+	return rec.Bytes()
+}
+
+func TestReadCSV(t *testing.T) {
+	in := "\ufeffTitle,Author,Year,ISBN,Barcode,Location\n" +
+		"Dune,Frank Herbert,1965,978-0-441-17271-9,B-001,Shelf A\n" +
+		"\"Emma, Vol. 1\",Jane Austen,,,B-002\n"
+
+	rows, err := Read(strings.NewReader(in), FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []usecase.ImportRow{
+		{Row: 2, Title: "Dune", Author: "Frank Herbert", Year: "1965", ISBN: "978-0-441-17271-9", Code: "B-001", Location: "Shelf A"},
+		{Row: 3, Title: "Emma, Vol. 1", Author: "Jane Austen", Code: "B-002"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %+v, want %+v", rows, want)
+	}
+
+	_, err = Read(strings.NewReader("title,author\nDune,Frank Herbert\n"), FormatCSV)
+	if !errors.Is(err, usecase.ErrInvalidArgument) {
+		t.Errorf("error without code column = %v, want %v", err, usecase.ErrInvalidArgument)
+	}
+}
+
+func TestReadMARC(t *testing.T) {
+	sf := string(rune(subfieldDelimiter))
+	rec := encodeMARC(
+		"001", "ocm123",
+		"008", "850101s1965    nyu           000 1 eng d",
+		"020", "  "+sf+"a0441172717 (pbk.)",
+		"100", "1 "+sf+"aHerbert, Frank.",
+		"245", "10"+sf+"aDune :"+sf+"bthe novel /"+sf+"cFrank Herbert.",
+		"852", "  "+sf+"cFiction"+sf+"pB-001",
+	)
+	in := append(append(rec, '\n'), rec...)
+
+	rows, err := Read(bytes.NewReader(in), FormatMARC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := usecase.ImportRow{Row: 1, Title: "Dune: the novel", Author: "Herbert, Frank", Year: "1965", ISBN: "0441172717", Code: "B-001", Location: "Fiction"}
+	if len(rows) != 2 || rows[0] != want {
+		t.Fatalf("rows = %+v, want 2 of %+v", rows, want)
+	}
+
+	_, err = Read(bytes.NewReader(rec[:len(rec)-5]), FormatMARC)
+	if !errors.Is(err, usecase.ErrInvalidArgument) {
+		t.Errorf("error for truncated record = %v, want %v", err, usecase.ErrInvalidArgument)
+	}
+}
+
+func TestReadMARCXML(t *testing.T) {
+	in := `<?xml version="1.0" encoding="UTF-8"?>
+<collection xmlns="http://www.loc.gov/MARC21/slim">
+  <record>
+    <leader>00000nam a2200000   4500</leader>
+    <controlfield tag="001">ocm123</controlfield>
+    <datafield tag="020" ind1=" " ind2=" "><subfield code="a">9780441172719</subfield></datafield>
+    <datafield tag="100" ind1="1" ind2=" "><subfield code="a">Herbert, Frank.</subfield></datafield>
+    <datafield tag="245" ind1="1" ind2="0"><subfield code="a">Dune /</subfield></datafield>
+    <datafield tag="264" ind1=" " ind2="1"><subfield code="c">[1965]</subfield></datafield>
+    <datafield tag="952" ind1=" " ind2=" "><subfield code="p">B-001</subfield></datafield>
+  </record>
+</collection>`
+
+	rows, err := Read(strings.NewReader(in), FormatMARCXML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []usecase.ImportRow{{Row: 1, Title: "Dune", Author: "Herbert, Frank", Year: "1965", ISBN: "9780441172719", Code: "B-001"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %+v, want %+v", rows, want)
+	}
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	books := []usecase.Book{
+		{ID: uuid.New(), Title: "Dune", Author: "Frank Herbert", Year: 1965, ISBN13: "9780441172719", Code: "B-001", Location: "Fiction", Condition: "GOOD"},
+		{ID: uuid.New(), Title: "Emma, <Vol. 1>", Code: "B-002", Condition: "FAIR"},
+	}
+
+	for _, format := range []string{FormatCSV, FormatMARCXML} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, b := range books {
+				if err := w.Write(b); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			rows, err := Read(&buf, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) != len(books) {
+				t.Fatalf("read %d rows, want %d", len(rows), len(books))
+			}
+			for i, r := range rows {
+				b := books[i]
+				if r.Title != b.Title || r.Author != b.Author || r.ISBN != b.ISBN13 || r.Code != b.Code || r.Location != b.Location {
+					t.Errorf("row %d = %+v, want %+v", i, r, b)
+				}
+			}
+		})
+	}
+}
+
+func TestDublinCoreWriter(t *testing.T) {
+	for _, n := range []int{0, 2} {
+		var buf bytes.Buffer
+		w, _ := NewWriter(&buf, FormatDublinCoreJSON)
+		for i := 0; i < n; i++ {
+			w.Write(usecase.Book{ID: uuid.New(), Title: "Dune", ISBN13: "9780441172719"})
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		var recs []dublinCoreRecord
+		if err := json.Unmarshal(buf.Bytes(), &recs); err != nil {
+			t.Fatalf("%d records: %v\n%s", n, err, buf.String())
+		}
+		if len(recs) != n {
+			t.Errorf("decoded %d records, want %d", len(recs), n)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package catalog
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"librarease/internal/usecase"
+)
+
+// csvColumns are the columns written to a CSV catalogue, which reads back
+// with readCSV.
+var csvColumns = []string{"title", "author", "year", "isbn", "code", "location", "condition"}
+
+// readCSV reads a CSV catalogue whose header row names its columns, in
+// any order: title, author, year, isbn, code (or barcode), location and
+// condition. Only code is required.
+func readCSV(r io.Reader) ([]usecase.ImportRow, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: csv file is empty", usecase.ErrInvalidArgument)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", usecase.ErrInvalidArgument, err)
+	}
+
+	cols := make(map[string]int)
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if h == "barcode" {
+			h = "code"
+		}
+		cols[h] = i
+	}
+	if _, ok := cols["code"]; !ok {
+		return nil, fmt.Errorf("%w: csv header has no code column", usecase.ErrInvalidArgument)
+	}
+
+	var rows []usecase.ImportRow
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", usecase.ErrInvalidArgument, err)
+		}
+		line, _ := cr.FieldPos(0)
+
+		get := func(col string) string {
+			i, ok := cols[col]
+			if !ok || i >= len(rec) {
+				return ""
+			}
+			return strings.TrimSpace(rec[i])
+		}
+		rows = append(rows, usecase.ImportRow{
+			Row:       line,
+			Title:     get("title"),
+			Author:    get("author"),
+			Year:      get("year"),
+			ISBN:      get("isbn"),
+			Code:      get("code"),
+			Location:  get("location"),
+			Condition: get("condition"),
+		})
+	}
+
+	return rows, nil
+}
+
+type csvWriter struct {
+	bw     *bufio.Writer
+	cw     *csv.Writer
+	header bool
+}
+
+func newCSVWriter(bw *bufio.Writer) *csvWriter {
+	return &csvWriter{bw: bw, cw: csv.NewWriter(bw)}
+}
+
+func (w *csvWriter) writeHeader() error {
+	if w.header {
+		return nil
+	}
+	w.header = true
+	return w.cw.Write(csvColumns)
+}
+
+func (w *csvWriter) Write(b usecase.Book) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	var year string
+	if b.Year != 0 {
+		year = strconv.Itoa(b.Year)
+	}
+	return w.cw.Write([]string{b.Title, b.Author, year, b.ISBN13, b.Code, b.Location, b.Condition})
+}
+
+func (w *csvWriter) Close() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.cw.Flush()
+	if err := w.cw.Error(); err != nil {
+		return err
+	}
+	return w.bw.Flush()
+}
@@ -0,0 +1,70 @@
+package catalog
+
+import (
+	"bufio"
+	"encoding/json"
+	"strconv"
+
+	"librarease/internal/usecase"
+)
+
+// dublinCoreRecord holds the Dublin Core elements a book fills in.
+type dublinCoreRecord struct {
+	Identifier []string `json:"identifier"`
+	Title      string   `json:"title"`
+	Creator    []string `json:"creator,omitempty"`
+	Date       string   `json:"date,omitempty"`
+	Type       string   `json:"type"`
+}
+
+type dublinCoreWriter struct {
+	bw    *bufio.Writer
+	count int
+}
+
+func newDublinCoreWriter(bw *bufio.Writer) *dublinCoreWriter {
+	return &dublinCoreWriter{bw: bw}
+}
+
+func (w *dublinCoreWriter) Write(b usecase.Book) error {
+	rec := dublinCoreRecord{
+		Identifier: []string{"urn:uuid:" + b.ID.String()},
+		Title:      b.Title,
+		Type:       "Text",
+	}
+	if b.ISBN13 != "" {
+		rec.Identifier = append(rec.Identifier, "urn:isbn:"+b.ISBN13)
+	}
+	if b.Author != "" {
+		rec.Creator = []string{b.Author}
+	}
+	if b.Year != 0 {
+		rec.Date = strconv.Itoa(b.Year)
+	}
+
+	sep := ",\n"
+	if w.count == 0 {
+		sep = "[\n"
+	}
+	w.count++
+	if _, err := w.bw.WriteString(sep); err != nil {
+		return err
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	_, err = w.bw.Write(data)
+	return err
+}
+
+func (w *dublinCoreWriter) Close() error {
+	end := "\n]\n"
+	if w.count == 0 {
+		end = "[]\n"
+	}
+	if _, err := w.bw.WriteString(end); err != nil {
+		return err
+	}
+	return w.bw.Flush()
+}
@@ -0,0 +1,330 @@
+package catalog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"librarease/internal/usecase"
+)
+
+// ISO 2709 delimiters used by binary MARC21.
+const (
+	subfieldDelimiter = 0x1F
+	fieldTerminator   = 0x1E
+	recordTerminator  = 0x1D
+)
+
+type marcSubfield struct {
+	code  byte
+	value string
+}
+
+// marcRecord holds the fields of a MARC21 record that map onto a book.
+type marcRecord struct {
+	control map[string]string
+	data    map[string][][]marcSubfield
+}
+
+func newMARCRecord() marcRecord {
+	return marcRecord{
+		control: make(map[string]string),
+		data:    make(map[string][][]marcSubfield),
+	}
+}
+
+// subfield returns the first subfield with the code in the first field
+// with the tag.
+func (m marcRecord) subfield(tag string, code byte) string {
+	fields := m.data[tag]
+	if len(fields) == 0 {
+		return ""
+	}
+	for _, sf := range fields[0] {
+		if sf.code == code {
+			return strings.TrimSpace(sf.value)
+		}
+	}
+	return ""
+}
+
+// firstSubfield returns the subfield from the first of the tags that has it.
+func (m marcRecord) firstSubfield(code byte, tags ...string) string {
+	for _, tag := range tags {
+		if v := m.subfield(tag, code); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// row maps the record onto an import row: 245 title, 100/110/700
+// author, 264/260 or 008 year, 020 ISBN and the 852 (or Koha's 952)
+// holdings barcode and shelving location.
+func (m marcRecord) row(n int) usecase.ImportRow {
+	title := m.subfield("245", 'a')
+	if sub := m.subfield("245", 'b'); sub != "" {
+		title = strings.TrimRight(title, " :") + ": " + sub
+	}
+
+	isbn := m.subfield("020", 'a')
+	if i := strings.IndexByte(isbn, ' '); i >= 0 {
+		// drop qualifiers such as "(pbk.)"
+		isbn = isbn[:i]
+	}
+
+	year := marcYear(m.firstSubfield('c', "264", "260"))
+	if f := m.control["008"]; year == "" && len(f) >= 11 {
+		year = marcYear(f[7:11])
+	}
+
+	return usecase.ImportRow{
+		Row:      n,
+		Title:    strings.TrimRight(title, " /:;,.="),
+		Author:   strings.TrimRight(m.firstSubfield('a', "100", "110", "700"), " ,."),
+		Year:     year,
+		ISBN:     isbn,
+		Code:     m.firstSubfield('p', "852", "952"),
+		Location: m.firstSubfield('c', "852", "952"),
+	}
+}
+
+// marcYear returns the first run of four digits, as in "c1999." or "[2001]".
+func marcYear(s string) string {
+	run := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			run = 0
+			continue
+		}
+		if run++; run == 4 {
+			return s[i-3 : i+1]
+		}
+	}
+	return ""
+}
+
+func readMARC(r io.Reader) ([]usecase.ImportRow, error) {
+	br := bufio.NewReader(r)
+
+	var rows []usecase.ImportRow
+	for n := 1; ; n++ {
+		// tolerate line breaks some tools put between records
+		for {
+			b, err := br.Peek(1)
+			if err != nil || (b[0] != '\n' && b[0] != '\r') {
+				break
+			}
+			br.ReadByte()
+		}
+
+		leader := make([]byte, 24)
+		_, err := io.ReadFull(br, leader)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, marcError(n, "record is truncated")
+		}
+
+		length, err1 := strconv.Atoi(string(leader[0:5]))
+		base, err2 := strconv.Atoi(string(leader[12:17]))
+		if err1 != nil || err2 != nil || base < 25 || length <= base {
+			return nil, marcError(n, "leader is malformed")
+		}
+		rec := make([]byte, length)
+		copy(rec, leader)
+		if _, err := io.ReadFull(br, rec[24:]); err != nil {
+			return nil, marcError(n, "record is truncated")
+		}
+		if rec[length-1] != recordTerminator {
+			return nil, marcError(n, "record terminator is missing")
+		}
+
+		m, err := parseMARC(rec, base)
+		if err != nil {
+			return nil, marcError(n, err.Error())
+		}
+		rows = append(rows, m.row(n))
+	}
+
+	return rows, nil
+}
+
+func parseMARC(rec []byte, base int) (marcRecord, error) {
+	m := newMARCRecord()
+
+	dir := rec[24 : base-1]
+	if len(dir)%12 != 0 {
+		return m, errors.New("directory is malformed")
+	}
+	for i := 0; i < len(dir); i += 12 {
+		tag := string(dir[i : i+3])
+		length, err1 := strconv.Atoi(string(dir[i+3 : i+7]))
+		start, err2 := strconv.Atoi(string(dir[i+7 : i+12]))
+		if err1 != nil || err2 != nil || base+start+length > len(rec) {
+			return m, fmt.Errorf("directory entry %s is malformed", tag)
+		}
+		data := bytes.TrimSuffix(rec[base+start:base+start+length], []byte{fieldTerminator})
+
+		if tag < "010" {
+			m.control[tag] = string(data)
+			continue
+		}
+		var subfields []marcSubfield
+		// the first part holds the two indicators
+		for _, p := range bytes.Split(data, []byte{subfieldDelimiter})[1:] {
+			if len(p) == 0 {
+				continue
+			}
+			subfields = append(subfields, marcSubfield{code: p[0], value: string(p[1:])})
+		}
+		m.data[tag] = append(m.data[tag], subfields)
+	}
+
+	return m, nil
+}
+
+type marcXMLSubfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+type marcXMLControlField struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+type marcXMLDataField struct {
+	Tag       string            `xml:"tag,attr"`
+	Ind1      string            `xml:"ind1,attr"`
+	Ind2      string            `xml:"ind2,attr"`
+	Subfields []marcXMLSubfield `xml:"subfield"`
+}
+
+type marcXMLRecord struct {
+	XMLName       xml.Name              `xml:"record"`
+	Leader        string                `xml:"leader"`
+	ControlFields []marcXMLControlField `xml:"controlfield"`
+	DataFields    []marcXMLDataField    `xml:"datafield"`
+}
+
+// readMARCXML decodes the file one record at a time, so the whole
+// document is never held in memory.
+func readMARCXML(r io.Reader) ([]usecase.ImportRow, error) {
+	dec := xml.NewDecoder(r)
+
+	var rows []usecase.ImportRow
+	for n := 1; ; {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, marcError(n, err.Error())
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok || se.Name.Local != "record" {
+			continue
+		}
+
+		var xr marcXMLRecord
+		if err := dec.DecodeElement(&xr, &se); err != nil {
+			return nil, marcError(n, err.Error())
+		}
+		m := newMARCRecord()
+		for _, cf := range xr.ControlFields {
+			m.control[cf.Tag] = cf.Value
+		}
+		for _, df := range xr.DataFields {
+			var subfields []marcSubfield
+			for _, sf := range df.Subfields {
+				if sf.Code == "" {
+					continue
+				}
+				subfields = append(subfields, marcSubfield{code: sf.Code[0], value: sf.Value})
+			}
+			m.data[df.Tag] = append(m.data[df.Tag], subfields)
+		}
+		rows = append(rows, m.row(n))
+		n++
+	}
+
+	return rows, nil
+}
+
+func marcError(n int, msg string) error {
+	return fmt.Errorf("%w: marc record %d: %s", usecase.ErrInvalidArgument, n, msg)
+}
+
+type marcXMLWriter struct {
+	bw      *bufio.Writer
+	enc     *xml.Encoder
+	started bool
+}
+
+func newMARCXMLWriter(bw *bufio.Writer) *marcXMLWriter {
+	return &marcXMLWriter{bw: bw, enc: xml.NewEncoder(bw)}
+}
+
+func (w *marcXMLWriter) start() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+	_, err := w.bw.WriteString(xml.Header + `<collection xmlns="http://www.loc.gov/MARC21/slim">` + "\n")
+	return err
+}
+
+// Write writes the book as a record with the fields row reads back.
+func (w *marcXMLWriter) Write(b usecase.Book) error {
+	if err := w.start(); err != nil {
+		return err
+	}
+
+	rec := marcXMLRecord{
+		Leader:        "00000nam a2200000   4500",
+		ControlFields: []marcXMLControlField{{Tag: "001", Value: b.ID.String()}},
+	}
+	field := func(tag, ind1 string, subfields ...string) {
+		df := marcXMLDataField{Tag: tag, Ind1: ind1, Ind2: " "}
+		for i := 0; i < len(subfields); i += 2 {
+			if subfields[i+1] != "" {
+				df.Subfields = append(df.Subfields, marcXMLSubfield{Code: subfields[i], Value: subfields[i+1]})
+			}
+		}
+		if len(df.Subfields) > 0 {
+			rec.DataFields = append(rec.DataFields, df)
+		}
+	}
+	var year string
+	if b.Year != 0 {
+		year = strconv.Itoa(b.Year)
+	}
+	field("020", " ", "a", b.ISBN13)
+	field("100", "1", "a", b.Author)
+	field("245", "1", "a", b.Title)
+	field("264", " ", "c", year)
+	field("852", " ", "c", b.Location, "p", b.Code)
+
+	if err := w.enc.Encode(rec); err != nil {
+		return err
+	}
+	_, err := w.bw.WriteString("\n")
+	return err
+}
+
+func (w *marcXMLWriter) Close() error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	if _, err := w.bw.WriteString("</collection>\n"); err != nil {
+		return err
+	}
+	return w.bw.Flush()
+}
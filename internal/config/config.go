@@ -2,9 +2,30 @@ package config
 
 // Header constants.
 const (
-	HEADER_KEY_X_USER_ID = "X-User-Id"
+	HEADER_KEY_X_USER_ID     = "X-User-Id"
+	HEADER_KEY_AUTHORIZATION = "Authorization"
 )
 
+// Context keys.
 const (
-	ENV_KEY_APP_ENV = "APP_ENV"
+	CTX_KEY_PRINCIPAL = "principal"
+)
+
+const (
+	ENV_KEY_APP_ENV           = "APP_ENV"
+	ENV_KEY_IDENTITY_PROVIDER = "IDENTITY_PROVIDER"
+	ENV_KEY_NOTIFIER          = "NOTIFIER"
+	ENV_KEY_LOCAL_AUTH_SECRET = "LOCAL_AUTH_SECRET"
+)
+
+// Identity providers selectable with IDENTITY_PROVIDER.
+const (
+	IDENTITY_PROVIDER_FIREBASE = "firebase"
+	IDENTITY_PROVIDER_LOCAL    = "local"
+)
+
+// Notifiers selectable with NOTIFIER.
+const (
+	NOTIFIER_LOG  = "log"
+	NOTIFIER_SMTP = "smtp"
 )
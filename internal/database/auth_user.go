@@ -37,12 +37,52 @@ func (s *service) CreateAuthUser(ctx context.Context, au usecase.AuthUser) (usec
 	return u.ConvertToUsecase(), nil
 }
 
+func (s *service) GetAuthUserByUID(ctx context.Context, uid string) (usecase.AuthUser, error) {
+	var au AuthUser
+	err := s.db.
+		WithContext(ctx).
+		Preload("User").
+		Preload("User.Staffs").
+		Where("uid = ?", uid).
+		First(&au).
+		Error
+	if err != nil {
+		return usecase.AuthUser{}, err
+	}
+
+	return au.ConvertToUsecase(), nil
+}
+
+func (s *service) GetAuthUserByUserID(ctx context.Context, id uuid.UUID) (usecase.AuthUser, error) {
+	var au AuthUser
+	err := s.db.
+		WithContext(ctx).
+		Preload("User").
+		Preload("User.Staffs").
+		Where("user_id = ?", id).
+		First(&au).
+		Error
+	if err != nil {
+		return usecase.AuthUser{}, err
+	}
+
+	return au.ConvertToUsecase(), nil
+}
+
 func (a AuthUser) ConvertToUsecase() usecase.AuthUser {
-	return usecase.AuthUser{
+	au := usecase.AuthUser{
 		UID:        a.UID,
 		UserID:     a.UserID,
 		GlobalRole: a.GlobalRole,
 		CreatedAt:  a.CreateAt,
 		UpdatedAt:  a.UpdatedAt,
 	}
+	if a.User != nil {
+		u := a.User.ConvertToUsecase()
+		for _, st := range a.User.Staffs {
+			u.Staffs = append(u.Staffs, st.ConvertToUsecase())
+		}
+		au.User = &u
+	}
+	return au
 }
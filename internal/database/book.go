@@ -7,17 +7,19 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Book struct {
 	ID         uuid.UUID       `gorm:"column:id;primaryKey;type:uuid;default:uuid_generate_v4()"`
-	Title      string          `gorm:"column:title;type:varchar(255)"`
-	Author     string          `gorm:"column:author;type:varchar(255)"`
-	Year       int             `gorm:"column:year;type:int"`
 	Code       string          `gorm:"column:code;type:varchar(255);uniqueIndex:idx_lib_code"`
+	Location   string          `gorm:"column:location;type:varchar(255)"`
+	Condition  string          `gorm:"column:condition;type:varchar(20);not null;default:'GOOD'"`
 	CreatedAt  time.Time       `gorm:"column:created_at"`
 	UpdatedAt  time.Time       `gorm:"column:updated_at"`
 	DeletedAt  *gorm.DeletedAt `gorm:"column:deleted_at"`
+	WorkID     uuid.UUID       `gorm:"column:work_id;type:uuid;not null;index"`
+	Work       *Work           `gorm:"foreignKey:WorkID;"`
 	LibraryID  uuid.UUID       `gorm:"uniqueIndex:idx_lib_code"`
 	Library    *Library        `gorm:"foreignKey:LibraryID;"`
 	Borrowings []Borrowing
@@ -27,25 +29,129 @@ func (Book) TableName() string {
 	return "books"
 }
 
-func (s *service) ListBooks(ctx context.Context, opt usecase.ListBooksOption) ([]usecase.Book, int, error) {
-	var (
-		books  []Book
-		ubooks []usecase.Book
-		count  int64
+// availableCond matches the books neither lost, lent out nor put aside
+// for a ready hold.
+const availableCond = `books.condition <> ?
+	AND NOT EXISTS (
+		SELECT 1 FROM borrowings
+		WHERE borrowings.book_id = books.id
+		AND borrowings.returned_at IS NULL
+		AND borrowings.deleted_at IS NULL
 	)
+	AND NOT EXISTS (
+		SELECT 1 FROM holds
+		WHERE holds.book_id = books.id AND holds.status = ?
+	)`
 
-	db := s.db.Model([]Book{}).WithContext(ctx)
+type bookAvailability struct {
+	ID     uuid.UUID
+	Status string
+	DueAt  *time.Time
+}
+
+// availability returns the status of each of the books, and when the
+// lent out ones are due, in a single query.
+func (s *service) availability(ctx context.Context, ids uuid.UUIDs) (map[uuid.UUID]bookAvailability, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var rows []bookAvailability
+	err := s.db.
+		WithContext(ctx).
+		Table("books").
+		Select(`books.id, CASE
+			WHEN books.condition = ? THEN ?
+			WHEN loan.due_at IS NOT NULL THEN ?
+			WHEN EXISTS (
+				SELECT 1 FROM holds
+				WHERE holds.book_id = books.id AND holds.status = ?
+			) THEN ?
+			ELSE ?
+		END AS status, loan.due_at`,
+			usecase.BookConditionLost, usecase.BookStatusLost,
+			usecase.BookStatusOnLoan,
+			usecase.HoldStatusReady, usecase.BookStatusOnHold,
+			usecase.BookStatusAvailable).
+		Joins(`LEFT JOIN borrowings loan ON loan.book_id = books.id
+			AND loan.returned_at IS NULL AND loan.deleted_at IS NULL`).
+		Where("books.id IN ?", ids).
+		Scan(&rows).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[uuid.UUID]bookAvailability, len(rows))
+	for _, r := range rows {
+		m[r.ID] = r
+	}
+	return m, nil
+}
+
+// bookQuery returns a query on the books matching the filters of opt,
+// joined with their library and work.
+func (s *service) bookQuery(ctx context.Context, opt usecase.ListBooksOption) *gorm.DB {
+	db := s.db.
+		Model([]Book{}).
+		WithContext(ctx).
+		Joins("Library").
+		// the copy counts are only selected by withCopyCounts
+		Joins("Work", s.db.Omit("total_copies", "available_copies"))
+
+	if opt.IncludeDeleted {
+		db = db.Unscoped()
+	}
 
 	if opt.LibraryIDs != nil {
-		db = db.Where("library_id IN ?", opt.LibraryIDs)
+		db = db.Where("books.library_id IN ?", opt.LibraryIDs)
 	}
 
 	if opt.Title != "" {
-		db = db.Where("title ILIKE ?", "%"+opt.Title+"%")
+		db = db.Where(`"Work".title ILIKE ?`, "%"+opt.Title+"%")
 	}
 
 	if opt.IDs != nil {
-		db = db.Where("id IN ?", opt.IDs)
+		db = db.Where("books.id IN ?", opt.IDs)
+	}
+
+	if opt.WorkID != uuid.Nil {
+		db = db.Where("books.work_id = ?", opt.WorkID)
+	}
+
+	if opt.Codes != nil {
+		db = db.Where("books.code IN ?", opt.Codes)
+	}
+
+	if opt.ISBN13 != "" {
+		db = db.Where(`"Work".isbn13 = ?`, opt.ISBN13)
+	}
+
+	if opt.Query != "" {
+		db = searchWorks(db, `"Work"`, opt.Query)
+	}
+
+	if opt.SubjectIDs != nil {
+		db = db.Where("books.work_id"+subjectsCond, opt.SubjectIDs, len(opt.SubjectIDs))
+	}
+
+	if opt.Available {
+		db = db.Where(availableCond, usecase.BookConditionLost, usecase.HoldStatusReady)
+	}
+
+	return db
+}
+
+func (s *service) ListBooks(ctx context.Context, opt usecase.ListBooksOption) ([]usecase.Book, int, error) {
+	var (
+		books  []Book
+		ubooks []usecase.Book
+		count  int64
+	)
+
+	db := s.bookQuery(ctx, opt)
+	if opt.Query != "" && opt.SortBy == "" {
+		db = db.Order(searchRank(`"Work"`, opt.Query))
 	}
 
 	var (
@@ -58,9 +164,14 @@ func (s *service) ListBooks(ctx context.Context, opt usecase.ListBooksOption) ([
 	if opt.SortIn != "" {
 		orderIn = opt.SortIn
 	}
+	switch orderBy {
+	case "title", "author", "year":
+		orderBy = `"Work".` + orderBy
+	default:
+		orderBy = "books." + orderBy
+	}
 
 	err := db.
-		Joins("Library").
 		Count(&count).
 		Limit(opt.Limit).
 		Offset(opt.Skip).
@@ -72,22 +183,48 @@ func (s *service) ListBooks(ctx context.Context, opt usecase.ListBooksOption) ([
 		return nil, 0, err
 	}
 
+	ids := make(uuid.UUIDs, 0, len(books))
+	for _, b := range books {
+		ids = append(ids, b.ID)
+	}
+	avail, err := s.availability(ctx, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	for _, b := range books {
 		ub := b.ConvertToUsecase()
 		if b.Library != nil {
 			lib := b.Library.ConvertToUsecase()
 			ub.Library = &lib
 		}
+		ub.Status = avail[b.ID].Status
+		ub.DueAt = avail[b.ID].DueAt
 		ubooks = append(ubooks, ub)
 	}
 
 	return ubooks, int(count), nil
 }
 
+// ExportBooks calls fn with every book matching the filters of opt, in
+// order of id, reading batchSize rows at a time. Skip, Limit and the sort
+// options are ignored.
+func (s *service) ExportBooks(ctx context.Context, opt usecase.ListBooksOption, fn func(usecase.Book) error) error {
+	var books []Book
+	return s.bookQuery(ctx, opt).FindInBatches(&books, batchSize, func(*gorm.DB, int) error {
+		for _, b := range books {
+			if err := fn(b.ConvertToUsecase()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+}
+
 func (s *service) GetBookByID(ctx context.Context, id uuid.UUID) (usecase.Book, error) {
 	var b Book
 
-	err := s.db.WithContext(ctx).Preload("Library").Where("id = ?", id).First(&b).Error
+	err := s.db.WithContext(ctx).Preload("Library").Preload("Work").Where("id = ?", id).First(&b).Error
 	if err != nil {
 		return usecase.Book{}, err
 	}
@@ -98,15 +235,34 @@ func (s *service) GetBookByID(ctx context.Context, id uuid.UUID) (usecase.Book,
 		book.Library = &lib
 	}
 
+	avail, err := s.availability(ctx, uuid.UUIDs{id})
+	if err != nil {
+		return usecase.Book{}, err
+	}
+	book.Status = avail[id].Status
+	book.DueAt = avail[id].DueAt
+
 	return book, nil
 }
 
+// LockBook takes a row lock on the book, held until the surrounding
+// transaction ends.
+func (s *service) LockBook(ctx context.Context, id uuid.UUID) error {
+	return s.db.
+		WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		Where("id = ?", id).
+		First(&Book{}).
+		Error
+}
+
 func (s *service) CreateBook(ctx context.Context, book usecase.Book) (usecase.Book, error) {
 	b := Book{
-		Title:     book.Title,
-		Author:    book.Author,
-		Year:      book.Year,
 		Code:      book.Code,
+		Location:  book.Location,
+		Condition: book.Condition,
+		WorkID:    book.WorkID,
 		LibraryID: book.LibraryID,
 	}
 
@@ -114,23 +270,55 @@ func (s *service) CreateBook(ctx context.Context, book usecase.Book) (usecase.Bo
 	if err != nil {
 		return usecase.Book{}, err
 	}
-	return b.ConvertToUsecase(), nil
+	return s.GetBookByID(ctx, b.ID)
+}
+
+// CreateBooks inserts the books in batches.
+func (s *service) CreateBooks(ctx context.Context, books []usecase.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+
+	bs := make([]Book, 0, len(books))
+	for _, book := range books {
+		bs = append(bs, Book{
+			Code:      book.Code,
+			Location:  book.Location,
+			Condition: book.Condition,
+			WorkID:    book.WorkID,
+			LibraryID: book.LibraryID,
+		})
+	}
+
+	return s.db.WithContext(ctx).CreateInBatches(&bs, batchSize).Error
 }
 
 func (s *service) UpdateBook(ctx context.Context, book usecase.Book) (usecase.Book, error) {
 	b := Book{
-		Title:     book.Title,
-		Author:    book.Author,
-		Year:      book.Year,
+		ID:        book.ID,
 		Code:      book.Code,
-		LibraryID: book.LibraryID,
+		Location:  book.Location,
+		Condition: book.Condition,
+		WorkID:    book.WorkID,
 	}
 
 	err := s.db.WithContext(ctx).Updates(&b).Error
 	if err != nil {
 		return usecase.Book{}, err
 	}
-	return b.ConvertToUsecase(), nil
+	return s.GetBookByID(ctx, book.ID)
+}
+
+func (s *service) DeleteBook(ctx context.Context, id uuid.UUID) error {
+	return s.softDelete(ctx, &Book{}, id)
+}
+
+func (s *service) RestoreBook(ctx context.Context, id uuid.UUID) error {
+	return s.restore(ctx, &Book{}, id)
+}
+
+func (s *service) PurgeBook(ctx context.Context, id uuid.UUID) error {
+	return s.purge(ctx, &Book{}, id)
 }
 
 // Convert core model to Usecase
@@ -139,15 +327,23 @@ func (b Book) ConvertToUsecase() usecase.Book {
 	if b.DeletedAt != nil {
 		d = &b.DeletedAt.Time
 	}
-	return usecase.Book{
+	book := usecase.Book{
 		ID:        b.ID,
-		Title:     b.Title,
-		Author:    b.Author,
-		Year:      b.Year,
 		Code:      b.Code,
+		Location:  b.Location,
+		Condition: b.Condition,
+		WorkID:    b.WorkID,
 		LibraryID: b.LibraryID,
 		CreatedAt: b.CreatedAt,
 		UpdatedAt: b.UpdatedAt,
 		DeletedAt: d,
 	}
+	if b.Work != nil {
+		book.Title = b.Work.Title
+		book.Author = b.Work.Author
+		book.Year = b.Work.Year
+		book.ISBN13 = derefString(b.Work.ISBN13)
+		book.ISBN10 = derefString(b.Work.ISBN10)
+	}
+	return book
 }
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"librarease/internal/usecase"
+)
+
+func TestBookAvailability(t *testing.T) {
+	ctx := context.Background()
+	srv := New()
+	f := seedCheckout(t, srv, 5, 4, 2)
+	uc := usecase.New(srv, nil, nil)
+
+	if _, err := uc.CreateBorrowing(ctx, usecase.Borrowing{BookID: f.books[0].ID, SubscriptionID: f.subs[0].ID, StaffID: f.staff.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.UpdateBook(ctx, usecase.Book{ID: f.books[1].ID, Condition: usecase.BookConditionLost}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.PlaceHold(ctx, usecase.Hold{BookID: f.books[2].ID, SubscriptionID: f.subs[1].ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		usecase.BookStatusOnLoan,
+		usecase.BookStatusLost,
+		usecase.BookStatusOnHold,
+		usecase.BookStatusAvailable,
+	}
+	for i, b := range f.books {
+		got, err := uc.GetBookByID(ctx, b.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Status != want[i] || (got.DueAt != nil) != (i == 0) {
+			t.Errorf("book %d: status = %s, due %v, want %s", i, got.Status, got.DueAt, want[i])
+		}
+	}
+
+	books, total, err := uc.ListBooks(ctx, usecase.ListBooksOption{
+		WorkID:    f.books[0].WorkID,
+		Available: true,
+		Limit:     10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || books[0].ID != f.books[3].ID || books[0].Status != usecase.BookStatusAvailable {
+		t.Errorf("available books = %+v, want only book 3", books)
+	}
+}
+
+func TestDeleteBook(t *testing.T) {
+	ctx := context.Background()
+	srv := New()
+	f := seedCheckout(t, srv, 5, 1, 1)
+	uc := usecase.New(srv, nil, nil)
+	book := f.books[0]
+
+	b, err := uc.CreateBorrowing(ctx, usecase.Borrowing{BookID: book.ID, SubscriptionID: f.subs[0].ID, StaffID: f.staff.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := uc.DeleteBook(ctx, book.ID); !errors.Is(err, usecase.ErrBookOnLoan) {
+		t.Fatalf("delete on loan: err = %v, want ErrBookOnLoan", err)
+	}
+	if err := uc.DeleteWork(ctx, book.WorkID); !errors.Is(err, usecase.ErrWorkInUse) {
+		t.Errorf("delete work: err = %v, want ErrWorkInUse", err)
+	}
+	if err := uc.DeleteMembership(ctx, f.subs[0].MembershipID); !errors.Is(err, usecase.ErrMembershipInUse) {
+		t.Errorf("delete membership: err = %v, want ErrMembershipInUse", err)
+	}
+
+	if _, err := uc.ReturnBorrowing(ctx, b.ID, f.staff.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := uc.DeleteBook(ctx, book.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.GetBookByID(ctx, book.ID); !errors.Is(err, usecase.ErrNotFound) {
+		t.Errorf("get deleted: err = %v, want ErrNotFound", err)
+	}
+	for _, deleted := range []bool{false, true} {
+		_, total, err := uc.ListBooks(ctx, usecase.ListBooksOption{WorkID: book.WorkID, Limit: 10, IncludeDeleted: deleted})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := 0
+		if deleted {
+			want = 1
+		}
+		if total != want {
+			t.Errorf("include deleted %v: total = %d, want %d", deleted, total, want)
+		}
+	}
+
+	// the borrowing still references the book
+	if err := uc.PurgeBook(ctx, book.ID); !errors.Is(err, usecase.ErrInUse) {
+		t.Errorf("purge: err = %v, want ErrInUse", err)
+	}
+	if err := uc.RestoreBook(ctx, book.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.GetBookByID(ctx, book.ID); err != nil {
+		t.Errorf("get restored: %v", err)
+	}
+}
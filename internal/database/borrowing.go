@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"fmt"
 	"librarease/internal/usecase"
 	"time"
 
@@ -10,19 +11,22 @@ import (
 )
 
 type Borrowing struct {
-	ID             uuid.UUID     `gorm:"column:id;primaryKey;type:uuid;default:uuid_generate_v4()"`
-	BookID         uuid.UUID     `gorm:"column:book_id;type:uuid;"`
-	Book           *Book         `gorm:"foreignKey:BookID;references:ID"`
-	SubscriptionID uuid.UUID     `gorm:"column:subscription_id;type:uuid;"`
-	Subscription   *Subscription `gorm:"foreignKey:SubscriptionID;references:ID"`
-	StaffID        uuid.UUID     `gorm:"column:staff_id;type:uuid;"`
-	Staff          *Staff        `gorm:"foreignKey:StaffID;references:ID"`
-	BorrowedAt     time.Time     `gorm:"column:borrowed_at;default:now()"`
-	DueAt          time.Time     `gorm:"column:due_at"`
-	ReturnedAt     *time.Time    `gorm:"column:returned_at"`
-	CreatedAt      time.Time     `gorm:"column:created_at"`
-	UpdatedAt      time.Time     `gorm:"column:updated_at"`
-	DeletedAt      *gorm.DeletedAt
+	ID              uuid.UUID     `gorm:"column:id;primaryKey;type:uuid;default:uuid_generate_v4()"`
+	BookID          uuid.UUID     `gorm:"column:book_id;type:uuid;"`
+	Book            *Book         `gorm:"foreignKey:BookID;references:ID"`
+	SubscriptionID  uuid.UUID     `gorm:"column:subscription_id;type:uuid;"`
+	Subscription    *Subscription `gorm:"foreignKey:SubscriptionID;references:ID"`
+	StaffID         uuid.UUID     `gorm:"column:staff_id;type:uuid;"`
+	Staff           *Staff        `gorm:"foreignKey:StaffID;references:ID"`
+	BorrowedAt      time.Time     `gorm:"column:borrowed_at;default:now()"`
+	DueAt           time.Time     `gorm:"column:due_at"`
+	ReturnedAt      *time.Time    `gorm:"column:returned_at"`
+	ReturnedStaffID *uuid.UUID    `gorm:"column:returned_staff_id;type:uuid;"`
+	Fine            int           `gorm:"column:fine;default:0"`
+	RenewalCount    int           `gorm:"column:renewal_count;default:0"`
+	CreatedAt       time.Time     `gorm:"column:created_at"`
+	UpdatedAt       time.Time     `gorm:"column:updated_at"`
+	DeletedAt       *gorm.DeletedAt
 }
 
 func (Borrowing) TableName() string {
@@ -39,6 +43,10 @@ func (s *service) ListBorrowings(ctx context.Context, opt usecase.ListBorrowings
 
 	db := s.db.Model([]Borrowing{}).WithContext(ctx)
 
+	if opt.IncludeDeleted {
+		db = db.Unscoped()
+	}
+
 	if opt.BookID != "" {
 		db = db.Where("book_id = ?", opt.BookID)
 	}
@@ -54,6 +62,12 @@ func (s *service) ListBorrowings(ctx context.Context, opt usecase.ListBorrowings
 	if !opt.DueAt.IsZero() {
 		db = db.Where("due_at = ?", opt.DueAt)
 	}
+	if !opt.DueBefore.IsZero() {
+		db = db.Where("due_at < ?", opt.DueBefore)
+	}
+	if !opt.DueAfter.IsZero() {
+		db = db.Where("due_at > ?", opt.DueAfter)
+	}
 	if opt.ReturnedAt != nil {
 		db = db.Where("returned_at = ?", opt.ReturnedAt)
 	}
@@ -89,6 +103,7 @@ func (s *service) ListBorrowings(ctx context.Context, opt usecase.ListBorrowings
 
 	err := db.
 		Preload("Book").
+		Preload("Book.Work").
 		Preload("Staff").
 		Preload("Subscription").
 		Preload("Subscription.User").
@@ -150,6 +165,7 @@ func (s *service) GetBorrowingByID(ctx context.Context, id uuid.UUID) (usecase.B
 		Model(Borrowing{}).
 		WithContext(ctx).
 		Preload("Book").
+		Preload("Book.Work").
 		Preload("Staff").
 		Preload("Subscription").
 		Preload("Subscription.User").
@@ -233,6 +249,40 @@ func (s *service) UpdateBorrowing(ctx context.Context, b usecase.Borrowing) (use
 	return borrow.ConvertToUsecase(), nil
 }
 
+// ReturnBorrowing sets the return fields only while the borrowing is
+// still active, so concurrent returns cannot both succeed.
+func (s *service) ReturnBorrowing(ctx context.Context, b usecase.Borrowing) (usecase.Borrowing, error) {
+	res := s.db.
+		WithContext(ctx).
+		Model(&Borrowing{}).
+		Where("id = ? AND returned_at IS NULL", b.ID).
+		Updates(map[string]interface{}{
+			"returned_at":       b.ReturnedAt,
+			"returned_staff_id": b.ReturnedStaffID,
+			"fine":              b.Fine,
+		})
+	if res.Error != nil {
+		return usecase.Borrowing{}, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return usecase.Borrowing{}, fmt.Errorf("%w: borrowing %s", usecase.ErrAlreadyReturned, b.ID)
+	}
+
+	return s.GetBorrowingByID(ctx, b.ID)
+}
+
+func (s *service) DeleteBorrowing(ctx context.Context, id uuid.UUID) error {
+	return s.softDelete(ctx, &Borrowing{}, id)
+}
+
+func (s *service) RestoreBorrowing(ctx context.Context, id uuid.UUID) error {
+	return s.restore(ctx, &Borrowing{}, id)
+}
+
+func (s *service) PurgeBorrowing(ctx context.Context, id uuid.UUID) error {
+	return s.purge(ctx, &Borrowing{}, id)
+}
+
 // Convert core model to Usecase
 func (b Borrowing) ConvertToUsecase() usecase.Borrowing {
 	var d *time.Time
@@ -240,15 +290,18 @@ func (b Borrowing) ConvertToUsecase() usecase.Borrowing {
 		d = &b.DeletedAt.Time
 	}
 	return usecase.Borrowing{
-		ID:             b.ID,
-		BookID:         b.BookID,
-		SubscriptionID: b.SubscriptionID,
-		StaffID:        b.StaffID,
-		BorrowedAt:     b.BorrowedAt,
-		DueAt:          b.DueAt,
-		ReturnedAt:     b.ReturnedAt,
-		CreatedAt:      b.CreatedAt,
-		UpdatedAt:      b.UpdatedAt,
-		DeletedAt:      d,
+		ID:              b.ID,
+		BookID:          b.BookID,
+		SubscriptionID:  b.SubscriptionID,
+		StaffID:         b.StaffID,
+		BorrowedAt:      b.BorrowedAt,
+		DueAt:           b.DueAt,
+		ReturnedAt:      b.ReturnedAt,
+		ReturnedStaffID: b.ReturnedStaffID,
+		Fine:            b.Fine,
+		RenewalCount:    b.RenewalCount,
+		CreatedAt:       b.CreatedAt,
+		UpdatedAt:       b.UpdatedAt,
+		DeletedAt:       d,
 	}
 }
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"librarease/internal/usecase"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BorrowingRenewal is the history of due date extensions of a borrowing.
+type BorrowingRenewal struct {
+	ID            uuid.UUID  `gorm:"column:id;primaryKey;type:uuid;default:uuid_generate_v4()"`
+	BorrowingID   uuid.UUID  `gorm:"column:borrowing_id;type:uuid;index"`
+	Borrowing     *Borrowing `gorm:"foreignKey:BorrowingID;references:ID"`
+	StaffID       *uuid.UUID `gorm:"column:staff_id;type:uuid"`
+	Staff         *Staff     `gorm:"foreignKey:StaffID;references:ID"`
+	PreviousDueAt time.Time  `gorm:"column:previous_due_at"`
+	DueAt         time.Time  `gorm:"column:due_at"`
+	CreatedAt     time.Time  `gorm:"column:created_at"`
+}
+
+func (BorrowingRenewal) TableName() string {
+	return "borrowing_renewals"
+}
+
+// LockBorrowing takes a row lock on the borrowing, held until the
+// surrounding transaction ends.
+func (s *service) LockBorrowing(ctx context.Context, id uuid.UUID) error {
+	return s.db.
+		WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		Where("id = ?", id).
+		First(&Borrowing{}).
+		Error
+}
+
+// RenewBorrowing writes both the borrowing and its history, so callers
+// run it in a transaction.
+func (s *service) RenewBorrowing(ctx context.Context, r usecase.BorrowingRenewal) (usecase.Borrowing, error) {
+	err := s.db.
+		WithContext(ctx).
+		Model(&Borrowing{}).
+		Where("id = ?", r.BorrowingID).
+		Updates(map[string]interface{}{
+			"due_at":        r.DueAt,
+			"renewal_count": gorm.Expr("renewal_count + 1"),
+		}).
+		Error
+	if err != nil {
+		return usecase.Borrowing{}, err
+	}
+
+	err = s.db.WithContext(ctx).Create(&BorrowingRenewal{
+		BorrowingID:   r.BorrowingID,
+		StaffID:       r.StaffID,
+		PreviousDueAt: r.PreviousDueAt,
+		DueAt:         r.DueAt,
+	}).Error
+	if err != nil {
+		return usecase.Borrowing{}, err
+	}
+
+	return s.GetBorrowingByID(ctx, r.BorrowingID)
+}
+
+func (s *service) ListBorrowingRenewals(ctx context.Context, borrowingID uuid.UUID) ([]usecase.BorrowingRenewal, error) {
+	var renewals []BorrowingRenewal
+	err := s.db.
+		WithContext(ctx).
+		Where("borrowing_id = ?", borrowingID).
+		Order("created_at ASC").
+		Find(&renewals).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]usecase.BorrowingRenewal, 0, len(renewals))
+	for _, r := range renewals {
+		list = append(list, r.ConvertToUsecase())
+	}
+	return list, nil
+}
+
+// Convert core model to Usecase
+func (r BorrowingRenewal) ConvertToUsecase() usecase.BorrowingRenewal {
+	return usecase.BorrowingRenewal{
+		ID:            r.ID,
+		BorrowingID:   r.BorrowingID,
+		StaffID:       r.StaffID,
+		PreviousDueAt: r.PreviousDueAt,
+		DueAt:         r.DueAt,
+		CreatedAt:     r.CreatedAt,
+	}
+}
@@ -0,0 +1,171 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"librarease/internal/usecase"
+
+	"github.com/google/uuid"
+)
+
+type checkoutFixture struct {
+	staff usecase.Staff
+	subs  []usecase.Subscription
+	books []usecase.Book
+}
+
+// seedCheckout creates a library with a staff, the given number of copies
+// of one work and subscribers, each allowed loanLimit active loans.
+func seedCheckout(t *testing.T, srv *service, loanLimit, books, subscribers int) checkoutFixture {
+	t.Helper()
+	ctx := context.Background()
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var f checkoutFixture
+	lib, err := srv.CreateLibrary(ctx, usecase.Library{Name: "checkout"})
+	must(err)
+	u, err := srv.CreateUser(ctx, usecase.User{Name: "staff", Email: uuid.NewString() + "@example.com"})
+	must(err)
+	f.staff, err = srv.CreateStaff(ctx, usecase.Staff{Name: "staff", LibraryID: lib.ID, UserID: u.ID, Role: usecase.StaffRoleStaff})
+	must(err)
+	m, err := srv.CreateMembership(ctx, usecase.Membership{
+		Name:            "basic",
+		LibraryID:       lib.ID,
+		Duration:        30,
+		ActiveLoanLimit: loanLimit,
+		LoanPeriod:      7,
+		MaxRenewals:     2,
+	})
+	must(err)
+
+	for i := 0; i < subscribers; i++ {
+		u, err := srv.CreateUser(ctx, usecase.User{Name: "member", Email: uuid.NewString() + "@example.com"})
+		must(err)
+		sub, err := srv.CreateSubscription(ctx, usecase.Subscription{
+			UserID:          u.ID,
+			MembershipID:    m.ID,
+			ExpiresAt:       time.Now().AddDate(0, 0, m.Duration),
+			LoanPeriod:      m.LoanPeriod,
+			ActiveLoanLimit: m.ActiveLoanLimit,
+			MaxRenewals:     m.MaxRenewals,
+		})
+		must(err)
+		f.subs = append(f.subs, sub)
+	}
+	w, err := srv.CreateWork(ctx, usecase.Work{Title: "book", LibraryID: lib.ID})
+	must(err)
+	for i := 0; i < books; i++ {
+		b, err := srv.CreateBook(ctx, usecase.Book{Code: fmt.Sprintf("%s-%d", lib.ID, i), WorkID: w.ID, LibraryID: lib.ID})
+		must(err)
+		f.books = append(f.books, b)
+	}
+	return f
+}
+
+// checkoutConcurrently fires one CreateBorrowing per request at once and
+// returns the number of successes and the errors of the others.
+func checkoutConcurrently(uc usecase.Usecase, reqs []usecase.Borrowing) (int, []error) {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		ok   int
+		errs []error
+	)
+	start := make(chan struct{})
+	for _, req := range reqs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, err := uc.CreateBorrowing(context.Background(), req)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			ok++
+		}()
+	}
+	close(start)
+	wg.Wait()
+	return ok, errs
+}
+
+func TestCreateBorrowingConcurrentLoanLimit(t *testing.T) {
+	srv := New()
+	f := seedCheckout(t, srv, 2, 10, 1)
+	uc := usecase.New(srv, nil, nil)
+
+	var reqs []usecase.Borrowing
+	for _, b := range f.books {
+		reqs = append(reqs, usecase.Borrowing{BookID: b.ID, SubscriptionID: f.subs[0].ID, StaffID: f.staff.ID})
+	}
+
+	ok, errs := checkoutConcurrently(uc, reqs)
+	if ok != 2 {
+		t.Fatalf("successful checkouts = %d, want 2", ok)
+	}
+	for _, err := range errs {
+		if !errors.Is(err, usecase.ErrActiveLoanLimitReached) {
+			t.Errorf("error = %v, want %v", err, usecase.ErrActiveLoanLimitReached)
+		}
+	}
+}
+
+func TestCreateBorrowingConcurrentSameBook(t *testing.T) {
+	srv := New()
+	f := seedCheckout(t, srv, 5, 1, 10)
+	uc := usecase.New(srv, nil, nil)
+
+	var reqs []usecase.Borrowing
+	for _, sub := range f.subs {
+		reqs = append(reqs, usecase.Borrowing{BookID: f.books[0].ID, SubscriptionID: sub.ID, StaffID: f.staff.ID})
+	}
+
+	ok, errs := checkoutConcurrently(uc, reqs)
+	if ok != 1 {
+		t.Fatalf("successful checkouts = %d, want 1", ok)
+	}
+	for _, err := range errs {
+		if !errors.Is(err, usecase.ErrBookNotAvailable) {
+			t.Errorf("error = %v, want %v", err, usecase.ErrBookNotAvailable)
+		}
+	}
+}
+
+func TestCreateBorrowingSetsDueAndOpensLoan(t *testing.T) {
+	ctx := context.Background()
+	srv := New()
+	f := seedCheckout(t, srv, 5, 1, 1)
+	uc := usecase.New(srv, nil, nil)
+
+	returned := time.Now()
+	b, err := uc.CreateBorrowing(ctx, usecase.Borrowing{
+		BookID:         f.books[0].ID,
+		SubscriptionID: f.subs[0].ID,
+		StaffID:        f.staff.ID,
+		DueAt:          time.Now().AddDate(1, 0, 0),
+		ReturnedAt:     &returned,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.ReturnedAt != nil {
+		t.Errorf("returned at = %v, want an open loan", b.ReturnedAt)
+	}
+	want := time.Now().AddDate(0, 0, f.subs[0].LoanPeriod)
+	if d := b.DueAt.Sub(want); d < -time.Minute || d > time.Minute {
+		t.Errorf("due at = %v, want about %v", b.DueAt, want)
+	}
+}
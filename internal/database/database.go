@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"fmt"
+	"librarease/internal/usecase"
 	"log"
 	"os"
 	"strconv"
@@ -15,6 +16,9 @@ import (
 	"gorm.io/gorm/logger"
 )
 
+// batchSize is the number of rows inserted per statement by bulk inserts.
+const batchSize = 500
+
 // implements server/Service interface
 type service struct {
 	db *gorm.DB
@@ -29,12 +33,18 @@ var (
 	// dbInstance *service
 )
 
+// connString returns the connection string of the database configured
+// by the environment.
+func connString() string {
+	return fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable", username, password, host, port, database)
+}
+
 func New() *service {
 	// Reuse Connection
 	// if dbInstance != nil {
 	// 	return dbInstance
 	// }
-	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable", username, password, host, port, database)
+	connStr := connString()
 	// db, err := sql.Open("pgx", connStr)
 	// if err != nil {
 	// 	log.Fatal(err)
@@ -51,6 +61,10 @@ func New() *service {
 		log.Fatal(err)
 	}
 
+	if err := registerErrorTranslator(gormDB); err != nil {
+		log.Fatal(err)
+	}
+
 	db, err := gormDB.DB()
 	if err != nil {
 		log.Fatal(err)
@@ -63,34 +77,16 @@ func New() *service {
 	}
 	db.SetMaxOpenConns(maxOpenConnections)
 
-	_, err = db.Exec(`
-		CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
-	`)
+	// the schema is owned by the migrations, see Migrator
+	m, err := newMigrator(db)
 	if err != nil {
 		log.Fatal(err)
 	}
-
-	// migrate the schema
-	err = gormDB.AutoMigrate(
-		User{},
-		AuthUser{},
-		Library{},
-		Staff{},
-		Book{},
-		Membership{},
-		Subscription{},
-		Borrowing{},
-	)
-	if err != nil {
+	if _, err := m.Up(context.Background()); err != nil {
 		log.Fatal(err)
 	}
 
-	_, err = db.Exec(`
-        CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_book_id_returned_at_null
-        ON borrowings (book_id)
-        WHERE returned_at IS NULL
-		AND deleted_at IS NULL;
-    `)
+	err = gormDB.SetupJoinTable(&Work{}, "Subjects", &WorkSubject{})
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -98,6 +94,12 @@ func New() *service {
 	return &service{db: gormDB}
 }
 
+func (s *service) Transaction(ctx context.Context, fn func(usecase.Repository) error) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&service{db: tx})
+	})
+}
+
 // Health checks the health of the database connection by pinging the database.
 // It returns a map with keys indicating various health statistics.
 func (s *service) Health() map[string]string {
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"librarease/internal/usecase"
+)
+
+// softDelete sets deleted_at of the row of the model with the id,
+// failing with ErrNotFound when there is no such row left to delete.
+func (s *service) softDelete(ctx context.Context, model interface{}, id interface{}) error {
+	res := s.db.WithContext(ctx).Where("id = ?", id).Delete(model)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s %v", usecase.ErrNotFound, res.Statement.Table, id)
+	}
+	return nil
+}
+
+// restore clears deleted_at of the row of the model with the id. Rows
+// that are not deleted are left as they are.
+func (s *service) restore(ctx context.Context, model interface{}, id interface{}) error {
+	res := s.db.
+		WithContext(ctx).
+		Unscoped().
+		Model(model).
+		Where("id = ?", id).
+		Update("deleted_at", nil)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s %v", usecase.ErrNotFound, res.Statement.Table, id)
+	}
+	return nil
+}
+
+// purge deletes the row of the model with the id for good, deleted or
+// not. It fails with ErrInUse while other rows reference it.
+func (s *service) purge(ctx context.Context, model interface{}, id interface{}) error {
+	res := s.db.WithContext(ctx).Unscoped().Where("id = ?", id).Delete(model)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s %v", usecase.ErrNotFound, res.Statement.Table, id)
+	}
+	return nil
+}
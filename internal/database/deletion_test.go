@@ -0,0 +1,136 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"librarease/internal/usecase"
+
+	"github.com/google/uuid"
+)
+
+func TestDeleteRefusals(t *testing.T) {
+	ctx := context.Background()
+	srv := New()
+	f := seedCheckout(t, srv, 5, 1, 1)
+	uc := usecase.New(srv, nil, nil)
+	book, sub := f.books[0], f.subs[0]
+
+	b, err := uc.CreateBorrowing(ctx, usecase.Borrowing{BookID: book.ID, SubscriptionID: sub.ID, StaffID: f.staff.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := uc.DeleteBorrowing(ctx, b.ID); !errors.Is(err, usecase.ErrBookOnLoan) {
+		t.Errorf("delete unreturned borrowing: err = %v, want ErrBookOnLoan", err)
+	}
+	if err := uc.DeleteMembership(ctx, sub.MembershipID); !errors.Is(err, usecase.ErrMembershipInUse) {
+		t.Errorf("delete membership with an active subscription: err = %v, want ErrMembershipInUse", err)
+	}
+
+	if _, err := uc.ReturnBorrowing(ctx, b.ID, f.staff.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := uc.DeleteBorrowing(ctx, b.ID); err != nil {
+		t.Errorf("delete returned borrowing: %v", err)
+	}
+	// a work is in use by its copies, on loan or not
+	if err := uc.DeleteWork(ctx, book.WorkID); !errors.Is(err, usecase.ErrWorkInUse) {
+		t.Errorf("delete work with a copy: err = %v, want ErrWorkInUse", err)
+	}
+
+	// expired subscriptions do not keep the membership
+	if _, err := srv.UpdateSubscription(ctx, usecase.Subscription{ID: sub.ID, ExpiresAt: time.Now().Add(-time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := uc.DeleteMembership(ctx, sub.MembershipID); err != nil {
+		t.Fatalf("delete membership: %v", err)
+	}
+	_, err = uc.CreateSubscription(ctx, usecase.Subscription{UserID: sub.UserID, MembershipID: sub.MembershipID})
+	if !errors.Is(err, usecase.ErrNotFound) {
+		t.Errorf("subscribe to deleted membership: err = %v, want ErrNotFound", err)
+	}
+	if err := uc.RestoreMembership(ctx, sub.MembershipID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.CreateSubscription(ctx, usecase.Subscription{UserID: sub.UserID, MembershipID: sub.MembershipID}); err != nil {
+		t.Errorf("subscribe to restored membership: %v", err)
+	}
+}
+
+func TestRestoreAndPurge(t *testing.T) {
+	ctx := context.Background()
+	srv := New()
+	f := seedCheckout(t, srv, 5, 1, 1)
+	uc := usecase.New(srv, nil, nil)
+	book, sub := f.books[0], f.subs[0]
+
+	b, err := uc.CreateBorrowing(ctx, usecase.Borrowing{BookID: book.ID, SubscriptionID: sub.ID, StaffID: f.staff.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// records that others still refer to cannot be purged
+	for name, purge := range map[string]func() error{
+		"library":      func() error { return uc.PurgeLibrary(ctx, f.staff.LibraryID) },
+		"staff":        func() error { return uc.PurgeStaff(ctx, f.staff.ID) },
+		"user":         func() error { return uc.PurgeUser(ctx, sub.UserID) },
+		"membership":   func() error { return uc.PurgeMembership(ctx, sub.MembershipID) },
+		"subscription": func() error { return uc.PurgeSubscription(ctx, sub.ID) },
+		"work":         func() error { return uc.PurgeWork(ctx, book.WorkID) },
+		"book":         func() error { return uc.PurgeBook(ctx, book.ID) },
+	} {
+		if err := purge(); !errors.Is(err, usecase.ErrInUse) {
+			t.Errorf("purge %s: err = %v, want ErrInUse", name, err)
+		}
+	}
+
+	// records that do not exist cannot be restored or purged
+	unknown := uuid.New()
+	for name, fn := range map[string]func(context.Context, uuid.UUID) error{
+		"restore library":      uc.RestoreLibrary,
+		"restore staff":        uc.RestoreStaff,
+		"restore user":         uc.RestoreUser,
+		"restore membership":   uc.RestoreMembership,
+		"restore subscription": uc.RestoreSubscription,
+		"restore work":         uc.RestoreWork,
+		"restore book":         uc.RestoreBook,
+		"restore borrowing":    uc.RestoreBorrowing,
+		"purge library":        uc.PurgeLibrary,
+		"purge staff":          uc.PurgeStaff,
+		"purge user":           uc.PurgeUser,
+		"purge membership":     uc.PurgeMembership,
+		"purge subscription":   uc.PurgeSubscription,
+		"purge work":           uc.PurgeWork,
+		"purge book":           uc.PurgeBook,
+		"purge borrowing":      uc.PurgeBorrowing,
+	} {
+		if err := fn(ctx, unknown); !errors.Is(err, usecase.ErrNotFound) {
+			t.Errorf("%s: err = %v, want ErrNotFound", name, err)
+		}
+	}
+
+	// once nothing refers to them, records are purged for good
+	if _, err := uc.ReturnBorrowing(ctx, b.ID, f.staff.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := uc.DeleteBorrowing(ctx, b.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := uc.RestoreBorrowing(ctx, b.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.GetBorrowingByID(ctx, b.ID); err != nil {
+		t.Errorf("get restored borrowing: %v", err)
+	}
+	if err := uc.PurgeBorrowing(ctx, b.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := uc.RestoreBorrowing(ctx, b.ID); !errors.Is(err, usecase.ErrNotFound) {
+		t.Errorf("restore purged borrowing: err = %v, want ErrNotFound", err)
+	}
+	if err := uc.PurgeSubscription(ctx, sub.ID); err != nil {
+		t.Errorf("purge subscription without borrowings: %v", err)
+	}
+}
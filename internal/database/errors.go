@@ -0,0 +1,89 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"librarease/internal/usecase"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// Postgres error codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgForeignKeyViolation = "23503"
+	pgUniqueViolation     = "23505"
+	pgCheckViolation      = "23514"
+)
+
+// dbError tags a driver error with the usecase error it stands for.
+// Both stay in the chain, so errors.Is matches either.
+type dbError struct {
+	kind error
+	err  error
+}
+
+func (e *dbError) Error() string   { return fmt.Sprintf("%v: %v", e.kind, e.err) }
+func (e *dbError) Unwrap() []error { return []error{e.kind, e.err} }
+
+// translateError maps gorm and postgres errors to usecase errors.
+func translateError(err error) error {
+	var de *dbError
+	if err == nil || errors.As(err, &de) {
+		return err
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &dbError{usecase.ErrNotFound, err}
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	switch pgErr.Code {
+	case pgUniqueViolation:
+		return &dbError{usecase.ErrAlreadyExists, err}
+	case pgForeignKeyViolation:
+		return &dbError{usecase.ErrReferenceNotFound, err}
+	case pgCheckViolation:
+		return &dbError{usecase.ErrInvalidArgument, err}
+	}
+	return err
+}
+
+// translateDeleteError maps a foreign key violation on delete, raised
+// because other rows still reference the deleted one, to ErrInUse.
+func translateDeleteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation {
+		return &dbError{usecase.ErrInUse, err}
+	}
+	return translateError(err)
+}
+
+// registerErrorTranslator runs translateError after every statement, so
+// repository methods return usecase errors without handling them one by one.
+func registerErrorTranslator(db *gorm.DB) error {
+	const name = "librarease:translate_error"
+	translate := func(tx *gorm.DB) {
+		tx.Error = translateError(tx.Error)
+	}
+	translateDelete := func(tx *gorm.DB) {
+		tx.Error = translateDeleteError(tx.Error)
+	}
+
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().After("*").Register(name, translate),
+		cb.Query().After("*").Register(name, translate),
+		cb.Update().After("*").Register(name, translate),
+		cb.Delete().After("*").Register(name, translateDelete),
+		cb.Row().After("*").Register(name, translate),
+		cb.Raw().After("*").Register(name, translate),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
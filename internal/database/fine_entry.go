@@ -0,0 +1,223 @@
+package database
+
+import (
+	"context"
+	"librarease/internal/usecase"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FineEntry is a line of the fines ledger. Entries are never updated,
+// except the amount of a borrowing's charge while its fine accrues, and
+// archived along with their library.
+type FineEntry struct {
+	ID          uuid.UUID  `gorm:"column:id;primaryKey;type:uuid;default:uuid_generate_v4()"`
+	Kind        string     `gorm:"column:kind;type:varchar(16)"`
+	Amount      int        `gorm:"column:amount"`
+	UserID      uuid.UUID  `gorm:"column:user_id;type:uuid;index:idx_fine_entries_user_library"`
+	User        *User      `gorm:"foreignKey:UserID;references:ID"`
+	LibraryID   uuid.UUID  `gorm:"column:library_id;type:uuid;index:idx_fine_entries_user_library"`
+	Library     *Library   `gorm:"foreignKey:LibraryID;references:ID"`
+	BorrowingID *uuid.UUID `gorm:"column:borrowing_id;type:uuid;index"`
+	Borrowing   *Borrowing `gorm:"foreignKey:BorrowingID;references:ID"`
+	StaffID     *uuid.UUID `gorm:"column:staff_id;type:uuid"`
+	Staff       *Staff     `gorm:"foreignKey:StaffID;references:ID"`
+	Note        string     `gorm:"column:note;type:varchar(255)"`
+	CreatedAt   time.Time  `gorm:"column:created_at"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at"`
+	// DeletedAt is set while the library is deleted.
+	DeletedAt *gorm.DeletedAt `gorm:"column:deleted_at"`
+}
+
+func (FineEntry) TableName() string {
+	return "fine_entries"
+}
+
+func (s *service) ListFineEntries(ctx context.Context, opt usecase.ListFineEntriesOption) ([]usecase.FineEntry, int, error) {
+	var (
+		entries  []FineEntry
+		uentries []usecase.FineEntry
+		count    int64
+	)
+
+	db := s.db.Model([]FineEntry{}).WithContext(ctx)
+
+	if opt.UserID != "" {
+		db = db.Where("user_id = ?", opt.UserID)
+	}
+	if opt.LibraryID != "" {
+		db = db.Where("library_id = ?", opt.LibraryID)
+	}
+	if opt.BorrowingID != "" {
+		db = db.Where("borrowing_id = ?", opt.BorrowingID)
+	}
+	if opt.Kind != "" {
+		db = db.Where("kind = ?", opt.Kind)
+	}
+
+	var (
+		orderIn = "DESC"
+		orderBy = "created_at"
+	)
+	if opt.SortBy != "" {
+		orderBy = opt.SortBy
+	}
+	if opt.SortIn != "" {
+		orderIn = opt.SortIn
+	}
+
+	err := db.
+		Preload("User").
+		Preload("Library").
+		Preload("Staff").
+		Count(&count).
+		Limit(opt.Limit).
+		Offset(opt.Skip).
+		Order(orderBy + " " + orderIn).
+		Find(&entries).
+		Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, e := range entries {
+		ue := e.ConvertToUsecase()
+		if e.User != nil {
+			user := e.User.ConvertToUsecase()
+			ue.User = &user
+		}
+		if e.Library != nil {
+			lib := e.Library.ConvertToUsecase()
+			ue.Library = &lib
+		}
+		if e.Staff != nil {
+			staff := e.Staff.ConvertToUsecase()
+			ue.Staff = &staff
+		}
+		uentries = append(uentries, ue)
+	}
+
+	return uentries, int(count), nil
+}
+
+func (s *service) CreateFineEntry(ctx context.Context, e usecase.FineEntry) (usecase.FineEntry, error) {
+	d := FineEntry{
+		Kind:        e.Kind,
+		Amount:      e.Amount,
+		UserID:      e.UserID,
+		LibraryID:   e.LibraryID,
+		BorrowingID: e.BorrowingID,
+		StaffID:     e.StaffID,
+		Note:        e.Note,
+	}
+	err := s.db.WithContext(ctx).Create(&d).Error
+	if err != nil {
+		return usecase.FineEntry{}, err
+	}
+
+	return d.ConvertToUsecase(), nil
+}
+
+// UpsertFineCharge relies on the partial unique index on the charges of
+// a borrowing, created in migration 0002. Its predicate is written inline, since
+// Postgres cannot match the index against a bound parameter.
+func (s *service) UpsertFineCharge(ctx context.Context, e usecase.FineEntry) (usecase.FineEntry, error) {
+	d := FineEntry{
+		Kind:        usecase.FineKindCharge,
+		Amount:      e.Amount,
+		UserID:      e.UserID,
+		LibraryID:   e.LibraryID,
+		BorrowingID: e.BorrowingID,
+		Note:        e.Note,
+	}
+	err := s.db.
+		WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "borrowing_id"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "kind = '" + usecase.FineKindCharge + "'"}}},
+			DoUpdates:   clause.AssignmentColumns([]string{"amount", "updated_at"}),
+		}).
+		Create(&d).
+		Error
+	if err != nil {
+		return usecase.FineEntry{}, err
+	}
+
+	return d.ConvertToUsecase(), nil
+}
+
+// LockFineBalance takes a transaction-level advisory lock on the user and
+// library pair. Rows cannot be locked for a balance that may have no
+// entries yet.
+func (s *service) LockFineBalance(ctx context.Context, userID, libraryID uuid.UUID) error {
+	return s.db.
+		WithContext(ctx).
+		Exec("SELECT pg_advisory_xact_lock(hashtext(?), hashtext(?))", userID.String(), libraryID.String()).
+		Error
+}
+
+func (s *service) GetFineBalance(ctx context.Context, userID, libraryID uuid.UUID) (usecase.FineBalance, error) {
+	var rows []struct {
+		Kind  string
+		Total int
+	}
+	err := s.db.
+		WithContext(ctx).
+		Model(&FineEntry{}).
+		Select("kind, COALESCE(SUM(amount), 0) AS total").
+		Where("user_id = ? AND library_id = ?", userID, libraryID).
+		Group("kind").
+		Scan(&rows).
+		Error
+	if err != nil {
+		return usecase.FineBalance{}, err
+	}
+
+	bal := usecase.FineBalance{UserID: userID, LibraryID: libraryID}
+	for _, r := range rows {
+		switch r.Kind {
+		case usecase.FineKindCharge:
+			bal.Charged = r.Total
+		case usecase.FineKindPayment:
+			bal.Paid = r.Total
+		case usecase.FineKindWaiver:
+			bal.Waived = r.Total
+		}
+	}
+	bal.Outstanding = bal.Charged - bal.Paid - bal.Waived
+
+	return bal, nil
+}
+
+func (s *service) SumFineCharges(ctx context.Context, userID, libraryID uuid.UUID, dueAfter time.Time) (int, error) {
+	var total int
+	err := s.db.
+		WithContext(ctx).
+		Model(&FineEntry{}).
+		Select("COALESCE(SUM(fine_entries.amount), 0)").
+		Joins("JOIN borrowings ON borrowings.id = fine_entries.borrowing_id").
+		Where("fine_entries.user_id = ? AND fine_entries.library_id = ?", userID, libraryID).
+		Where("fine_entries.kind = ? AND borrowings.due_at > ?", usecase.FineKindCharge, dueAfter).
+		Scan(&total).
+		Error
+	return total, err
+}
+
+// Convert core model to Usecase
+func (e FineEntry) ConvertToUsecase() usecase.FineEntry {
+	return usecase.FineEntry{
+		ID:          e.ID,
+		Kind:        e.Kind,
+		Amount:      e.Amount,
+		UserID:      e.UserID,
+		LibraryID:   e.LibraryID,
+		BorrowingID: e.BorrowingID,
+		StaffID:     e.StaffID,
+		Note:        e.Note,
+		CreatedAt:   e.CreatedAt,
+		UpdatedAt:   e.UpdatedAt,
+	}
+}
@@ -0,0 +1,161 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"librarease/internal/usecase"
+)
+
+// seedFine lends the first book of the fixture to its first subscriber at
+// 100 a day, due three started days ago, and returns the borrowing.
+func seedFine(t *testing.T, srv *service, uc usecase.Usecase, f checkoutFixture) usecase.Borrowing {
+	t.Helper()
+	ctx := context.Background()
+
+	if _, err := srv.UpdateSubscription(ctx, usecase.Subscription{ID: f.subs[0].ID, FinePerDay: 100}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := uc.CreateBorrowing(ctx, usecase.Borrowing{BookID: f.books[0].ID, SubscriptionID: f.subs[0].ID, StaffID: f.staff.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.UpdateBorrowing(ctx, usecase.Borrowing{ID: b.ID, DueAt: time.Now().Add(-49 * time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestFineLedger(t *testing.T) {
+	ctx := context.Background()
+	srv := New()
+	f := seedCheckout(t, srv, 5, 2, 1)
+	uc := usecase.New(srv, nil, nil)
+	user, lib := f.subs[0].UserID, f.staff.LibraryID
+	b := seedFine(t, srv, uc, f)
+
+	charges := func() []usecase.FineEntry {
+		t.Helper()
+		entries, _, err := uc.ListFineEntries(ctx, usecase.ListFineEntriesOption{BorrowingID: b.ID.String(), Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return entries
+	}
+	balance := func() usecase.FineBalance {
+		t.Helper()
+		bal, err := uc.GetFineBalance(ctx, user, lib)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return bal
+	}
+
+	// accruing again and returning update the one charge of the borrowing
+	for range 2 {
+		if _, err := uc.AccrueFines(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := charges(); len(got) != 1 || got[0].Amount != 300 {
+		t.Fatalf("charges after accruing = %+v, want one of 300", got)
+	}
+	if _, err := uc.ReturnBorrowing(ctx, b.ID, f.staff.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := charges(); len(got) != 1 || got[0].Kind != usecase.FineKindCharge || got[0].Amount != 300 {
+		t.Fatalf("charges after return = %+v, want one of 300", got)
+	}
+
+	settle := usecase.FineEntry{UserID: user, LibraryID: lib, StaffID: &f.staff.ID}
+	settle.Amount = 100
+	if _, err := uc.PayFine(ctx, settle); err != nil {
+		t.Fatal(err)
+	}
+	settle.Amount = 50
+	if _, err := uc.WaiveFine(ctx, settle); err != nil {
+		t.Fatal(err)
+	}
+	want := usecase.FineBalance{UserID: user, LibraryID: lib, Charged: 300, Paid: 100, Waived: 50, Outstanding: 150}
+	if got := balance(); got != want {
+		t.Errorf("balance = %+v, want %+v", got, want)
+	}
+
+	settle.Amount = 151
+	if _, err := uc.PayFine(ctx, settle); !errors.Is(err, usecase.ErrInvalidArgument) {
+		t.Errorf("overpay: err = %v, want ErrInvalidArgument", err)
+	}
+
+	limit := 100
+	if _, err := uc.UpdateSetting(ctx, usecase.Setting{LibraryID: lib, MaxOutstandingFine: &limit}); err != nil {
+		t.Fatal(err)
+	}
+	next := usecase.Borrowing{BookID: f.books[1].ID, SubscriptionID: f.subs[0].ID, StaffID: f.staff.ID}
+	if _, err := uc.CreateBorrowing(ctx, next); !errors.Is(err, usecase.ErrOutstandingFines) {
+		t.Errorf("borrow owing 150: err = %v, want ErrOutstandingFines", err)
+	}
+	settle.Amount = 50
+	if _, err := uc.PayFine(ctx, settle); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.CreateBorrowing(ctx, next); err != nil {
+		t.Errorf("borrow owing 100: %v", err)
+	}
+}
+
+func TestSettleFineConcurrently(t *testing.T) {
+	ctx := context.Background()
+	srv := New()
+	f := seedCheckout(t, srv, 5, 1, 1)
+	uc := usecase.New(srv, nil, nil)
+	b := seedFine(t, srv, uc, f)
+	if _, err := uc.ReturnBorrowing(ctx, b.ID, f.staff.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// each pays the whole balance of 300
+	const payers = 5
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		ok   int
+		errs []error
+	)
+	start := make(chan struct{})
+	for range payers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, err := uc.PayFine(ctx, usecase.FineEntry{Amount: 300, UserID: f.subs[0].UserID, LibraryID: f.staff.LibraryID, StaffID: &f.staff.ID})
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			ok++
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if ok != 1 {
+		t.Errorf("%d payments went through, want 1", ok)
+	}
+	for _, err := range errs {
+		if !errors.Is(err, usecase.ErrInvalidArgument) {
+			t.Errorf("err = %v, want ErrInvalidArgument", err)
+		}
+	}
+	bal, err := uc.GetFineBalance(ctx, f.subs[0].UserID, f.staff.LibraryID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bal.Outstanding != 0 {
+		t.Errorf("outstanding = %d, want 0", bal.Outstanding)
+	}
+}
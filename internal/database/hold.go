@@ -0,0 +1,184 @@
+package database
+
+import (
+	"context"
+	"librarease/internal/usecase"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Hold struct {
+	ID             uuid.UUID     `gorm:"column:id;primaryKey;type:uuid;default:uuid_generate_v4()"`
+	BookID         uuid.UUID     `gorm:"column:book_id;type:uuid;index"`
+	Book           *Book         `gorm:"foreignKey:BookID;references:ID"`
+	SubscriptionID uuid.UUID     `gorm:"column:subscription_id;type:uuid;index"`
+	Subscription   *Subscription `gorm:"foreignKey:SubscriptionID;references:ID"`
+	StaffID        *uuid.UUID    `gorm:"column:staff_id;type:uuid"`
+	Staff          *Staff        `gorm:"foreignKey:StaffID;references:ID"`
+	Status         string        `gorm:"column:status;type:varchar(16)"`
+	ReadyAt        *time.Time    `gorm:"column:ready_at"`
+	ExpiresAt      *time.Time    `gorm:"column:expires_at"`
+	ClosedAt       *time.Time    `gorm:"column:closed_at"`
+	BorrowingID    *uuid.UUID    `gorm:"column:borrowing_id;type:uuid"`
+	Borrowing      *Borrowing    `gorm:"foreignKey:BorrowingID;references:ID"`
+	CreatedAt      time.Time     `gorm:"column:created_at"`
+	UpdatedAt      time.Time     `gorm:"column:updated_at"`
+}
+
+func (Hold) TableName() string {
+	return "holds"
+}
+
+func (s *service) ListHolds(ctx context.Context, opt usecase.ListHoldsOption) ([]usecase.Hold, int, error) {
+	var (
+		holds  []Hold
+		uholds []usecase.Hold
+		count  int64
+	)
+
+	db := s.db.Model([]Hold{}).WithContext(ctx)
+
+	if opt.BookID != "" {
+		db = db.Where("holds.book_id = ?", opt.BookID)
+	}
+	if opt.SubscriptionID != "" {
+		db = db.Where("holds.subscription_id = ?", opt.SubscriptionID)
+	}
+	if len(opt.Statuses) > 0 {
+		db = db.Where("holds.status IN ?", opt.Statuses)
+	}
+	if opt.IsExpired {
+		db = db.Where("holds.status = ? AND holds.expires_at < now()", usecase.HoldStatusReady)
+	}
+	if opt.SubscriptionExpired {
+		db = db.Where("holds.subscription_id IN (SELECT id FROM subscriptions WHERE expires_at < now())")
+	}
+	if opt.UserID != "" {
+		db = db.Joins("Subscription").Where("user_id = ?", opt.UserID)
+	}
+	if opt.LibraryID != "" {
+		db = db.Joins("Book").Where("library_id = ?", opt.LibraryID)
+	}
+
+	var (
+		orderIn = "DESC"
+		orderBy = "created_at"
+	)
+	if opt.SortBy != "" {
+		orderBy = opt.SortBy
+	}
+	if opt.SortIn != "" {
+		orderIn = opt.SortIn
+	}
+
+	err := db.
+		Preload("Book").
+		Preload("Book.Work").
+		Preload("Subscription").
+		Preload("Subscription.User").
+		Count(&count).
+		Limit(opt.Limit).
+		Offset(opt.Skip).
+		Order("holds." + orderBy + " " + orderIn).
+		Find(&holds).
+		Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, h := range holds {
+		uholds = append(uholds, h.convertWithAssociations())
+	}
+
+	return uholds, int(count), nil
+}
+
+func (s *service) GetHoldByID(ctx context.Context, id uuid.UUID) (usecase.Hold, error) {
+	var h Hold
+	err := s.db.
+		WithContext(ctx).
+		Preload("Book").
+		Preload("Book.Work").
+		Preload("Subscription").
+		Preload("Subscription.User").
+		Where("id = ?", id).
+		First(&h).
+		Error
+	if err != nil {
+		return usecase.Hold{}, err
+	}
+
+	return h.convertWithAssociations(), nil
+}
+
+func (s *service) CreateHold(ctx context.Context, h usecase.Hold) (usecase.Hold, error) {
+	d := Hold{
+		BookID:         h.BookID,
+		SubscriptionID: h.SubscriptionID,
+		StaffID:        h.StaffID,
+		Status:         h.Status,
+	}
+	err := s.db.WithContext(ctx).Create(&d).Error
+	if err != nil {
+		return usecase.Hold{}, err
+	}
+
+	return d.ConvertToUsecase(), nil
+}
+
+// UpdateHold writes the state of the hold, the book and subscription
+// never change.
+func (s *service) UpdateHold(ctx context.Context, h usecase.Hold) (usecase.Hold, error) {
+	err := s.db.
+		WithContext(ctx).
+		Model(&Hold{}).
+		Where("id = ?", h.ID).
+		Updates(map[string]interface{}{
+			"status":       h.Status,
+			"ready_at":     h.ReadyAt,
+			"expires_at":   h.ExpiresAt,
+			"closed_at":    h.ClosedAt,
+			"borrowing_id": h.BorrowingID,
+		}).
+		Error
+	if err != nil {
+		return usecase.Hold{}, err
+	}
+
+	return s.GetHoldByID(ctx, h.ID)
+}
+
+func (h Hold) convertWithAssociations() usecase.Hold {
+	uh := h.ConvertToUsecase()
+	if h.Book != nil {
+		book := h.Book.ConvertToUsecase()
+		uh.Book = &book
+	}
+	if h.Subscription != nil {
+		sub := h.Subscription.ConvertToUsecase()
+		if h.Subscription.User != nil {
+			user := h.Subscription.User.ConvertToUsecase()
+			sub.User = &user
+		}
+		uh.Subscription = &sub
+	}
+	return uh
+}
+
+// Convert core model to Usecase
+func (h Hold) ConvertToUsecase() usecase.Hold {
+	return usecase.Hold{
+		ID:             h.ID,
+		BookID:         h.BookID,
+		SubscriptionID: h.SubscriptionID,
+		StaffID:        h.StaffID,
+		Status:         h.Status,
+		ReadyAt:        h.ReadyAt,
+		ExpiresAt:      h.ExpiresAt,
+		ClosedAt:       h.ClosedAt,
+		BorrowingID:    h.BorrowingID,
+		CreatedAt:      h.CreatedAt,
+		UpdatedAt:      h.UpdatedAt,
+	}
+}
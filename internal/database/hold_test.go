@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"librarease/internal/usecase"
+)
+
+func TestHoldQueue(t *testing.T) {
+	ctx := context.Background()
+	srv := New()
+	f := seedCheckout(t, srv, 5, 1, 3)
+	uc := usecase.New(srv, nil, nil)
+	book := f.books[0]
+	lender, first, second := f.subs[0], f.subs[1], f.subs[2]
+
+	b, err := uc.CreateBorrowing(ctx, usecase.Borrowing{BookID: book.ID, SubscriptionID: lender.ID, StaffID: f.staff.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h1, err := uc.PlaceHold(ctx, usecase.Hold{BookID: book.ID, SubscriptionID: first.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.PlaceHold(ctx, usecase.Hold{BookID: book.ID, SubscriptionID: second.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if h1.Status != usecase.HoldStatusWaiting {
+		t.Fatalf("hold status = %s, want %s", h1.Status, usecase.HoldStatusWaiting)
+	}
+	if _, err := uc.RenewBorrowing(ctx, b.ID, nil); !errors.Is(err, usecase.ErrBookOnHold) {
+		t.Fatalf("renew error = %v, want %v", err, usecase.ErrBookOnHold)
+	}
+
+	if _, err := uc.ReturnBorrowing(ctx, b.ID, f.staff.ID); err != nil {
+		t.Fatal(err)
+	}
+	h1, err = uc.GetHoldByID(ctx, h1.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h1.Status != usecase.HoldStatusReady || h1.ExpiresAt == nil {
+		t.Fatalf("hold = %s expiring at %v, want %s with a deadline", h1.Status, h1.ExpiresAt, usecase.HoldStatusReady)
+	}
+
+	_, err = uc.CreateBorrowing(ctx, usecase.Borrowing{BookID: book.ID, SubscriptionID: second.ID, StaffID: f.staff.ID})
+	if !errors.Is(err, usecase.ErrBookOnHold) {
+		t.Fatalf("checkout by second in line error = %v, want %v", err, usecase.ErrBookOnHold)
+	}
+	b, err = uc.CreateBorrowing(ctx, usecase.Borrowing{BookID: book.ID, SubscriptionID: first.ID, StaffID: f.staff.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h1, err = uc.GetHoldByID(ctx, h1.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h1.Status != usecase.HoldStatusFulfilled || h1.BorrowingID == nil || *h1.BorrowingID != b.ID {
+		t.Errorf("hold = %s by %v, want %s by %s", h1.Status, h1.BorrowingID, usecase.HoldStatusFulfilled, b.ID)
+	}
+}
@@ -2,11 +2,13 @@ package database
 
 import (
 	"context"
+	"fmt"
 	"librarease/internal/usecase"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Library struct {
@@ -34,6 +36,10 @@ func (s *service) ListLibraries(ctx context.Context, opt usecase.ListLibrariesOp
 
 	db := s.db.Model([]Library{}).WithContext(ctx)
 
+	if opt.IncludeDeleted {
+		db = db.Unscoped()
+	}
+
 	if opt.Name != "" {
 		db = db.Where("name ILIKE ?", "%"+opt.Name+"%")
 	}
@@ -128,15 +134,147 @@ func (s *service) UpdateLibrary(ctx context.Context, library usecase.Library) (u
 	}, nil
 }
 
+// LockLibrary locks the library, then its subscriptions and books in the
+// order checkouts lock them, so that nothing is borrowed meanwhile.
+func (s *service) LockLibrary(ctx context.Context, id uuid.UUID) error {
+	lock := clause.Locking{Strength: "UPDATE"}
+	err := s.db.
+		WithContext(ctx).
+		Clauses(lock).
+		Select("id").
+		Where("id = ?", id).
+		First(&Library{}).
+		Error
+	if err != nil {
+		return err
+	}
+
+	err = s.db.
+		WithContext(ctx).
+		Clauses(lock).
+		Select("id").
+		Where("membership_id IN (?)", s.db.Model(&Membership{}).Select("id").Where("library_id = ?", id)).
+		Find(&[]Subscription{}).
+		Error
+	if err != nil {
+		return err
+	}
+
+	return s.db.
+		WithContext(ctx).
+		Clauses(lock).
+		Select("id").
+		Where("library_id = ?", id).
+		Find(&[]Book{}).
+		Error
+}
+
+// DeleteLibrary soft-deletes the library and the rows that belong to it,
+// all at the same time so that RestoreLibrary can tell them from the rows
+// deleted before. Borrowings on loan are left alone. It writes several
+// tables, so callers run it in a transaction.
 func (s *service) DeleteLibrary(ctx context.Context, id string) error {
-	err := s.db.WithContext(ctx).Where("id = ?", id).Delete(&Library{}).Error
+	now := time.Now()
+	db := s.db.WithContext(ctx)
+
+	res := db.Model(&Library{}).Where("id = ?", id).Update("deleted_at", now)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w: library %s", usecase.ErrNotFound, id)
+	}
+
+	ofLibrary := func(model interface{}) *gorm.DB {
+		return s.db.Model(model).Select("id").Where("library_id = ?", id)
+	}
+
+	err := db.
+		Model(&Hold{}).
+		Where("status IN ? AND book_id IN (?)", []string{usecase.HoldStatusWaiting, usecase.HoldStatusReady}, ofLibrary(&Book{})).
+		Updates(map[string]interface{}{"status": usecase.HoldStatusCancelled, "closed_at": now}).
+		Error
 	if err != nil {
 		return err
 	}
 
+	// borrowings and subscriptions first, as they are found through the
+	// books and memberships
+	for _, r := range []struct {
+		model interface{}
+		query string
+		arg   interface{}
+	}{
+		{&Borrowing{}, "returned_at IS NOT NULL AND book_id IN (?)", ofLibrary(&Book{})},
+		{&Subscription{}, "membership_id IN (?)", ofLibrary(&Membership{})},
+		{&Membership{}, "library_id = ?", id},
+		{&Staff{}, "library_id = ?", id},
+		{&Book{}, "library_id = ?", id},
+		{&Work{}, "library_id = ?", id},
+		{&Subject{}, "library_id = ?", id},
+		{&Setting{}, "library_id = ?", id},
+		{&FineEntry{}, "library_id = ?", id},
+	} {
+		if err := db.Model(r.model).Where(r.query, r.arg).Update("deleted_at", now).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestoreLibrary restores the library and the rows DeleteLibrary deleted
+// along with it; the holds it cancelled stay cancelled. It writes several
+// tables, so callers run it in a transaction.
+func (s *service) RestoreLibrary(ctx context.Context, id uuid.UUID) error {
+	db := s.db.WithContext(ctx).Unscoped()
+
+	var lib Library
+	if err := db.Where("id = ?", id).First(&lib).Error; err != nil {
+		return err
+	}
+	if lib.DeletedAt == nil || !lib.DeletedAt.Valid {
+		return nil
+	}
+
+	ofLibrary := func(model interface{}) *gorm.DB {
+		return s.db.Unscoped().Model(model).Select("id").Where("library_id = ?", id)
+	}
+
+	for _, r := range []struct {
+		model interface{}
+		query string
+		arg   interface{}
+	}{
+		{&Library{}, "id = ?", id},
+		{&Subject{}, "library_id = ?", id},
+		{&Setting{}, "library_id = ?", id},
+		{&FineEntry{}, "library_id = ?", id},
+		{&Work{}, "library_id = ?", id},
+		{&Book{}, "library_id = ?", id},
+		{&Staff{}, "library_id = ?", id},
+		{&Membership{}, "library_id = ?", id},
+		{&Subscription{}, "membership_id IN (?)", ofLibrary(&Membership{})},
+		{&Borrowing{}, "book_id IN (?)", ofLibrary(&Book{})},
+	} {
+		err := db.
+			Model(r.model).
+			Where("deleted_at = ?", lib.DeletedAt.Time).
+			Where(r.query, r.arg).
+			Update("deleted_at", nil).
+			Error
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// PurgeLibrary deletes the library for good; its settings and subjects
+// go with it.
+func (s *service) PurgeLibrary(ctx context.Context, id uuid.UUID) error {
+	return s.purge(ctx, &Library{}, id)
+}
+
 // Convert core model to Usecase
 func (lib Library) ConvertToUsecase() usecase.Library {
 	var d *time.Time
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"librarease/internal/usecase"
+)
+
+func TestDeleteLibrary(t *testing.T) {
+	ctx := context.Background()
+	srv := New()
+	f := seedCheckout(t, srv, 5, 3, 1)
+	uc := usecase.New(srv, nil, nil)
+	lib := f.books[0].LibraryID.String()
+
+	b, err := uc.CreateBorrowing(ctx, usecase.Borrowing{BookID: f.books[0].ID, SubscriptionID: f.subs[0].ID, StaffID: f.staff.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := uc.DeleteLibrary(ctx, lib); !errors.Is(err, usecase.ErrLibraryOnLoan) {
+		t.Fatalf("delete with a loan: err = %v, want ErrLibraryOnLoan", err)
+	}
+	if _, err := uc.GetBookByID(ctx, f.books[0].ID); err != nil {
+		t.Fatalf("refused delete deleted the books: %v", err)
+	}
+
+	if _, err := uc.ReturnBorrowing(ctx, b.ID, f.staff.ID); err != nil {
+		t.Fatal(err)
+	}
+	h, err := uc.PlaceHold(ctx, usecase.Hold{BookID: f.books[1].ID, SubscriptionID: f.subs[0].ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// deleted before the library, so not restored with it
+	if err := uc.DeleteBook(ctx, f.books[2].ID); err != nil {
+		t.Fatal(err)
+	}
+	libID := f.books[0].LibraryID
+	subject, err := uc.CreateSubject(ctx, usecase.Subject{Kind: usecase.SubjectKindGenre, Name: "Fiction", LibraryID: libID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pickup := 5
+	if _, err := uc.UpdateSetting(ctx, usecase.Setting{LibraryID: libID, HoldPickupDays: &pickup}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.UpsertFineCharge(ctx, usecase.FineEntry{Amount: 10, UserID: f.subs[0].UserID, LibraryID: libID, BorrowingID: &b.ID}); err != nil {
+		t.Fatal(err)
+	}
+	// what a library keeps besides its records
+	dependents := func(wantKept bool) {
+		t.Helper()
+		_, err := uc.GetSubjectByID(ctx, subject.ID)
+		if kept := err == nil; kept != wantKept {
+			t.Errorf("subject kept = %v (err %v), want %v", kept, err, wantKept)
+		}
+		st, err := uc.GetSetting(ctx, libID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kept := st.HoldPickupDays != nil; kept != wantKept {
+			t.Errorf("setting kept = %v, want %v", kept, wantKept)
+		}
+		bal, err := uc.GetFineBalance(ctx, f.subs[0].UserID, libID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kept := bal.Charged == 10; kept != wantKept {
+			t.Errorf("fines kept = %v (charged %d), want %v", kept, bal.Charged, wantKept)
+		}
+	}
+	dependents(true)
+
+	if err := uc.DeleteLibrary(ctx, lib); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.GetBookByID(ctx, f.books[0].ID); !errors.Is(err, usecase.ErrNotFound) {
+		t.Errorf("book: err = %v, want ErrNotFound", err)
+	}
+	if _, err := uc.GetSubscriptionByID(ctx, f.subs[0].ID); !errors.Is(err, usecase.ErrNotFound) {
+		t.Errorf("subscription: err = %v, want ErrNotFound", err)
+	}
+	if _, err := uc.GetStaffByID(ctx, f.staff.ID.String()); !errors.Is(err, usecase.ErrNotFound) {
+		t.Errorf("staff: err = %v, want ErrNotFound", err)
+	}
+	if got, err := uc.GetHoldByID(ctx, h.ID); err != nil || got.Status != usecase.HoldStatusCancelled {
+		t.Errorf("hold: status = %s, err = %v, want %s", got.Status, err, usecase.HoldStatusCancelled)
+	}
+	dependents(false)
+
+	// its staff, books and loans still reference it
+	if err := uc.PurgeLibrary(ctx, f.books[0].LibraryID); !errors.Is(err, usecase.ErrInUse) {
+		t.Errorf("purge: err = %v, want ErrInUse", err)
+	}
+
+	if err := uc.RestoreLibrary(ctx, f.books[0].LibraryID); err != nil {
+		t.Fatal(err)
+	}
+	for _, book := range f.books[:2] {
+		if _, err := uc.GetBookByID(ctx, book.ID); err != nil {
+			t.Errorf("restored book %s: %v", book.ID, err)
+		}
+	}
+	if _, err := uc.GetBookByID(ctx, f.books[2].ID); !errors.Is(err, usecase.ErrNotFound) {
+		t.Errorf("book deleted before: err = %v, want ErrNotFound", err)
+	}
+	if _, err := uc.GetBorrowingByID(ctx, b.ID); err != nil {
+		t.Errorf("restored borrowing: %v", err)
+	}
+	dependents(true)
+}
@@ -0,0 +1,45 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+// AdvisoryLock is a session-level Postgres advisory lock. It is held on a
+// connection of its own until it is unlocked or the connection breaks.
+type AdvisoryLock struct {
+	conn *sql.Conn
+	key  int64
+}
+
+// TryAdvisoryLock takes the advisory lock of the key, returning nil when
+// another session holds it.
+func (s *service) TryAdvisoryLock(ctx context.Context, key int64) (*AdvisoryLock, error) {
+	db, err := s.db.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ok bool
+	err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&ok)
+	if err != nil || !ok {
+		conn.Close()
+		return nil, err
+	}
+	return &AdvisoryLock{conn: conn, key: key}, nil
+}
+
+// Held reports whether the connection holding the lock is still alive.
+func (l *AdvisoryLock) Held(ctx context.Context) bool {
+	return l.conn.PingContext(ctx) == nil
+}
+
+func (l *AdvisoryLock) Unlock() error {
+	defer l.conn.Close()
+	_, err := l.conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", l.key)
+	return err
+}
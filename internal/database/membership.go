@@ -7,6 +7,7 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Membership struct {
@@ -18,6 +19,7 @@ type Membership struct {
 	ActiveLoanLimit int             `gorm:"column:active_loan_limit;type:int"`
 	LoanPeriod      int             `gorm:"column:loan_period;type:int"`
 	FinePerDay      int             `gorm:"column:fine_per_day;type:int"`
+	MaxRenewals     int             `gorm:"column:max_renewals;type:int;default:0"`
 	CreatedAt       time.Time       `gorm:"column:created_at"`
 	UpdatedAt       time.Time       `gorm:"column:updated_at"`
 	DeletedAt       *gorm.DeletedAt `gorm:"column:deleted_at"`
@@ -38,6 +40,10 @@ func (s *service) ListMemberships(ctx context.Context, opt usecase.ListMembershi
 
 	db := s.db.Model([]Membership{}).WithContext(ctx)
 
+	if opt.IncludeDeleted {
+		db = db.Unscoped()
+	}
+
 	if opt.LibraryID != "" {
 		db = db.Where("library_id = ?", opt.LibraryID)
 	}
@@ -66,6 +72,16 @@ func (s *service) ListMemberships(ctx context.Context, opt usecase.ListMembershi
 	return ums, int(count), nil
 }
 
+func (s *service) LockMembership(ctx context.Context, id uuid.UUID) error {
+	return s.db.
+		WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		Where("id = ?", id).
+		First(&Membership{}).
+		Error
+}
+
 func (s *service) GetMembershipByID(ctx context.Context, id uuid.UUID) (usecase.Membership, error) {
 	var m Membership
 	err := s.db.
@@ -94,6 +110,7 @@ func (s *service) CreateMembership(ctx context.Context, m usecase.Membership) (u
 		ActiveLoanLimit: m.ActiveLoanLimit,
 		LoanPeriod:      m.LoanPeriod,
 		FinePerDay:      m.FinePerDay,
+		MaxRenewals:     m.MaxRenewals,
 	}
 
 	if err := s.db.WithContext(ctx).Create(&mem).Error; err != nil {
@@ -112,6 +129,7 @@ func (s *service) UpdateMembership(ctx context.Context, m usecase.Membership) (u
 		ActiveLoanLimit: m.ActiveLoanLimit,
 		LoanPeriod:      m.LoanPeriod,
 		FinePerDay:      m.FinePerDay,
+		MaxRenewals:     m.MaxRenewals,
 	}
 
 	err := s.db.WithContext(ctx).Updates(&mem).Error
@@ -123,7 +141,15 @@ func (s *service) UpdateMembership(ctx context.Context, m usecase.Membership) (u
 }
 
 func (s *service) DeleteMembership(ctx context.Context, id uuid.UUID) error {
-	return s.db.WithContext(ctx).Delete(&Membership{}, id).Error
+	return s.softDelete(ctx, &Membership{}, id)
+}
+
+func (s *service) RestoreMembership(ctx context.Context, id uuid.UUID) error {
+	return s.restore(ctx, &Membership{}, id)
+}
+
+func (s *service) PurgeMembership(ctx context.Context, id uuid.UUID) error {
+	return s.purge(ctx, &Membership{}, id)
 }
 
 // Convert core model to Usecase
@@ -140,6 +166,7 @@ func (m Membership) ConvertToUsecase() usecase.Membership {
 		ActiveLoanLimit: m.ActiveLoanLimit,
 		LoanPeriod:      m.LoanPeriod,
 		FinePerDay:      m.FinePerDay,
+		MaxRenewals:     m.MaxRenewals,
 		CreatedAt:       m.CreatedAt,
 		DeletedAt:       d,
 	}
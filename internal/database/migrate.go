@@ -0,0 +1,248 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// migrationFS holds the schema migrations, numbered from 1 without gaps,
+// each an NNNN_name.up.sql file and the NNNN_name.down.sql that undoes it.
+//
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+// migrationLockID is the key of the advisory lock held while migrating,
+// so that instances starting together apply each migration once.
+const migrationLockID int64 = 0x6c6962726172 // "librar"
+
+var migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a schema change; AppliedAt is nil until it is applied.
+type Migration struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+
+	up, down string
+}
+
+// Migrator applies and rolls back the migrations, recording the applied
+// ones in the schema_migrations table.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewMigrator connects to the database configured by the environment.
+func NewMigrator() (*Migrator, error) {
+	db, err := sql.Open("pgx", connString())
+	if err != nil {
+		return nil, err
+	}
+	return newMigrator(db)
+}
+
+func newMigrator(db *sql.DB) (*Migrator, error) {
+	migrations, err := loadMigrations(migrationFS)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+func (m *Migrator) Close() error {
+	return m.db.Close()
+}
+
+// loadMigrations reads the migrations of fsys, in order of version.
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, file := range files {
+		match := migrationFile.FindStringSubmatch(path.Base(file))
+		if match == nil {
+			return nil, fmt.Errorf("migration %s: name is not NNNN_name.up.sql or NNNN_name.down.sql", file)
+		}
+		version, _ := strconv.Atoi(match[1])
+		b, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = mig
+		}
+		if mig.Name != match[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, mig.Name, match[2])
+		}
+		if match[3] == "up" {
+			mig.up = string(b)
+		} else {
+			mig.down = string(b)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for v := 1; v <= len(byVersion); v++ {
+		mig, ok := byVersion[v]
+		if !ok {
+			return nil, fmt.Errorf("migration %d is missing", v)
+		}
+		if mig.up == "" || mig.down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", v, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	return migrations, nil
+}
+
+// withLock runs fn on a connection holding the migration lock, after
+// creating the schema_migrations table if needed.
+func (m *Migrator) withLock(ctx context.Context, fn func(*sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return err
+	}
+	// unlock with a fresh context, as ctx may be why fn returned
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID)
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint PRIMARY KEY,
+		name text NOT NULL,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// applied returns the applied versions and when they were applied.
+func applied(ctx context.Context, conn *sql.Conn) (map[int]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := make(map[int]time.Time)
+	for rows.Next() {
+		var (
+			v int
+			t time.Time
+		)
+		if err := rows.Scan(&v, &t); err != nil {
+			return nil, err
+		}
+		versions[v] = t
+	}
+	return versions, rows.Err()
+}
+
+// run runs the statements of a migration and records the change of its
+// state in one transaction.
+func run(ctx context.Context, conn *sql.Conn, stmts, record string, args ...any) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, stmts); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Up applies the pending migrations in order and returns them.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		versions, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if _, ok := versions[mig.Version]; ok {
+				continue
+			}
+			err := run(ctx, conn, mig.up,
+				"INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", mig.Version, mig.Name)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down rolls back the last n applied migrations, latest first, and
+// returns them.
+func (m *Migrator) Down(ctx context.Context, n int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		versions, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range slices.Backward(m.migrations) {
+			if len(done) == n {
+				break
+			}
+			if _, ok := versions[mig.Version]; !ok {
+				continue
+			}
+			err := run(ctx, conn, mig.down,
+				"DELETE FROM schema_migrations WHERE version = $1", mig.Version)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status returns every migration with when it was applied.
+func (m *Migrator) Status(ctx context.Context) ([]Migration, error) {
+	var status []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		versions, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if t, ok := versions[mig.Version]; ok {
+				mig.AppliedAt = &t
+			}
+			status = append(status, mig)
+		}
+		return nil
+	})
+	return status, err
+}
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// newTestMigrator creates the database name and returns a migrator of
+// it, as New migrates the shared test database.
+func newTestMigrator(t *testing.T, name string) (*Migrator, *sql.DB) {
+	t.Helper()
+	admin, err := sql.Open("pgx", connString())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+	if _, err := admin.ExecContext(context.Background(), "CREATE DATABASE "+name); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("pgx", fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable", username, password, host, port, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := newMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	return m, db
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	m, _ := newTestMigrator(t, "migrate_test")
+
+	applied := func(want int) {
+		t.Helper()
+		status, err := m.Status(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var n int
+		for _, mig := range status {
+			if mig.AppliedAt != nil {
+				n++
+			}
+		}
+		if n != want {
+			t.Errorf("got %d applied migrations, want %d", n, want)
+		}
+	}
+
+	total := len(m.migrations)
+
+	done, err := m.Up(ctx)
+	if err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if len(done) != total {
+		t.Errorf("up applied %d migrations, want %d", len(done), total)
+	}
+	applied(total)
+
+	done, err = m.Up(ctx)
+	if err != nil {
+		t.Fatalf("second up: %v", err)
+	}
+	if len(done) != 0 {
+		t.Errorf("second up applied %d migrations, want 0", len(done))
+	}
+
+	done, err = m.Down(ctx, 1)
+	if err != nil {
+		t.Fatalf("down 1: %v", err)
+	}
+	if len(done) != 1 || done[0].Version != total {
+		t.Errorf("down 1 rolled back %v, want version %d", done, total)
+	}
+	applied(total - 1)
+
+	if _, err := m.Down(ctx, total); err != nil {
+		t.Fatalf("down all: %v", err)
+	}
+	applied(0)
+
+	// every down must leave the schema its up can be applied to again
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("up after down: %v", err)
+	}
+	applied(total)
+}
+
+func TestUniqueEmailMigrationReportsDuplicates(t *testing.T) {
+	ctx := context.Background()
+	m, db := newTestMigrator(t, "migrate_email_test")
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// back to before 0012, with users that only differ in case
+	if _, err := m.Down(ctx, len(m.migrations)-11); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, `INSERT INTO users (name, email) VALUES ('a', 'ada@example.com'), ('b', 'Ada@Example.com')`); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := m.Up(ctx)
+	if err == nil || !strings.Contains(err.Error(), "ada@example.com") {
+		t.Errorf("up with duplicate emails: err = %v, want one naming ada@example.com", err)
+	}
+
+	if _, err := db.ExecContext(ctx, `UPDATE users SET deleted_at = now() WHERE name = 'b'`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Errorf("up once the duplicate is deleted: %v", err)
+	}
+}
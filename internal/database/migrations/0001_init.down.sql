@@ -0,0 +1 @@
+DROP TABLE IF EXISTS borrowings, subscriptions, memberships, books, staffs, libraries, auth_users, users;
@@ -0,0 +1,118 @@
+-- The schema of the first release. Tables and indexes are only created
+-- when missing, so that databases created by gorm's AutoMigrate adopt it.
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS users (
+	id uuid DEFAULT uuid_generate_v4(),
+	name varchar(255),
+	email varchar(255),
+	phone varchar(255),
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS auth_users (
+	uid varchar(255),
+	user_id uuid,
+	global_role text DEFAULT 'USER',
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	PRIMARY KEY (uid),
+	CONSTRAINT fk_users_auth_user FOREIGN KEY (user_id) REFERENCES users (id),
+	CONSTRAINT chk_auth_users_global_role CHECK (global_role IN ('SUPERADMIN', 'ADMIN', 'USER'))
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_auth_users_user_id ON auth_users (user_id);
+
+CREATE TABLE IF NOT EXISTS libraries (
+	id uuid DEFAULT uuid_generate_v4(),
+	name varchar(255),
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS staffs (
+	id uuid DEFAULT uuid_generate_v4(),
+	name varchar(255),
+	library_id uuid,
+	user_id uuid,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	role text DEFAULT 'STAFF',
+	PRIMARY KEY (id),
+	CONSTRAINT fk_users_staffs FOREIGN KEY (user_id) REFERENCES users (id),
+	CONSTRAINT fk_libraries_staffs FOREIGN KEY (library_id) REFERENCES libraries (id),
+	CONSTRAINT chk_staffs_role CHECK (role IN ('STAFF', 'ADMIN'))
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_library ON staffs (library_id, user_id);
+
+CREATE TABLE IF NOT EXISTS books (
+	id uuid DEFAULT uuid_generate_v4(),
+	title varchar(255),
+	author varchar(255),
+	year bigint,
+	code varchar(255),
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	library_id uuid,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_libraries_books FOREIGN KEY (library_id) REFERENCES libraries (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_lib_code ON books (code, library_id);
+
+CREATE TABLE IF NOT EXISTS memberships (
+	id uuid DEFAULT uuid_generate_v4(),
+	name varchar(255),
+	library_id uuid,
+	duration bigint,
+	active_loan_limit bigint,
+	loan_period bigint,
+	fine_per_day bigint,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_libraries_memberships FOREIGN KEY (library_id) REFERENCES libraries (id)
+);
+
+CREATE TABLE IF NOT EXISTS subscriptions (
+	id uuid DEFAULT uuid_generate_v4(),
+	user_id uuid,
+	membership_id uuid,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	expires_at timestamptz,
+	fine_per_day bigint,
+	loan_period bigint,
+	active_loan_limit bigint,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_memberships_subscriptions FOREIGN KEY (membership_id) REFERENCES memberships (id),
+	CONSTRAINT fk_users_subscriptions FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS borrowings (
+	id uuid DEFAULT uuid_generate_v4(),
+	book_id uuid,
+	subscription_id uuid,
+	staff_id uuid,
+	borrowed_at timestamptz DEFAULT now(),
+	due_at timestamptz,
+	returned_at timestamptz,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_books_borrowings FOREIGN KEY (book_id) REFERENCES books (id),
+	CONSTRAINT fk_subscriptions_borrowings FOREIGN KEY (subscription_id) REFERENCES subscriptions (id),
+	CONSTRAINT fk_staffs_borrowings FOREIGN KEY (staff_id) REFERENCES staffs (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_book_id_returned_at_null
+	ON borrowings (book_id)
+	WHERE returned_at IS NULL AND deleted_at IS NULL;
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS settings, fine_entries, holds, borrowing_renewals, refresh_tokens, password_credentials;
+
+ALTER TABLE borrowings
+	DROP COLUMN IF EXISTS returned_staff_id,
+	DROP COLUMN IF EXISTS fine,
+	DROP COLUMN IF EXISTS renewal_count;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS max_renewals;
+ALTER TABLE memberships DROP COLUMN IF EXISTS max_renewals;
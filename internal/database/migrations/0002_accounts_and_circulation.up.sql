@@ -0,0 +1,102 @@
+-- Local accounts, renewals, holds, fines and library settings.
+CREATE TABLE IF NOT EXISTS password_credentials (
+	uid varchar(255),
+	email varchar(255),
+	password_hash varchar(255),
+	created_at timestamptz,
+	updated_at timestamptz,
+	PRIMARY KEY (uid)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_password_credentials_email ON password_credentials (email);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+	id uuid DEFAULT uuid_generate_v4(),
+	user_id uuid,
+	uid varchar(255),
+	token_hash varchar(255),
+	expires_at timestamptz,
+	revoked_at timestamptz,
+	created_at timestamptz,
+	updated_at timestamptz,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_refresh_tokens_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refresh_tokens_token_hash ON refresh_tokens (token_hash);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+
+ALTER TABLE memberships ADD COLUMN IF NOT EXISTS max_renewals bigint DEFAULT 0;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS max_renewals bigint DEFAULT 0;
+ALTER TABLE borrowings
+	ADD COLUMN IF NOT EXISTS returned_staff_id uuid,
+	ADD COLUMN IF NOT EXISTS fine bigint DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS renewal_count bigint DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS borrowing_renewals (
+	id uuid DEFAULT uuid_generate_v4(),
+	borrowing_id uuid,
+	staff_id uuid,
+	previous_due_at timestamptz,
+	due_at timestamptz,
+	created_at timestamptz,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_borrowing_renewals_borrowing FOREIGN KEY (borrowing_id) REFERENCES borrowings (id),
+	CONSTRAINT fk_borrowing_renewals_staff FOREIGN KEY (staff_id) REFERENCES staffs (id)
+);
+CREATE INDEX IF NOT EXISTS idx_borrowing_renewals_borrowing_id ON borrowing_renewals (borrowing_id);
+
+CREATE TABLE IF NOT EXISTS holds (
+	id uuid DEFAULT uuid_generate_v4(),
+	book_id uuid,
+	subscription_id uuid,
+	staff_id uuid,
+	status varchar(16),
+	ready_at timestamptz,
+	expires_at timestamptz,
+	closed_at timestamptz,
+	borrowing_id uuid,
+	created_at timestamptz,
+	updated_at timestamptz,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_holds_book FOREIGN KEY (book_id) REFERENCES books (id),
+	CONSTRAINT fk_holds_subscription FOREIGN KEY (subscription_id) REFERENCES subscriptions (id),
+	CONSTRAINT fk_holds_staff FOREIGN KEY (staff_id) REFERENCES staffs (id),
+	CONSTRAINT fk_holds_borrowing FOREIGN KEY (borrowing_id) REFERENCES borrowings (id)
+);
+CREATE INDEX IF NOT EXISTS idx_holds_book_id ON holds (book_id);
+CREATE INDEX IF NOT EXISTS idx_holds_subscription_id ON holds (subscription_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_holds_book_id_subscription_id_open
+	ON holds (book_id, subscription_id)
+	WHERE status IN ('WAITING', 'READY');
+
+CREATE TABLE IF NOT EXISTS fine_entries (
+	id uuid DEFAULT uuid_generate_v4(),
+	kind varchar(16),
+	amount bigint,
+	user_id uuid,
+	library_id uuid,
+	borrowing_id uuid,
+	staff_id uuid,
+	note varchar(255),
+	created_at timestamptz,
+	updated_at timestamptz,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_fine_entries_user FOREIGN KEY (user_id) REFERENCES users (id),
+	CONSTRAINT fk_fine_entries_library FOREIGN KEY (library_id) REFERENCES libraries (id),
+	CONSTRAINT fk_fine_entries_borrowing FOREIGN KEY (borrowing_id) REFERENCES borrowings (id),
+	CONSTRAINT fk_fine_entries_staff FOREIGN KEY (staff_id) REFERENCES staffs (id)
+);
+CREATE INDEX IF NOT EXISTS idx_fine_entries_user_library ON fine_entries (user_id, library_id);
+CREATE INDEX IF NOT EXISTS idx_fine_entries_borrowing_id ON fine_entries (borrowing_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_fine_entries_charge_borrowing_id
+	ON fine_entries (borrowing_id)
+	WHERE kind = 'CHARGE';
+
+CREATE TABLE IF NOT EXISTS settings (
+	library_id uuid,
+	max_outstanding_fine bigint,
+	hold_pickup_days bigint,
+	created_at timestamptz,
+	updated_at timestamptz,
+	PRIMARY KEY (library_id),
+	CONSTRAINT fk_settings_library FOREIGN KEY (library_id) REFERENCES libraries (id)
+);
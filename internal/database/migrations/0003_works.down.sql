@@ -0,0 +1,14 @@
+ALTER TABLE books
+	ADD COLUMN title varchar(255),
+	ADD COLUMN author varchar(255),
+	ADD COLUMN year bigint;
+
+UPDATE books SET title = works.title, author = works.author, year = works.year
+FROM works
+WHERE works.id = books.work_id;
+
+ALTER TABLE books
+	DROP COLUMN work_id,
+	DROP COLUMN location,
+	DROP COLUMN condition;
+DROP TABLE works;
@@ -0,0 +1,52 @@
+-- Books become copies of works, which hold their title, author and year.
+-- Existing books get one work per distinct title, author and year in
+-- each library.
+CREATE TABLE IF NOT EXISTS works (
+	id uuid DEFAULT uuid_generate_v4(),
+	title varchar(255) NOT NULL,
+	author varchar(255),
+	year bigint,
+	library_id uuid NOT NULL,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_works_library FOREIGN KEY (library_id) REFERENCES libraries (id)
+);
+CREATE INDEX IF NOT EXISTS idx_works_library_id ON works (library_id);
+
+ALTER TABLE books
+	ADD COLUMN IF NOT EXISTS location varchar(255),
+	ADD COLUMN IF NOT EXISTS condition varchar(20) NOT NULL DEFAULT 'GOOD',
+	ADD COLUMN IF NOT EXISTS work_id uuid;
+
+DO $$
+BEGIN
+	IF EXISTS (
+		SELECT 1 FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = 'books' AND column_name = 'title'
+	) THEN
+		INSERT INTO works (library_id, title, author, year, created_at, updated_at)
+		SELECT library_id, COALESCE(title, ''), COALESCE(author, ''), COALESCE(year, 0), min(created_at), now()
+		FROM books
+		WHERE work_id IS NULL
+		GROUP BY library_id, COALESCE(title, ''), COALESCE(author, ''), COALESCE(year, 0);
+
+		UPDATE books SET work_id = works.id
+		FROM works
+		WHERE books.work_id IS NULL
+		AND works.library_id = books.library_id
+		AND works.title = COALESCE(books.title, '')
+		AND works.author = COALESCE(books.author, '')
+		AND works.year = COALESCE(books.year, 0);
+
+		ALTER TABLE books DROP COLUMN title, DROP COLUMN author, DROP COLUMN year;
+	END IF;
+
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_books_work') THEN
+		ALTER TABLE books ADD CONSTRAINT fk_books_work FOREIGN KEY (work_id) REFERENCES works (id);
+	END IF;
+END $$;
+
+ALTER TABLE books ALTER COLUMN work_id SET NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_books_work_id ON books (work_id);
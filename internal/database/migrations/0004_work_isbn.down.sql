@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_works_library_id_isbn13;
+ALTER TABLE works DROP COLUMN isbn13, DROP COLUMN isbn10;
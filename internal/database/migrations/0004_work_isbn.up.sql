@@ -0,0 +1,4 @@
+ALTER TABLE works
+	ADD COLUMN IF NOT EXISTS isbn13 varchar(13),
+	ADD COLUMN IF NOT EXISTS isbn10 varchar(10);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_works_library_id_isbn13 ON works (library_id, isbn13);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_works_author_trgm;
+DROP INDEX IF EXISTS idx_works_title_trgm;
+DROP INDEX IF EXISTS idx_works_search_vector;
+ALTER TABLE works DROP COLUMN search_vector;
@@ -0,0 +1,14 @@
+-- search_vector backs full-text search of works, title words weighing
+-- more than author ones; the trigram indexes back fuzzy matching. The
+-- text search configuration must match searchConfig.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE works ADD COLUMN IF NOT EXISTS search_vector tsvector
+	GENERATED ALWAYS AS (
+		setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+		setweight(to_tsvector('simple', coalesce(author, '')), 'B')
+	) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_works_search_vector ON works USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_works_title_trgm ON works USING GIN (title gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_works_author_trgm ON works USING GIN (author gin_trgm_ops);
@@ -0,0 +1,2 @@
+ALTER TABLE works DROP COLUMN call_number, DROP COLUMN call_number_scheme;
+DROP TABLE work_subjects, subjects;
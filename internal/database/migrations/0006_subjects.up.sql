@@ -0,0 +1,25 @@
+-- Subjects, genres and tags classifying works, and call numbers.
+CREATE TABLE IF NOT EXISTS subjects (
+	id uuid DEFAULT uuid_generate_v4(),
+	kind varchar(16) NOT NULL,
+	name varchar(255) NOT NULL,
+	library_id uuid NOT NULL,
+	created_at timestamptz,
+	updated_at timestamptz,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_subjects_library FOREIGN KEY (library_id) REFERENCES libraries (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_subjects_library_id_kind_name ON subjects (library_id, kind, name);
+
+CREATE TABLE IF NOT EXISTS work_subjects (
+	work_id uuid,
+	subject_id uuid,
+	PRIMARY KEY (work_id, subject_id),
+	CONSTRAINT fk_work_subjects_work FOREIGN KEY (work_id) REFERENCES works (id),
+	CONSTRAINT fk_work_subjects_subject FOREIGN KEY (subject_id) REFERENCES subjects (id)
+);
+CREATE INDEX IF NOT EXISTS idx_work_subjects_subject_id ON work_subjects (subject_id);
+
+ALTER TABLE works
+	ADD COLUMN IF NOT EXISTS call_number varchar(64),
+	ADD COLUMN IF NOT EXISTS call_number_scheme varchar(8);
@@ -0,0 +1,11 @@
+-- Reminders sent to members about their loans, one of each kind per due date.
+CREATE TABLE IF NOT EXISTS reminders (
+	id uuid DEFAULT uuid_generate_v4(),
+	borrowing_id uuid NOT NULL,
+	kind varchar(16) NOT NULL,
+	due_at timestamptz NOT NULL,
+	created_at timestamptz,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_reminders_borrowing FOREIGN KEY (borrowing_id) REFERENCES borrowings (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reminders_borrowing_id_kind_due_at ON reminders (borrowing_id, kind, due_at);
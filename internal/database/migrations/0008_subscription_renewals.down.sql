@@ -0,0 +1,9 @@
+DELETE FROM reminders WHERE subscription_id IS NOT NULL;
+DROP INDEX idx_reminders_subscription_id_kind_due_at;
+ALTER TABLE reminders
+	DROP CONSTRAINT chk_reminders_subject,
+	DROP COLUMN subscription_id,
+	ALTER COLUMN borrowing_id SET NOT NULL;
+
+DROP TABLE subscription_renewals;
+ALTER TABLE subscriptions DROP COLUMN expired_at;
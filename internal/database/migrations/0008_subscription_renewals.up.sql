@@ -0,0 +1,28 @@
+-- Renewal history of subscriptions, the mark of expired ones, and
+-- reminders about subscriptions.
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS expired_at timestamptz;
+
+CREATE TABLE IF NOT EXISTS subscription_renewals (
+	id uuid DEFAULT uuid_generate_v4(),
+	subscription_id uuid NOT NULL,
+	staff_id uuid,
+	previous_expires_at timestamptz NOT NULL,
+	expires_at timestamptz NOT NULL,
+	regrandfathered boolean NOT NULL DEFAULT false,
+	fine_per_day bigint,
+	max_renewals bigint,
+	loan_period bigint,
+	active_loan_limit bigint,
+	created_at timestamptz,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_subscription_renewals_subscription FOREIGN KEY (subscription_id) REFERENCES subscriptions (id),
+	CONSTRAINT fk_subscription_renewals_staff FOREIGN KEY (staff_id) REFERENCES staffs (id)
+);
+CREATE INDEX IF NOT EXISTS idx_subscription_renewals_subscription_id ON subscription_renewals (subscription_id);
+
+ALTER TABLE reminders
+	ALTER COLUMN borrowing_id DROP NOT NULL,
+	ADD COLUMN subscription_id uuid,
+	ADD CONSTRAINT fk_reminders_subscription FOREIGN KEY (subscription_id) REFERENCES subscriptions (id),
+	ADD CONSTRAINT chk_reminders_subject CHECK (num_nonnulls(borrowing_id, subscription_id) = 1);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reminders_subscription_id_kind_due_at ON reminders (subscription_id, kind, due_at);
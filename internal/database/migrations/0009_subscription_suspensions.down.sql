@@ -0,0 +1,6 @@
+ALTER TABLE settings
+	DROP COLUMN auto_suspend_overdue,
+	DROP COLUMN auto_suspend_fine;
+
+DROP TABLE subscription_suspensions;
+ALTER TABLE subscriptions DROP COLUMN suspended_at;
@@ -0,0 +1,27 @@
+-- Suspensions of subscriptions with their history, and the library
+-- thresholds that suspend members automatically.
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS suspended_at timestamptz;
+
+CREATE TABLE IF NOT EXISTS subscription_suspensions (
+	id uuid DEFAULT uuid_generate_v4(),
+	subscription_id uuid NOT NULL,
+	staff_id uuid,
+	reason text NOT NULL,
+	suspended_at timestamptz NOT NULL,
+	reinstated_at timestamptz,
+	reinstated_staff_id uuid,
+	expires_at timestamptz,
+	created_at timestamptz,
+	updated_at timestamptz,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_subscription_suspensions_subscription FOREIGN KEY (subscription_id) REFERENCES subscriptions (id),
+	CONSTRAINT fk_subscription_suspensions_staff FOREIGN KEY (staff_id) REFERENCES staffs (id),
+	CONSTRAINT fk_subscription_suspensions_reinstated_staff FOREIGN KEY (reinstated_staff_id) REFERENCES staffs (id)
+);
+CREATE INDEX IF NOT EXISTS idx_subscription_suspensions_subscription_id ON subscription_suspensions (subscription_id);
+-- a subscription has at most one suspension in force
+CREATE UNIQUE INDEX IF NOT EXISTS idx_subscription_suspensions_open ON subscription_suspensions (subscription_id) WHERE reinstated_at IS NULL;
+
+ALTER TABLE settings
+	ADD COLUMN IF NOT EXISTS auto_suspend_fine bigint,
+	ADD COLUMN IF NOT EXISTS auto_suspend_overdue bigint;
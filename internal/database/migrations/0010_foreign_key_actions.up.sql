@@ -0,0 +1,107 @@
+-- Every foreign key declares what deleting the row it references does.
+-- Records a library, member or staff is accountable for (staff, books,
+-- subscriptions, loans and fines) RESTRICT, so they must be purged
+-- first; rows that only describe another one, such as settings, renewals,
+-- reminders and holds, CASCADE; references to the staff who acted on a
+-- record are SET NULL, keeping the record.
+ALTER TABLE auth_users
+	DROP CONSTRAINT IF EXISTS fk_users_auth_user,
+	ADD CONSTRAINT fk_users_auth_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
+
+ALTER TABLE refresh_tokens
+	DROP CONSTRAINT IF EXISTS fk_refresh_tokens_user,
+	ADD CONSTRAINT fk_refresh_tokens_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
+
+ALTER TABLE staffs
+	DROP CONSTRAINT IF EXISTS fk_users_staffs,
+	DROP CONSTRAINT IF EXISTS fk_libraries_staffs,
+	ADD CONSTRAINT fk_users_staffs FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE RESTRICT,
+	ADD CONSTRAINT fk_libraries_staffs FOREIGN KEY (library_id) REFERENCES libraries (id) ON DELETE RESTRICT;
+
+ALTER TABLE settings
+	DROP CONSTRAINT IF EXISTS fk_settings_library,
+	ADD CONSTRAINT fk_settings_library FOREIGN KEY (library_id) REFERENCES libraries (id) ON DELETE CASCADE;
+
+ALTER TABLE memberships
+	DROP CONSTRAINT IF EXISTS fk_libraries_memberships,
+	ADD CONSTRAINT fk_libraries_memberships FOREIGN KEY (library_id) REFERENCES libraries (id) ON DELETE RESTRICT;
+
+ALTER TABLE subscriptions
+	DROP CONSTRAINT IF EXISTS fk_memberships_subscriptions,
+	DROP CONSTRAINT IF EXISTS fk_users_subscriptions,
+	ADD CONSTRAINT fk_memberships_subscriptions FOREIGN KEY (membership_id) REFERENCES memberships (id) ON DELETE RESTRICT,
+	ADD CONSTRAINT fk_users_subscriptions FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE RESTRICT;
+
+ALTER TABLE works
+	DROP CONSTRAINT IF EXISTS fk_works_library,
+	ADD CONSTRAINT fk_works_library FOREIGN KEY (library_id) REFERENCES libraries (id) ON DELETE RESTRICT;
+
+ALTER TABLE subjects
+	DROP CONSTRAINT IF EXISTS fk_subjects_library,
+	ADD CONSTRAINT fk_subjects_library FOREIGN KEY (library_id) REFERENCES libraries (id) ON DELETE CASCADE;
+
+ALTER TABLE work_subjects
+	DROP CONSTRAINT IF EXISTS fk_work_subjects_work,
+	DROP CONSTRAINT IF EXISTS fk_work_subjects_subject,
+	ADD CONSTRAINT fk_work_subjects_work FOREIGN KEY (work_id) REFERENCES works (id) ON DELETE CASCADE,
+	ADD CONSTRAINT fk_work_subjects_subject FOREIGN KEY (subject_id) REFERENCES subjects (id) ON DELETE CASCADE;
+
+ALTER TABLE books
+	DROP CONSTRAINT IF EXISTS fk_libraries_books,
+	DROP CONSTRAINT IF EXISTS fk_books_work,
+	ADD CONSTRAINT fk_libraries_books FOREIGN KEY (library_id) REFERENCES libraries (id) ON DELETE RESTRICT,
+	ADD CONSTRAINT fk_books_work FOREIGN KEY (work_id) REFERENCES works (id) ON DELETE RESTRICT;
+
+ALTER TABLE borrowings
+	DROP CONSTRAINT IF EXISTS fk_books_borrowings,
+	DROP CONSTRAINT IF EXISTS fk_subscriptions_borrowings,
+	DROP CONSTRAINT IF EXISTS fk_staffs_borrowings,
+	ADD CONSTRAINT fk_books_borrowings FOREIGN KEY (book_id) REFERENCES books (id) ON DELETE RESTRICT,
+	ADD CONSTRAINT fk_subscriptions_borrowings FOREIGN KEY (subscription_id) REFERENCES subscriptions (id) ON DELETE RESTRICT,
+	ADD CONSTRAINT fk_staffs_borrowings FOREIGN KEY (staff_id) REFERENCES staffs (id) ON DELETE RESTRICT;
+
+ALTER TABLE borrowing_renewals
+	DROP CONSTRAINT IF EXISTS fk_borrowing_renewals_borrowing,
+	DROP CONSTRAINT IF EXISTS fk_borrowing_renewals_staff,
+	ADD CONSTRAINT fk_borrowing_renewals_borrowing FOREIGN KEY (borrowing_id) REFERENCES borrowings (id) ON DELETE CASCADE,
+	ADD CONSTRAINT fk_borrowing_renewals_staff FOREIGN KEY (staff_id) REFERENCES staffs (id) ON DELETE SET NULL;
+
+ALTER TABLE holds
+	DROP CONSTRAINT IF EXISTS fk_holds_book,
+	DROP CONSTRAINT IF EXISTS fk_holds_subscription,
+	DROP CONSTRAINT IF EXISTS fk_holds_staff,
+	DROP CONSTRAINT IF EXISTS fk_holds_borrowing,
+	ADD CONSTRAINT fk_holds_book FOREIGN KEY (book_id) REFERENCES books (id) ON DELETE CASCADE,
+	ADD CONSTRAINT fk_holds_subscription FOREIGN KEY (subscription_id) REFERENCES subscriptions (id) ON DELETE CASCADE,
+	ADD CONSTRAINT fk_holds_staff FOREIGN KEY (staff_id) REFERENCES staffs (id) ON DELETE SET NULL,
+	ADD CONSTRAINT fk_holds_borrowing FOREIGN KEY (borrowing_id) REFERENCES borrowings (id) ON DELETE SET NULL;
+
+ALTER TABLE fine_entries
+	DROP CONSTRAINT IF EXISTS fk_fine_entries_user,
+	DROP CONSTRAINT IF EXISTS fk_fine_entries_library,
+	DROP CONSTRAINT IF EXISTS fk_fine_entries_borrowing,
+	DROP CONSTRAINT IF EXISTS fk_fine_entries_staff,
+	ADD CONSTRAINT fk_fine_entries_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE RESTRICT,
+	ADD CONSTRAINT fk_fine_entries_library FOREIGN KEY (library_id) REFERENCES libraries (id) ON DELETE RESTRICT,
+	ADD CONSTRAINT fk_fine_entries_borrowing FOREIGN KEY (borrowing_id) REFERENCES borrowings (id) ON DELETE RESTRICT,
+	ADD CONSTRAINT fk_fine_entries_staff FOREIGN KEY (staff_id) REFERENCES staffs (id) ON DELETE SET NULL;
+
+ALTER TABLE reminders
+	DROP CONSTRAINT IF EXISTS fk_reminders_borrowing,
+	DROP CONSTRAINT IF EXISTS fk_reminders_subscription,
+	ADD CONSTRAINT fk_reminders_borrowing FOREIGN KEY (borrowing_id) REFERENCES borrowings (id) ON DELETE CASCADE,
+	ADD CONSTRAINT fk_reminders_subscription FOREIGN KEY (subscription_id) REFERENCES subscriptions (id) ON DELETE CASCADE;
+
+ALTER TABLE subscription_renewals
+	DROP CONSTRAINT IF EXISTS fk_subscription_renewals_subscription,
+	DROP CONSTRAINT IF EXISTS fk_subscription_renewals_staff,
+	ADD CONSTRAINT fk_subscription_renewals_subscription FOREIGN KEY (subscription_id) REFERENCES subscriptions (id) ON DELETE CASCADE,
+	ADD CONSTRAINT fk_subscription_renewals_staff FOREIGN KEY (staff_id) REFERENCES staffs (id) ON DELETE SET NULL;
+
+ALTER TABLE subscription_suspensions
+	DROP CONSTRAINT IF EXISTS fk_subscription_suspensions_subscription,
+	DROP CONSTRAINT IF EXISTS fk_subscription_suspensions_staff,
+	DROP CONSTRAINT IF EXISTS fk_subscription_suspensions_reinstated_staff,
+	ADD CONSTRAINT fk_subscription_suspensions_subscription FOREIGN KEY (subscription_id) REFERENCES subscriptions (id) ON DELETE CASCADE,
+	ADD CONSTRAINT fk_subscription_suspensions_staff FOREIGN KEY (staff_id) REFERENCES staffs (id) ON DELETE SET NULL,
+	ADD CONSTRAINT fk_subscription_suspensions_reinstated_staff FOREIGN KEY (reinstated_staff_id) REFERENCES staffs (id) ON DELETE SET NULL;
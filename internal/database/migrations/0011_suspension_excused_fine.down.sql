@@ -0,0 +1 @@
+ALTER TABLE subscription_suspensions DROP COLUMN excused_fine;
@@ -0,0 +1,3 @@
+-- What a member owed when their suspension was lifted, which the library
+-- thresholds excuse until the member owes more.
+ALTER TABLE subscription_suspensions ADD COLUMN IF NOT EXISTS excused_fine bigint NOT NULL DEFAULT 0;
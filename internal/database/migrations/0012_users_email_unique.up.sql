@@ -0,0 +1,26 @@
+-- At most one live user per email, ignoring case, so concurrent sign-ups
+-- cannot both pass the check in RegisterUser. Users created by staff may
+-- have no email.
+--
+-- Users that already share an email cannot be merged here, since each may
+-- hold its own subscriptions and loans, so the migration stops and names
+-- them instead.
+DO $$
+DECLARE
+	dups text;
+BEGIN
+	SELECT string_agg(email, ', ' ORDER BY email) INTO dups
+	FROM (
+		SELECT LOWER(email) AS email FROM users
+		WHERE deleted_at IS NULL AND email <> ''
+		GROUP BY LOWER(email)
+		HAVING count(*) > 1
+	) d;
+	IF dups IS NOT NULL THEN
+		RAISE EXCEPTION 'users share these emails, ignoring case: %', dups
+			USING HINT = 'Merge or soft-delete the duplicate users, then run the migrations again.';
+	END IF;
+END
+$$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (LOWER(email)) WHERE deleted_at IS NULL AND email <> '';
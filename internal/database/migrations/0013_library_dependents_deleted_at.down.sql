@@ -0,0 +1,3 @@
+ALTER TABLE subjects DROP COLUMN deleted_at;
+ALTER TABLE settings DROP COLUMN deleted_at;
+ALTER TABLE fine_entries DROP COLUMN deleted_at;
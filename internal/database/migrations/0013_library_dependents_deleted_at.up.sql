@@ -0,0 +1,5 @@
+-- Subjects, settings and fines are archived with their library, and
+-- restored with it.
+ALTER TABLE subjects ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+ALTER TABLE settings ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+ALTER TABLE fine_entries ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
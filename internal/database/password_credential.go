@@ -0,0 +1,58 @@
+package database
+
+import (
+	"context"
+	"librarease/internal/usecase"
+	"time"
+)
+
+type PasswordCredential struct {
+	UID          string    `gorm:"column:uid;primaryKey;type:varchar(255)"`
+	Email        string    `gorm:"column:email;type:varchar(255);uniqueIndex"`
+	PasswordHash string    `gorm:"column:password_hash;type:varchar(255)"`
+	CreatedAt    time.Time `gorm:"column:created_at"`
+	UpdatedAt    time.Time `gorm:"column:updated_at"`
+}
+
+func (PasswordCredential) TableName() string {
+	return "password_credentials"
+}
+
+func (s *service) CreatePasswordCredential(ctx context.Context, pc usecase.PasswordCredential) (usecase.PasswordCredential, error) {
+	c := PasswordCredential{
+		UID:          pc.UID,
+		Email:        pc.Email,
+		PasswordHash: pc.PasswordHash,
+	}
+	err := s.db.WithContext(ctx).Create(&c).Error
+	if err != nil {
+		return usecase.PasswordCredential{}, err
+	}
+
+	return c.ConvertToUsecase(), nil
+}
+
+func (s *service) GetPasswordCredentialByEmail(ctx context.Context, email string) (usecase.PasswordCredential, error) {
+	var c PasswordCredential
+	err := s.db.WithContext(ctx).Where("email = ?", email).First(&c).Error
+	if err != nil {
+		return usecase.PasswordCredential{}, err
+	}
+
+	return c.ConvertToUsecase(), nil
+}
+
+func (s *service) DeletePasswordCredential(ctx context.Context, uid string) error {
+	return s.db.WithContext(ctx).Where("uid = ?", uid).Delete(&PasswordCredential{}).Error
+}
+
+// Convert core model to Usecase
+func (c PasswordCredential) ConvertToUsecase() usecase.PasswordCredential {
+	return usecase.PasswordCredential{
+		UID:          c.UID,
+		Email:        c.Email,
+		PasswordHash: c.PasswordHash,
+		CreatedAt:    c.CreatedAt,
+		UpdatedAt:    c.UpdatedAt,
+	}
+}
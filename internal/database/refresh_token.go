@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"librarease/internal/usecase"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RefreshToken struct {
+	ID        uuid.UUID  `gorm:"column:id;primaryKey;type:uuid;default:uuid_generate_v4()"`
+	UserID    uuid.UUID  `gorm:"column:user_id;type:uuid;index"`
+	User      *User      `gorm:"foreignKey:UserID;references:ID"`
+	UID       string     `gorm:"column:uid;type:varchar(255)"`
+	TokenHash string     `gorm:"column:token_hash;type:varchar(255);uniqueIndex"`
+	ExpiresAt time.Time  `gorm:"column:expires_at"`
+	RevokedAt *time.Time `gorm:"column:revoked_at"`
+	CreatedAt time.Time  `gorm:"column:created_at"`
+	UpdatedAt time.Time  `gorm:"column:updated_at"`
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+func (s *service) CreateRefreshToken(ctx context.Context, rt usecase.RefreshToken) (usecase.RefreshToken, error) {
+	t := RefreshToken{
+		UserID:    rt.UserID,
+		UID:       rt.UID,
+		TokenHash: rt.TokenHash,
+		ExpiresAt: rt.ExpiresAt,
+	}
+	err := s.db.WithContext(ctx).Create(&t).Error
+	if err != nil {
+		return usecase.RefreshToken{}, err
+	}
+
+	return t.ConvertToUsecase(), nil
+}
+
+func (s *service) GetRefreshTokenByHash(ctx context.Context, hash string) (usecase.RefreshToken, error) {
+	var t RefreshToken
+	err := s.db.WithContext(ctx).Where("token_hash = ?", hash).First(&t).Error
+	if err != nil {
+		return usecase.RefreshToken{}, err
+	}
+
+	return t.ConvertToUsecase(), nil
+}
+
+// RevokeRefreshToken marks the token revoked. It fails with
+// ErrInvalidRefreshToken when the token was already revoked, so that
+// concurrent refreshes cannot both rotate the same token.
+func (s *service) RevokeRefreshToken(ctx context.Context, id uuid.UUID) error {
+	res := s.db.
+		WithContext(ctx).
+		Model(&RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return usecase.ErrInvalidRefreshToken
+	}
+
+	return nil
+}
+
+// Convert core model to Usecase
+func (t RefreshToken) ConvertToUsecase() usecase.RefreshToken {
+	return usecase.RefreshToken{
+		ID:        t.ID,
+		UserID:    t.UserID,
+		UID:       t.UID,
+		TokenHash: t.TokenHash,
+		ExpiresAt: t.ExpiresAt,
+		RevokedAt: t.RevokedAt,
+		CreatedAt: t.CreatedAt,
+		UpdatedAt: t.UpdatedAt,
+	}
+}
@@ -0,0 +1,42 @@
+package database
+
+import (
+	"context"
+	"librarease/internal/usecase"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+type Reminder struct {
+	ID             uuid.UUID     `gorm:"column:id;primaryKey;type:uuid;default:uuid_generate_v4()"`
+	BorrowingID    *uuid.UUID    `gorm:"column:borrowing_id;type:uuid"`
+	Borrowing      *Borrowing    `gorm:"foreignKey:BorrowingID;references:ID"`
+	SubscriptionID *uuid.UUID    `gorm:"column:subscription_id;type:uuid"`
+	Subscription   *Subscription `gorm:"foreignKey:SubscriptionID;references:ID"`
+	Kind           string        `gorm:"column:kind;type:varchar(16)"`
+	DueAt          time.Time     `gorm:"column:due_at"`
+	CreatedAt      time.Time     `gorm:"column:created_at"`
+}
+
+func (Reminder) TableName() string {
+	return "reminders"
+}
+
+func (s *service) ClaimReminder(ctx context.Context, r usecase.Reminder) (bool, error) {
+	d := Reminder{
+		BorrowingID:    r.BorrowingID,
+		SubscriptionID: r.SubscriptionID,
+		Kind:           r.Kind,
+		DueAt:          r.DueAt,
+	}
+	res := s.db.
+		WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&d)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
@@ -0,0 +1,137 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"librarease/internal/usecase"
+)
+
+// recordingNotifier keeps the notifications instead of sending them.
+type recordingNotifier struct {
+	mu   sync.Mutex
+	sent []usecase.Notification
+	// reject is an email the notifications to fail for.
+	reject string
+}
+
+func (r *recordingNotifier) Notify(_ context.Context, n usecase.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if n.Email == r.reject {
+		return errors.New("mailbox unavailable")
+	}
+	r.sent = append(r.sent, n)
+	return nil
+}
+
+// to returns the subjects of the notifications sent to the email.
+func (r *recordingNotifier) to(email string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var subjects []string
+	for _, n := range r.sent {
+		if n.Email == email {
+			subjects = append(subjects, n.Subject)
+		}
+	}
+	return subjects
+}
+
+func TestSendReminders(t *testing.T) {
+	ctx := context.Background()
+	srv := New()
+	f := seedCheckout(t, srv, 5, 1, 1)
+	var rec recordingNotifier
+	uc := usecase.New(srv, nil, &rec)
+
+	b, err := uc.CreateBorrowing(ctx, usecase.Borrowing{BookID: f.books[0].ID, SubscriptionID: f.subs[0].ID, StaffID: f.staff.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, err := uc.GetSubscriptionByID(ctx, f.subs[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	email := sub.User.Email
+
+	setDue := func(due time.Time) {
+		t.Helper()
+		if _, err := srv.UpdateBorrowing(ctx, usecase.Borrowing{ID: b.ID, DueAt: due}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	send := func() {
+		t.Helper()
+		if _, err := uc.SendReminders(ctx, 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// due in a week, too early for a reminder
+	send()
+	if got := rec.to(email); len(got) != 0 {
+		t.Fatalf("reminded a week before due: %v", got)
+	}
+
+	setDue(time.Now().Add(24 * time.Hour))
+	send()
+	send()
+	got := rec.to(email)
+	if len(got) != 1 || !strings.Contains(got[0], "is due on") {
+		t.Fatalf("reminders a day before due = %v, want one due soon", got)
+	}
+
+	setDue(time.Now().Add(-time.Hour))
+	send()
+	send()
+	got = rec.to(email)
+	if len(got) != 2 || !strings.Contains(got[1], "is overdue") {
+		t.Fatalf("reminders once overdue = %v, want one more overdue", got)
+	}
+}
+
+func TestSendRemindersSkipsFailures(t *testing.T) {
+	ctx := context.Background()
+	srv := New()
+	f := seedCheckout(t, srv, 5, 2, 2)
+	var rec recordingNotifier
+	uc := usecase.New(srv, nil, &rec)
+
+	var emails []string
+	for i, sub := range f.subs {
+		b, err := uc.CreateBorrowing(ctx, usecase.Borrowing{BookID: f.books[i].ID, SubscriptionID: sub.ID, StaffID: f.staff.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the rejected member's loan comes first
+		due := time.Now().Add(time.Duration(i+1) * time.Hour)
+		if _, err := srv.UpdateBorrowing(ctx, usecase.Borrowing{ID: b.ID, DueAt: due}); err != nil {
+			t.Fatal(err)
+		}
+		s, err := uc.GetSubscriptionByID(ctx, sub.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		emails = append(emails, s.User.Email)
+	}
+	rec.reject = emails[0]
+
+	sent, err := uc.SendReminders(ctx, 2)
+	if err == nil || !strings.Contains(err.Error(), emails[0]) {
+		t.Errorf("err = %v, want the failure for %s", err, emails[0])
+	}
+	if sent != 1 || len(rec.to(emails[1])) != 1 {
+		t.Errorf("sent %d, to the other member %v, want one", sent, rec.to(emails[1]))
+	}
+
+	// the failed reminder is tried again
+	rec.reject = ""
+	if sent, err := uc.SendReminders(ctx, 2); err != nil || sent != 1 || len(rec.to(emails[0])) != 1 {
+		t.Errorf("retry sent %d, err %v, to the member %v, want one", sent, err, rec.to(emails[0]))
+	}
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"librarease/internal/usecase"
+
+	"github.com/google/uuid"
+)
+
+func TestRenewBorrowing(t *testing.T) {
+	ctx := context.Background()
+	srv := New()
+	f := seedCheckout(t, srv, 5, 2, 2)
+	uc := usecase.New(srv, nil, nil)
+
+	b, err := uc.CreateBorrowing(ctx, usecase.Borrowing{BookID: f.books[0].ID, SubscriptionID: f.subs[0].ID, StaffID: f.staff.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// as stored, to the database's precision
+	if b, err = uc.GetBorrowingByID(ctx, b.ID); err != nil {
+		t.Fatal(err)
+	}
+	// subscribed with 2 renewals, which raising the membership's does not change
+	m, err := uc.GetMembershipByID(ctx, f.subs[0].MembershipID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.MaxRenewals = 5
+	if _, err := uc.UpdateMembership(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+
+	// renewed once by staff and once by the member, a loan period each
+	due := b.DueAt
+	for i, staffID := range []*uuid.UUID{&f.staff.ID, nil} {
+		r, err := uc.RenewBorrowing(ctx, b.ID, staffID)
+		if err != nil {
+			t.Fatalf("renewal %d: %v", i+1, err)
+		}
+		due = due.AddDate(0, 0, 7)
+		if !r.DueAt.Equal(due) || r.RenewalCount != i+1 {
+			t.Errorf("renewal %d: due %s, count %d, want %s, %d", i+1, r.DueAt, r.RenewalCount, due, i+1)
+		}
+	}
+	if _, err := uc.RenewBorrowing(ctx, b.ID, nil); !errors.Is(err, usecase.ErrRenewalLimitReached) {
+		t.Errorf("third renewal: err = %v, want ErrRenewalLimitReached", err)
+	}
+
+	renewals, err := uc.ListBorrowingRenewals(ctx, b.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(renewals) != 2 {
+		t.Fatalf("%d renewals, want 2", len(renewals))
+	}
+	if r := renewals[0]; r.StaffID == nil || *r.StaffID != f.staff.ID || !r.PreviousDueAt.Equal(b.DueAt) || !r.DueAt.Equal(b.DueAt.AddDate(0, 0, 7)) {
+		t.Errorf("first renewal = %+v", r)
+	}
+	if r := renewals[1]; r.StaffID != nil || !r.PreviousDueAt.Equal(b.DueAt.AddDate(0, 0, 7)) || !r.DueAt.Equal(due) {
+		t.Errorf("second renewal = %+v", r)
+	}
+
+	// another member waits for the book
+	b, err = uc.CreateBorrowing(ctx, usecase.Borrowing{BookID: f.books[1].ID, SubscriptionID: f.subs[0].ID, StaffID: f.staff.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.PlaceHold(ctx, usecase.Hold{BookID: f.books[1].ID, SubscriptionID: f.subs[1].ID}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.RenewBorrowing(ctx, b.ID, nil); !errors.Is(err, usecase.ErrBookOnHold) {
+		t.Errorf("renew with a waiting hold: err = %v, want ErrBookOnHold", err)
+	}
+
+	if _, err := srv.UpdateBorrowing(ctx, usecase.Borrowing{ID: b.ID, DueAt: time.Now().Add(-time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.RenewBorrowing(ctx, b.ID, nil); !errors.Is(err, usecase.ErrBorrowingOverdue) {
+		t.Errorf("renew overdue: err = %v, want ErrBorrowingOverdue", err)
+	}
+	if got, err := uc.GetBorrowingByID(ctx, b.ID); err != nil || got.RenewalCount != 0 {
+		t.Errorf("refused renewals: count %d, err = %v, want 0", got.RenewalCount, err)
+	}
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// searchConfig is the text search configuration of works.search_vector,
+// which migration 0005_work_search generates with the same one.
+// simple neither stems nor drops stop words, so it suits catalogues in
+// any language.
+const searchConfig = "simple"
+
+// prefixQuery turns free text into a tsquery matching works that contain
+// every word, each as a prefix for type-ahead. It returns "" when q has
+// no words.
+func prefixQuery(q string) string {
+	words := strings.FieldsFunc(q, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		words[i] = strings.ToLower(w) + ":*"
+	}
+	return strings.Join(words, " & ")
+}
+
+// searchWorks filters the query to the works of table matching q, by
+// their search vector or, to forgive typos, by trigram word similarity
+// of the title or author.
+func searchWorks(db *gorm.DB, table, q string) *gorm.DB {
+	cond := fmt.Sprintf("? <%% %[1]s.title OR ? <%% %[1]s.author", table)
+	vars := []interface{}{q, q}
+	if tsq := prefixQuery(q); tsq != "" {
+		cond = fmt.Sprintf("%s.search_vector @@ to_tsquery('%s', ?) OR ", table, searchConfig) + cond
+		vars = append([]interface{}{tsq}, vars...)
+	}
+	return db.Where(cond, vars...)
+}
+
+// searchRank orders works of table matching q by the rank of their
+// search vector, title words outranking author ones, then by similarity.
+func searchRank(table, q string) clause.Expr {
+	sql := fmt.Sprintf("greatest(word_similarity(?, %[1]s.title), word_similarity(?, %[1]s.author)) DESC", table)
+	vars := []interface{}{q, q}
+	if tsq := prefixQuery(q); tsq != "" {
+		sql = fmt.Sprintf("ts_rank(%s.search_vector, to_tsquery('%s', ?)) DESC, ", table, searchConfig) + sql
+		vars = append([]interface{}{tsq}, vars...)
+	}
+	return clause.Expr{SQL: sql, Vars: vars, WithoutParentheses: true}
+}
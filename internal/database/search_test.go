@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"librarease/internal/usecase"
+
+	"github.com/google/uuid"
+)
+
+func TestPrefixQuery(t *testing.T) {
+	tests := map[string]string{
+		"Dune":             "dune:*",
+		"  the Lord, of  ": "the:* & lord:* & of:*",
+		"O'Brien & !(x|y)": "o:* & brien:* & x:* & y:*",
+		"Ἰλιάς 1984":       "ἰλιάς:* & 1984:*",
+		"&!|:*()":          "",
+	}
+	for q, want := range tests {
+		if got := prefixQuery(q); got != want {
+			t.Errorf("prefixQuery(%q) = %q, want %q", q, got, want)
+		}
+	}
+}
+
+func TestSearchBooks(t *testing.T) {
+	ctx := context.Background()
+	srv := New()
+	lib, err := srv.CreateLibrary(ctx, usecase.Library{Name: "search"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, w := range []usecase.Work{
+		{Title: "Dune", Author: "Frank Herbert"},
+		{Title: "Dune Messiah", Author: "Frank Herbert"},
+		{Title: "Herbert's Garden", Author: "Ann Other"},
+	} {
+		w.LibraryID = lib.ID
+		w, err := srv.CreateWork(ctx, w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = srv.CreateBook(ctx, usecase.Book{Code: fmt.Sprintf("S-%d", i), WorkID: w.ID, LibraryID: lib.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		q    string
+		want []string
+	}{
+		{"dun", []string{"Dune", "Dune Messiah"}},
+		{"messiah dune", []string{"Dune Messiah"}},
+		{"herbert", []string{"Herbert's Garden", "Dune", "Dune Messiah"}},
+		{"herbrt", []string{"Herbert's Garden", "Dune", "Dune Messiah"}},
+	}
+	for _, tt := range tests {
+		books, _, err := srv.ListBooks(ctx, usecase.ListBooksOption{
+			LibraryIDs: uuid.UUIDs{lib.ID},
+			Query:      tt.q,
+			Limit:      10,
+		})
+		if err != nil {
+			t.Fatalf("%q: %v", tt.q, err)
+		}
+		var got []string
+		for _, b := range books {
+			got = append(got, b.Title)
+		}
+		if len(got) != len(tt.want) || got[0] != tt.want[0] {
+			t.Errorf("search %q = %v, want %v", tt.q, got, tt.want)
+		}
+	}
+}
@@ -1 +1,79 @@
 package database
+
+import (
+	"context"
+	"librarease/internal/usecase"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Setting holds the configuration of a library, one row per library.
+type Setting struct {
+	LibraryID          uuid.UUID `gorm:"column:library_id;primaryKey;type:uuid"`
+	Library            *Library  `gorm:"foreignKey:LibraryID;references:ID"`
+	MaxOutstandingFine *int      `gorm:"column:max_outstanding_fine"`
+	HoldPickupDays     *int      `gorm:"column:hold_pickup_days"`
+	AutoSuspendFine    *int      `gorm:"column:auto_suspend_fine"`
+	AutoSuspendOverdue *int      `gorm:"column:auto_suspend_overdue"`
+	CreatedAt          time.Time `gorm:"column:created_at"`
+	UpdatedAt          time.Time `gorm:"column:updated_at"`
+	// DeletedAt is set while the library is deleted.
+	DeletedAt *gorm.DeletedAt `gorm:"column:deleted_at"`
+}
+
+func (Setting) TableName() string {
+	return "settings"
+}
+
+func (s *service) GetSetting(ctx context.Context, libraryID uuid.UUID) (usecase.Setting, error) {
+	var st Setting
+	err := s.db.
+		WithContext(ctx).
+		Where("library_id = ?", libraryID).
+		First(&st).
+		Error
+	if err != nil {
+		return usecase.Setting{}, err
+	}
+
+	return st.ConvertToUsecase(), nil
+}
+
+func (s *service) UpsertSetting(ctx context.Context, us usecase.Setting) (usecase.Setting, error) {
+	st := Setting{
+		LibraryID:          us.LibraryID,
+		MaxOutstandingFine: us.MaxOutstandingFine,
+		HoldPickupDays:     us.HoldPickupDays,
+		AutoSuspendFine:    us.AutoSuspendFine,
+		AutoSuspendOverdue: us.AutoSuspendOverdue,
+	}
+	err := s.db.
+		WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "library_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"max_outstanding_fine", "hold_pickup_days", "auto_suspend_fine", "auto_suspend_overdue", "updated_at"}),
+		}).
+		Create(&st).
+		Error
+	if err != nil {
+		return usecase.Setting{}, err
+	}
+
+	return s.GetSetting(ctx, us.LibraryID)
+}
+
+// Convert core model to Usecase
+func (s Setting) ConvertToUsecase() usecase.Setting {
+	return usecase.Setting{
+		LibraryID:          s.LibraryID,
+		MaxOutstandingFine: s.MaxOutstandingFine,
+		HoldPickupDays:     s.HoldPickupDays,
+		AutoSuspendFine:    s.AutoSuspendFine,
+		AutoSuspendOverdue: s.AutoSuspendOverdue,
+		CreatedAt:          s.CreatedAt,
+		UpdatedAt:          s.UpdatedAt,
+	}
+}
@@ -36,6 +36,10 @@ func (s *service) ListStaffs(ctx context.Context, opt usecase.ListStaffsOption)
 
 	db := s.db.Model([]Staff{}).WithContext(ctx)
 
+	if opt.IncludeDeleted {
+		db = db.Unscoped()
+	}
+
 	if opt.LibraryID != "" {
 		db = db.Where("library_id = ?", opt.LibraryID)
 	}
@@ -93,6 +97,7 @@ func (s *service) CreateStaff(ctx context.Context, staff usecase.Staff) (usecase
 		Name:      staff.Name,
 		LibraryID: staff.LibraryID,
 		UserID:    staff.UserID,
+		Role:      staff.Role,
 	}
 
 	err := s.db.Create(&st).Error
@@ -130,6 +135,7 @@ func (s *service) GetStaffByID(ctx context.Context, id uuid.UUID) (usecase.Staff
 func (s *service) UpdateStaff(ctx context.Context, staff usecase.Staff) (usecase.Staff, error) {
 	st := Staff{
 		Name: staff.Name,
+		Role: staff.Role,
 	}
 
 	err := s.db.WithContext(ctx).Where("id = ?", staff.ID).Updates(&st).Error
@@ -140,6 +146,18 @@ func (s *service) UpdateStaff(ctx context.Context, staff usecase.Staff) (usecase
 	return st.ConvertToUsecase(), nil
 }
 
+func (s *service) DeleteStaff(ctx context.Context, id uuid.UUID) error {
+	return s.softDelete(ctx, &Staff{}, id)
+}
+
+func (s *service) RestoreStaff(ctx context.Context, id uuid.UUID) error {
+	return s.restore(ctx, &Staff{}, id)
+}
+
+func (s *service) PurgeStaff(ctx context.Context, id uuid.UUID) error {
+	return s.purge(ctx, &Staff{}, id)
+}
+
 // Convert core model to Usecase
 func (st Staff) ConvertToUsecase() usecase.Staff {
 	var d *time.Time
@@ -151,6 +169,7 @@ func (st Staff) ConvertToUsecase() usecase.Staff {
 		Name:      st.Name,
 		LibraryID: st.LibraryID,
 		UserID:    st.UserID,
+		Role:      st.Role,
 		CreatedAt: st.CreatedAt,
 		UpdatedAt: st.UpdatedAt,
 		DeleteAt:  d,
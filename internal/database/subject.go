@@ -0,0 +1,210 @@
+package database
+
+import (
+	"context"
+	"librarease/internal/usecase"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Subject struct {
+	ID        uuid.UUID `gorm:"column:id;primaryKey;type:uuid;default:uuid_generate_v4()"`
+	Kind      string    `gorm:"column:kind;type:varchar(16);not null;uniqueIndex:idx_subjects_library_id_kind_name,priority:2"`
+	Name      string    `gorm:"column:name;type:varchar(255);not null;uniqueIndex:idx_subjects_library_id_kind_name,priority:3"`
+	LibraryID uuid.UUID `gorm:"column:library_id;type:uuid;not null;uniqueIndex:idx_subjects_library_id_kind_name,priority:1"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+	// DeletedAt is only set by DeleteLibrary; subjects themselves are
+	// deleted for good.
+	DeletedAt *gorm.DeletedAt `gorm:"column:deleted_at"`
+	Library   *Library        `gorm:"foreignKey:LibraryID"`
+}
+
+func (Subject) TableName() string {
+	return "subjects"
+}
+
+// WorkSubject is the join table of works and the subjects they are
+// classified under.
+type WorkSubject struct {
+	WorkID    uuid.UUID `gorm:"column:work_id;type:uuid;primaryKey"`
+	SubjectID uuid.UUID `gorm:"column:subject_id;type:uuid;primaryKey;index"`
+}
+
+func (WorkSubject) TableName() string {
+	return "work_subjects"
+}
+
+// subjectsCond matches the works classified under all of a list of
+// subjects, given the column of the work id, the subject ids and their
+// number.
+const subjectsCond = ` IN (
+	SELECT work_id FROM work_subjects
+	WHERE subject_id IN ?
+	GROUP BY work_id
+	HAVING count(*) = ?
+)`
+
+func (s *service) ListSubjects(ctx context.Context, opt usecase.ListSubjectsOption) ([]usecase.Subject, int, error) {
+	var (
+		subjects  []Subject
+		usubjects []usecase.Subject
+		count     int64
+	)
+
+	db := s.db.Model([]Subject{}).WithContext(ctx)
+
+	if opt.LibraryIDs != nil {
+		db = db.Where("library_id IN ?", opt.LibraryIDs)
+	}
+	if opt.IDs != nil {
+		db = db.Where("id IN ?", opt.IDs)
+	}
+	if opt.Kinds != nil {
+		db = db.Where("kind IN ?", opt.Kinds)
+	}
+	if opt.Name != "" {
+		db = db.Where("name ILIKE ?", "%"+opt.Name+"%")
+	}
+
+	var (
+		orderIn = "ASC"
+		orderBy = "name"
+	)
+	if opt.SortBy != "" {
+		orderBy = opt.SortBy
+	}
+	if opt.SortIn != "" {
+		orderIn = opt.SortIn
+	}
+
+	err := db.
+		Count(&count).
+		Offset(opt.Skip).
+		Limit(opt.Limit).
+		Order(orderBy + " " + orderIn).
+		Find(&subjects).
+		Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, sub := range subjects {
+		usubjects = append(usubjects, sub.ConvertToUsecase())
+	}
+
+	return usubjects, int(count), nil
+}
+
+func (s *service) GetSubjectByID(ctx context.Context, id uuid.UUID) (usecase.Subject, error) {
+	var sub Subject
+
+	err := s.db.WithContext(ctx).Where("id = ?", id).First(&sub).Error
+	if err != nil {
+		return usecase.Subject{}, err
+	}
+
+	return sub.ConvertToUsecase(), nil
+}
+
+func (s *service) CreateSubject(ctx context.Context, subject usecase.Subject) (usecase.Subject, error) {
+	sub := Subject{
+		Kind:      subject.Kind,
+		Name:      subject.Name,
+		LibraryID: subject.LibraryID,
+	}
+
+	err := s.db.WithContext(ctx).Create(&sub).Error
+	if err != nil {
+		return usecase.Subject{}, err
+	}
+	return sub.ConvertToUsecase(), nil
+}
+
+func (s *service) UpdateSubject(ctx context.Context, subject usecase.Subject) (usecase.Subject, error) {
+	sub := Subject{
+		ID:   subject.ID,
+		Kind: subject.Kind,
+		Name: subject.Name,
+	}
+
+	err := s.db.WithContext(ctx).Updates(&sub).Error
+	if err != nil {
+		return usecase.Subject{}, err
+	}
+	return s.GetSubjectByID(ctx, subject.ID)
+}
+
+func (s *service) DeleteSubject(ctx context.Context, id uuid.UUID) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("subject_id = ?", id).Delete(&WorkSubject{}).Error
+		if err != nil {
+			return err
+		}
+
+		res := tx.Unscoped().Where("id = ?", id).Delete(&Subject{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return usecase.ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (s *service) SetWorkSubjects(ctx context.Context, workID uuid.UUID, subjectIDs uuid.UUIDs) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("work_id = ?", workID).Delete(&WorkSubject{}).Error
+		if err != nil || len(subjectIDs) == 0 {
+			return err
+		}
+
+		ws := make([]WorkSubject, 0, len(subjectIDs))
+		for _, id := range subjectIDs {
+			ws = append(ws, WorkSubject{WorkID: workID, SubjectID: id})
+		}
+		return tx.Create(&ws).Error
+	})
+}
+
+func (s *service) BookFacets(ctx context.Context, opt usecase.ListBooksOption) ([]usecase.Facet, error) {
+	var rows []struct {
+		Subject
+		Count int
+	}
+
+	err := s.db.
+		WithContext(ctx).
+		Table("(?) AS b", s.bookQuery(ctx, opt).Select("books.work_id")).
+		Select("subjects.*, count(*) AS count").
+		Joins("JOIN work_subjects ON work_subjects.work_id = b.work_id").
+		Joins("JOIN subjects ON subjects.id = work_subjects.subject_id").
+		Group("subjects.id").
+		Order("count DESC, subjects.name").
+		Scan(&rows).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	facets := make([]usecase.Facet, 0, len(rows))
+	for _, r := range rows {
+		facets = append(facets, usecase.Facet{Subject: r.Subject.ConvertToUsecase(), Count: r.Count})
+	}
+	return facets, nil
+}
+
+// Convert core model to Usecase
+func (sub Subject) ConvertToUsecase() usecase.Subject {
+	return usecase.Subject{
+		ID:        sub.ID,
+		Kind:      sub.Kind,
+		Name:      sub.Name,
+		LibraryID: sub.LibraryID,
+		CreatedAt: sub.CreatedAt,
+		UpdatedAt: sub.UpdatedAt,
+	}
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"librarease/internal/usecase"
+
+	"github.com/google/uuid"
+)
+
+func TestBookFacets(t *testing.T) {
+	ctx := context.Background()
+	srv := New()
+	uc := usecase.New(srv, nil, nil)
+	lib, err := srv.CreateLibrary(ctx, usecase.Library{Name: "facets"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subject := func(kind, name string) usecase.Subject {
+		t.Helper()
+		s, err := uc.CreateSubject(ctx, usecase.Subject{Kind: kind, Name: name, LibraryID: lib.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	fiction := subject(usecase.SubjectKindGenre, "Fiction")
+	history := subject(usecase.SubjectKindSubject, "History")
+	classic := subject(usecase.SubjectKindTag, "classic")
+
+	// Dune and Emma have two copies each, the history book one.
+	for i, w := range []usecase.Work{
+		{Title: "Dune", SubjectIDs: uuid.UUIDs{fiction.ID}},
+		{Title: "Emma", SubjectIDs: uuid.UUIDs{fiction.ID, classic.ID}},
+		{Title: "SPQR", SubjectIDs: uuid.UUIDs{history.ID}},
+	} {
+		w.LibraryID = lib.ID
+		w, err := uc.CreateWork(ctx, w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := 0; j < 2-i/2; j++ {
+			_, err := srv.CreateBook(ctx, usecase.Book{Code: fmt.Sprintf("F-%d-%d", i, j), WorkID: w.ID, LibraryID: lib.ID})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	facets, err := uc.BookFacets(ctx, usecase.ListBooksOption{LibraryIDs: uuid.UUIDs{lib.ID}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range facets {
+		got = append(got, fmt.Sprintf("%s %d", f.Subject.Name, f.Count))
+	}
+	if fmt.Sprint(got) != "[Fiction 4 classic 2 History 1]" {
+		t.Errorf("facets = %v, want Fiction 4, classic 2, History 1", got)
+	}
+
+	_, total, err := uc.ListBooks(ctx, usecase.ListBooksOption{
+		LibraryIDs: uuid.UUIDs{lib.ID},
+		SubjectIDs: uuid.UUIDs{fiction.ID, classic.ID},
+		Limit:      10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 {
+		t.Errorf("books of Fiction and classic = %d, want 2", total)
+	}
+
+	other, err := srv.CreateLibrary(ctx, usecase.Library{Name: "other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = uc.CreateWork(ctx, usecase.Work{Title: "Dune", LibraryID: other.ID, SubjectIDs: uuid.UUIDs{fiction.ID}})
+	if !errors.Is(err, usecase.ErrInvalidArgument) {
+		t.Errorf("work with a subject of another library: error = %v, want %v", err, usecase.ErrInvalidArgument)
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Subscription represents user's purchase of a membership
@@ -19,11 +20,14 @@ type Subscription struct {
 	CreatedAt    time.Time       `gorm:"column:created_at"`
 	UpdatedAt    time.Time       `gorm:"column:updated_at"`
 	DeletedAt    *gorm.DeletedAt `gorm:"column:deleted_at"`
+	ExpiredAt    *time.Time      `gorm:"column:expired_at"`
+	SuspendedAt  *time.Time      `gorm:"column:suspended_at"`
 	Borrowings   []Borrowing
 
 	// Granfathering the membership
 	ExpiresAt       time.Time `gorm:"column:expires_at"`
 	FinePerDay      int       `gorm:"column:fine_per_day;type:int"`
+	MaxRenewals     int       `gorm:"column:max_renewals;type:int;default:0"`
 	LoanPeriod      int       `gorm:"column:loan_period;type:int"`
 	ActiveLoanLimit int       `gorm:"column:active_loan_limit;type:int"`
 }
@@ -41,6 +45,10 @@ func (s *service) ListSubscriptions(ctx context.Context, opt usecase.ListSubscri
 
 	db := s.db.Model([]Subscription{}).WithContext(ctx)
 
+	if opt.IncludeDeleted {
+		db = db.Unscoped()
+	}
+
 	if opt.UserID != "" {
 		db = db.Where("user_id = ?", opt.UserID)
 	}
@@ -53,6 +61,22 @@ func (s *service) ListSubscriptions(ctx context.Context, opt usecase.ListSubscri
 	if opt.IsActive {
 		db = db.Where("expires_at > ?", time.Now())
 	}
+	if opt.IsSuspended {
+		db = db.Where("suspended_at IS NOT NULL")
+	}
+	if !opt.ExpiresBefore.IsZero() {
+		db = db.Where("expires_at < ?", opt.ExpiresBefore).Order("expires_at")
+	}
+	if opt.PendingExpiry {
+		db = db.Where("expires_at < ? AND expired_at IS NULL", time.Now())
+	}
+	if opt.ExpiredUnnotified {
+		db = db.
+			Where("subscriptions.expired_at IS NOT NULL").
+			Where("NOT EXISTS (SELECT 1 FROM reminders r WHERE r.subscription_id = subscriptions.id AND r.kind = ? AND r.due_at = subscriptions.expires_at)",
+				usecase.ReminderKindSubscriptionExpired).
+			Order("subscriptions.expired_at, subscriptions.id")
+	}
 	if opt.MembershipName != "" {
 		db = db.
 			Joins("JOIN memberships m ON subscriptions.membership_id = m.id").
@@ -102,6 +126,7 @@ func (s *service) CreateSubscription(ctx context.Context, sub usecase.Subscripti
 		UpdatedAt:       sub.UpdatedAt,
 		ExpiresAt:       sub.ExpiresAt,
 		FinePerDay:      sub.FinePerDay,
+		MaxRenewals:     sub.MaxRenewals,
 		LoanPeriod:      sub.LoanPeriod,
 		ActiveLoanLimit: sub.ActiveLoanLimit,
 	}
@@ -144,6 +169,18 @@ func (s *service) GetSubscriptionByID(ctx context.Context, id uuid.UUID) (usecas
 	return usub, nil
 }
 
+// LockSubscription takes a row lock on the subscription, held until the
+// surrounding transaction ends.
+func (s *service) LockSubscription(ctx context.Context, id uuid.UUID) error {
+	return s.db.
+		WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		Where("id = ?", id).
+		First(&Subscription{}).
+		Error
+}
+
 func (s *service) UpdateSubscription(ctx context.Context, sub usecase.Subscription) (usecase.Subscription, error) {
 	d := Subscription{
 		ID:              sub.ID,
@@ -153,6 +190,7 @@ func (s *service) UpdateSubscription(ctx context.Context, sub usecase.Subscripti
 		UpdatedAt:       sub.UpdatedAt,
 		ExpiresAt:       sub.ExpiresAt,
 		FinePerDay:      sub.FinePerDay,
+		MaxRenewals:     sub.MaxRenewals,
 		LoanPeriod:      sub.LoanPeriod,
 		ActiveLoanLimit: sub.ActiveLoanLimit,
 	}
@@ -166,6 +204,18 @@ func (s *service) UpdateSubscription(ctx context.Context, sub usecase.Subscripti
 	return d.ConvertToUsecase(), nil
 }
 
+func (s *service) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	return s.softDelete(ctx, &Subscription{}, id)
+}
+
+func (s *service) RestoreSubscription(ctx context.Context, id uuid.UUID) error {
+	return s.restore(ctx, &Subscription{}, id)
+}
+
+func (s *service) PurgeSubscription(ctx context.Context, id uuid.UUID) error {
+	return s.purge(ctx, &Subscription{}, id)
+}
+
 // Convert core model to Usecase
 func (s Subscription) ConvertToUsecase() usecase.Subscription {
 	var d *time.Time
@@ -179,8 +229,11 @@ func (s Subscription) ConvertToUsecase() usecase.Subscription {
 		CreatedAt:       s.CreatedAt,
 		UpdatedAt:       s.UpdatedAt,
 		DeletedAt:       d,
+		ExpiredAt:       s.ExpiredAt,
+		SuspendedAt:     s.SuspendedAt,
 		ExpiresAt:       s.ExpiresAt,
 		FinePerDay:      s.FinePerDay,
+		MaxRenewals:     s.MaxRenewals,
 		LoanPeriod:      s.LoanPeriod,
 		ActiveLoanLimit: s.ActiveLoanLimit,
 	}
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"librarease/internal/usecase"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SubscriptionRenewal is the history of extensions of a subscription.
+type SubscriptionRenewal struct {
+	ID                uuid.UUID     `gorm:"column:id;primaryKey;type:uuid;default:uuid_generate_v4()"`
+	SubscriptionID    uuid.UUID     `gorm:"column:subscription_id;type:uuid;index"`
+	Subscription      *Subscription `gorm:"foreignKey:SubscriptionID;references:ID"`
+	StaffID           *uuid.UUID    `gorm:"column:staff_id;type:uuid"`
+	Staff             *Staff        `gorm:"foreignKey:StaffID;references:ID"`
+	PreviousExpiresAt time.Time     `gorm:"column:previous_expires_at"`
+	ExpiresAt         time.Time     `gorm:"column:expires_at"`
+	Regrandfathered   bool          `gorm:"column:regrandfathered"`
+	FinePerDay        int           `gorm:"column:fine_per_day"`
+	MaxRenewals       int           `gorm:"column:max_renewals"`
+	LoanPeriod        int           `gorm:"column:loan_period"`
+	ActiveLoanLimit   int           `gorm:"column:active_loan_limit"`
+	CreatedAt         time.Time     `gorm:"column:created_at"`
+}
+
+func (SubscriptionRenewal) TableName() string {
+	return "subscription_renewals"
+}
+
+// RenewSubscription writes both the subscription and its history, so
+// callers run it in a transaction.
+func (s *service) RenewSubscription(ctx context.Context, r usecase.SubscriptionRenewal) (usecase.Subscription, error) {
+	err := s.db.
+		WithContext(ctx).
+		Model(&Subscription{}).
+		Where("id = ?", r.SubscriptionID).
+		Updates(map[string]interface{}{
+			"expires_at":        r.ExpiresAt,
+			"expired_at":        nil,
+			"fine_per_day":      r.FinePerDay,
+			"max_renewals":      r.MaxRenewals,
+			"loan_period":       r.LoanPeriod,
+			"active_loan_limit": r.ActiveLoanLimit,
+		}).
+		Error
+	if err != nil {
+		return usecase.Subscription{}, err
+	}
+
+	err = s.db.WithContext(ctx).Create(&SubscriptionRenewal{
+		SubscriptionID:    r.SubscriptionID,
+		StaffID:           r.StaffID,
+		PreviousExpiresAt: r.PreviousExpiresAt,
+		ExpiresAt:         r.ExpiresAt,
+		Regrandfathered:   r.Regrandfathered,
+		FinePerDay:        r.FinePerDay,
+		MaxRenewals:       r.MaxRenewals,
+		LoanPeriod:        r.LoanPeriod,
+		ActiveLoanLimit:   r.ActiveLoanLimit,
+	}).Error
+	if err != nil {
+		return usecase.Subscription{}, err
+	}
+
+	return s.GetSubscriptionByID(ctx, r.SubscriptionID)
+}
+
+func (s *service) ListSubscriptionRenewals(ctx context.Context, subscriptionID uuid.UUID) ([]usecase.SubscriptionRenewal, error) {
+	var renewals []SubscriptionRenewal
+	err := s.db.
+		WithContext(ctx).
+		Where("subscription_id = ?", subscriptionID).
+		Order("created_at ASC").
+		Find(&renewals).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]usecase.SubscriptionRenewal, 0, len(renewals))
+	for _, r := range renewals {
+		list = append(list, r.ConvertToUsecase())
+	}
+	return list, nil
+}
+
+func (s *service) MarkSubscriptionExpired(ctx context.Context, id uuid.UUID) error {
+	return s.db.
+		WithContext(ctx).
+		Model(&Subscription{}).
+		Where("id = ?", id).
+		Update("expired_at", time.Now()).
+		Error
+}
+
+// Convert core model to Usecase
+func (r SubscriptionRenewal) ConvertToUsecase() usecase.SubscriptionRenewal {
+	return usecase.SubscriptionRenewal{
+		ID:                r.ID,
+		SubscriptionID:    r.SubscriptionID,
+		StaffID:           r.StaffID,
+		PreviousExpiresAt: r.PreviousExpiresAt,
+		ExpiresAt:         r.ExpiresAt,
+		Regrandfathered:   r.Regrandfathered,
+		FinePerDay:        r.FinePerDay,
+		MaxRenewals:       r.MaxRenewals,
+		LoanPeriod:        r.LoanPeriod,
+		ActiveLoanLimit:   r.ActiveLoanLimit,
+		CreatedAt:         r.CreatedAt,
+	}
+}
@@ -0,0 +1,133 @@
+package database
+
+import (
+	"context"
+	"librarease/internal/usecase"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SubscriptionSuspension is the history of suspensions of a subscription.
+// At most one per subscription is in force, without reinstated_at.
+type SubscriptionSuspension struct {
+	ID                uuid.UUID     `gorm:"column:id;primaryKey;type:uuid;default:uuid_generate_v4()"`
+	SubscriptionID    uuid.UUID     `gorm:"column:subscription_id;type:uuid;index"`
+	Subscription      *Subscription `gorm:"foreignKey:SubscriptionID;references:ID"`
+	StaffID           *uuid.UUID    `gorm:"column:staff_id;type:uuid"`
+	Staff             *Staff        `gorm:"foreignKey:StaffID;references:ID"`
+	Reason            string        `gorm:"column:reason;type:text"`
+	SuspendedAt       time.Time     `gorm:"column:suspended_at"`
+	ReinstatedAt      *time.Time    `gorm:"column:reinstated_at"`
+	ReinstatedStaffID *uuid.UUID    `gorm:"column:reinstated_staff_id;type:uuid"`
+	ReinstatedStaff   *Staff        `gorm:"foreignKey:ReinstatedStaffID;references:ID"`
+	ExpiresAt         *time.Time    `gorm:"column:expires_at"`
+	ExcusedFine       int           `gorm:"column:excused_fine"`
+	CreatedAt         time.Time     `gorm:"column:created_at"`
+	UpdatedAt         time.Time     `gorm:"column:updated_at"`
+}
+
+func (SubscriptionSuspension) TableName() string {
+	return "subscription_suspensions"
+}
+
+// SuspendSubscription writes both the subscription and its history, so
+// callers run it in a transaction.
+func (s *service) SuspendSubscription(ctx context.Context, ss usecase.SubscriptionSuspension) (usecase.Subscription, error) {
+	err := s.db.
+		WithContext(ctx).
+		Model(&Subscription{}).
+		Where("id = ?", ss.SubscriptionID).
+		Update("suspended_at", ss.SuspendedAt).
+		Error
+	if err != nil {
+		return usecase.Subscription{}, err
+	}
+
+	err = s.db.WithContext(ctx).Create(&SubscriptionSuspension{
+		SubscriptionID: ss.SubscriptionID,
+		StaffID:        ss.StaffID,
+		Reason:         ss.Reason,
+		SuspendedAt:    ss.SuspendedAt,
+	}).Error
+	if err != nil {
+		return usecase.Subscription{}, err
+	}
+
+	return s.GetSubscriptionByID(ctx, ss.SubscriptionID)
+}
+
+// ReinstateSubscription writes both the subscription and its history, so
+// callers run it in a transaction. A subscription moved past now is no
+// longer expired.
+func (s *service) ReinstateSubscription(ctx context.Context, ss usecase.SubscriptionSuspension) (usecase.Subscription, error) {
+	updates := map[string]interface{}{"suspended_at": nil}
+	if ss.ExpiresAt != nil {
+		updates["expires_at"] = *ss.ExpiresAt
+		if ss.ExpiresAt.After(time.Now()) {
+			updates["expired_at"] = nil
+		}
+	}
+	err := s.db.
+		WithContext(ctx).
+		Model(&Subscription{}).
+		Where("id = ?", ss.SubscriptionID).
+		Updates(updates).
+		Error
+	if err != nil {
+		return usecase.Subscription{}, err
+	}
+
+	err = s.db.
+		WithContext(ctx).
+		Model(&SubscriptionSuspension{}).
+		Where("subscription_id = ? AND reinstated_at IS NULL", ss.SubscriptionID).
+		Updates(map[string]interface{}{
+			"reinstated_at":       ss.ReinstatedAt,
+			"reinstated_staff_id": ss.ReinstatedStaffID,
+			"expires_at":          ss.ExpiresAt,
+			"excused_fine":        ss.ExcusedFine,
+		}).
+		Error
+	if err != nil {
+		return usecase.Subscription{}, err
+	}
+
+	return s.GetSubscriptionByID(ctx, ss.SubscriptionID)
+}
+
+func (s *service) ListSubscriptionSuspensions(ctx context.Context, subscriptionID uuid.UUID) ([]usecase.SubscriptionSuspension, error) {
+	var suspensions []SubscriptionSuspension
+	err := s.db.
+		WithContext(ctx).
+		Where("subscription_id = ?", subscriptionID).
+		Order("suspended_at ASC").
+		Find(&suspensions).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]usecase.SubscriptionSuspension, 0, len(suspensions))
+	for _, ss := range suspensions {
+		list = append(list, ss.ConvertToUsecase())
+	}
+	return list, nil
+}
+
+// Convert core model to Usecase
+func (ss SubscriptionSuspension) ConvertToUsecase() usecase.SubscriptionSuspension {
+	return usecase.SubscriptionSuspension{
+		ID:                ss.ID,
+		SubscriptionID:    ss.SubscriptionID,
+		StaffID:           ss.StaffID,
+		Reason:            ss.Reason,
+		SuspendedAt:       ss.SuspendedAt,
+		ReinstatedAt:      ss.ReinstatedAt,
+		ReinstatedStaffID: ss.ReinstatedStaffID,
+		ExpiresAt:         ss.ExpiresAt,
+		ExcusedFine:       ss.ExcusedFine,
+		CreatedAt:         ss.CreatedAt,
+		UpdatedAt:         ss.UpdatedAt,
+	}
+}
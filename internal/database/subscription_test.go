@@ -0,0 +1,452 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"librarease/internal/usecase"
+)
+
+func TestRenewSubscription(t *testing.T) {
+	ctx := context.Background()
+	srv := New()
+	f := seedCheckout(t, srv, 5, 1, 1)
+	uc := usecase.New(srv, nil, nil)
+	sub := f.subs[0]
+
+	// a renewal before expiry extends from the expiry
+	renewed, err := uc.RenewSubscription(ctx, usecase.RenewSubscriptionOption{ID: sub.ID, StaffID: &f.staff.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// postgres keeps microseconds
+	if want := sub.ExpiresAt.AddDate(0, 0, 30); renewed.ExpiresAt.Sub(want).Abs() > time.Millisecond {
+		t.Errorf("renewed until %s, want %s", renewed.ExpiresAt, want)
+	}
+
+	// a renewal after expiry extends from now, with the membership's terms
+	lapsed := time.Now().Add(-48 * time.Hour)
+	if _, err := srv.UpdateSubscription(ctx, usecase.Subscription{ID: sub.ID, ExpiresAt: lapsed, LoanPeriod: 1}); err != nil {
+		t.Fatal(err)
+	}
+	renewed, err = uc.RenewSubscription(ctx, usecase.RenewSubscriptionOption{ID: sub.ID, Regrandfather: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Until(renewed.ExpiresAt); d < 29*24*time.Hour || d > 30*24*time.Hour {
+		t.Errorf("renewed until %s, want 30 days from now", renewed.ExpiresAt)
+	}
+	if renewed.LoanPeriod != 7 {
+		t.Errorf("loan period = %d, want the membership's 7", renewed.LoanPeriod)
+	}
+
+	renewals, err := uc.ListSubscriptionRenewals(ctx, sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(renewals) != 2 || renewals[0].StaffID == nil || !renewals[1].Regrandfathered ||
+		renewals[1].PreviousExpiresAt.Sub(lapsed).Abs() > time.Millisecond {
+		t.Errorf("renewals = %+v, want the staff's then the regrandfathering one", renewals)
+	}
+}
+
+func TestExpireSubscriptions(t *testing.T) {
+	ctx := context.Background()
+	srv := New()
+	f := seedCheckout(t, srv, 5, 1, 2)
+	var rec recordingNotifier
+	uc := usecase.New(srv, nil, &rec)
+	book := f.books[0]
+	lapsed, next := f.subs[0], f.subs[1]
+
+	h1, err := uc.PlaceHold(ctx, usecase.Hold{BookID: book.ID, SubscriptionID: lapsed.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2, err := uc.PlaceHold(ctx, usecase.Hold{BookID: book.ID, SubscriptionID: next.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = srv.UpdateSubscription(ctx, usecase.Subscription{ID: lapsed.ID, ExpiresAt: time.Now().Add(24 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, err := uc.GetSubscriptionByID(ctx, lapsed.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	email := sub.User.Email
+
+	for range 2 {
+		if _, err := uc.NotifyExpiringSubscriptions(ctx, 7); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := rec.to(email); len(got) != 1 {
+		t.Fatalf("notices before expiry = %v, want one", got)
+	}
+
+	_, err = srv.UpdateSubscription(ctx, usecase.Subscription{ID: lapsed.ID, ExpiresAt: time.Now().Add(-time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.ExpireSubscriptions(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.ExpireSubscriptions(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.to(email); len(got) != 2 {
+		t.Errorf("notices after expiry = %v, want one more", got)
+	}
+	sub, err = uc.GetSubscriptionByID(ctx, lapsed.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.ExpiredAt == nil {
+		t.Error("subscription not marked expired")
+	}
+
+	h1, err = uc.GetHoldByID(ctx, h1.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h1.Status != usecase.HoldStatusExpired {
+		t.Errorf("lapsed hold status = %s, want %s", h1.Status, usecase.HoldStatusExpired)
+	}
+	h2, err = uc.GetHoldByID(ctx, h2.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h2.Status != usecase.HoldStatusReady {
+		t.Errorf("next hold status = %s, want %s", h2.Status, usecase.HoldStatusReady)
+	}
+}
+
+func TestExpireSubscriptionsWithoutNotifier(t *testing.T) {
+	ctx := context.Background()
+	srv := New()
+	f := seedCheckout(t, srv, 5, 1, 1)
+	uc := usecase.New(srv, nil, nil)
+	lapsed := f.subs[0]
+
+	_, err := srv.UpdateSubscription(ctx, usecase.Subscription{ID: lapsed.ID, ExpiresAt: time.Now().Add(-time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.ExpireSubscriptions(ctx); err != nil {
+		t.Fatal(err)
+	}
+	sub, err := uc.GetSubscriptionByID(ctx, lapsed.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.ExpiredAt == nil {
+		t.Error("subscription not marked expired without a notifier")
+	}
+}
+
+func TestExpireSubscriptionsRetriesNotices(t *testing.T) {
+	ctx := context.Background()
+	srv := New()
+	f := seedCheckout(t, srv, 5, 1, 2)
+	var rec recordingNotifier
+	uc := usecase.New(srv, nil, &rec)
+
+	var emails []string
+	for _, sub := range f.subs {
+		_, err := srv.UpdateSubscription(ctx, usecase.Subscription{ID: sub.ID, ExpiresAt: time.Now().Add(-time.Hour)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := uc.GetSubscriptionByID(ctx, sub.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		emails = append(emails, s.User.Email)
+	}
+	rec.reject = emails[0]
+
+	expired, err := uc.ExpireSubscriptions(ctx)
+	if err == nil || !strings.Contains(err.Error(), emails[0]) {
+		t.Errorf("err = %v, want the failure for %s", err, emails[0])
+	}
+	if expired != 2 || len(rec.to(emails[1])) != 1 {
+		t.Errorf("expired %d, told the other member %v, want 2 and one", expired, rec.to(emails[1]))
+	}
+	sub, err := uc.GetSubscriptionByID(ctx, f.subs[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.ExpiredAt == nil {
+		t.Error("subscription not marked expired when its notice failed")
+	}
+
+	// the failed notice goes out on the next run, and only once
+	rec.reject = ""
+	for range 2 {
+		if expired, err := uc.ExpireSubscriptions(ctx); err != nil || expired != 0 {
+			t.Fatalf("retry expired %d, err %v, want none", expired, err)
+		}
+	}
+	if got := rec.to(emails[0]); len(got) != 1 {
+		t.Errorf("notices after retry = %v, want one", got)
+	}
+	if got := rec.to(emails[1]); len(got) != 1 {
+		t.Errorf("notices to the other member = %v, want still one", got)
+	}
+}
+
+func TestNotifyExpiringSubscriptionsSkipsFailures(t *testing.T) {
+	ctx := context.Background()
+	srv := New()
+	f := seedCheckout(t, srv, 5, 1, 2)
+	var rec recordingNotifier
+	uc := usecase.New(srv, nil, &rec)
+
+	var emails []string
+	for i, sub := range f.subs {
+		// the rejected member's subscription comes first
+		exp := time.Now().Add(time.Duration(i+1) * time.Hour)
+		if _, err := srv.UpdateSubscription(ctx, usecase.Subscription{ID: sub.ID, ExpiresAt: exp}); err != nil {
+			t.Fatal(err)
+		}
+		s, err := uc.GetSubscriptionByID(ctx, sub.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		emails = append(emails, s.User.Email)
+	}
+	rec.reject = emails[0]
+
+	sent, err := uc.NotifyExpiringSubscriptions(ctx, 7)
+	if err == nil || !strings.Contains(err.Error(), emails[0]) {
+		t.Errorf("err = %v, want the failure for %s", err, emails[0])
+	}
+	if sent != 1 || len(rec.to(emails[1])) != 1 {
+		t.Errorf("sent %d, to the other member %v, want one", sent, rec.to(emails[1]))
+	}
+
+	rec.reject = ""
+	if sent, err := uc.NotifyExpiringSubscriptions(ctx, 7); err != nil || sent != 1 || len(rec.to(emails[0])) != 1 {
+		t.Errorf("retry sent %d, err %v, to the member %v, want one", sent, err, rec.to(emails[0]))
+	}
+}
+
+func TestSuspendSubscription(t *testing.T) {
+	ctx := context.Background()
+	srv := New()
+	f := seedCheckout(t, srv, 5, 2, 1)
+	uc := usecase.New(srv, nil, nil)
+	sub := f.subs[0]
+
+	_, err := uc.SuspendSubscription(ctx, usecase.SuspendSubscriptionOption{ID: sub.ID, StaffID: &f.staff.ID, Reason: "damaged books"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = uc.CreateBorrowing(ctx, usecase.Borrowing{BookID: f.books[0].ID, SubscriptionID: sub.ID, StaffID: f.staff.ID})
+	if !errors.Is(err, usecase.ErrSubscriptionSuspended) {
+		t.Fatalf("borrowing while suspended: err = %v, want ErrSubscriptionSuspended", err)
+	}
+	_, err = uc.SuspendSubscription(ctx, usecase.SuspendSubscriptionOption{ID: sub.ID, Reason: "again"})
+	if !errors.Is(err, usecase.ErrSubscriptionSuspended) {
+		t.Fatalf("suspending twice: err = %v, want ErrSubscriptionSuspended", err)
+	}
+
+	reinstated, err := uc.ReinstateSubscription(ctx, usecase.ReinstateSubscriptionOption{ID: sub.ID, StaffID: &f.staff.ID, ExtendExpiry: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reinstated.SuspendedAt != nil || !reinstated.ExpiresAt.After(sub.ExpiresAt) {
+		t.Errorf("reinstated = %+v, want not suspended and expiring after %s", reinstated, sub.ExpiresAt)
+	}
+	b, err := uc.CreateBorrowing(ctx, usecase.Borrowing{BookID: f.books[0].ID, SubscriptionID: sub.ID, StaffID: f.staff.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a loan falling overdue after the reinstatement crosses the library threshold
+	one := 1
+	if _, err := uc.UpdateSetting(ctx, usecase.Setting{LibraryID: f.staff.LibraryID, AutoSuspendOverdue: &one}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.UpdateBorrowing(ctx, usecase.Borrowing{ID: b.ID, DueAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.SuspendDelinquentSubscriptions(ctx); err != nil {
+		t.Fatal(err)
+	}
+	_, err = uc.CreateBorrowing(ctx, usecase.Borrowing{BookID: f.books[1].ID, SubscriptionID: sub.ID, StaffID: f.staff.ID})
+	if !errors.Is(err, usecase.ErrSubscriptionSuspended) {
+		t.Fatalf("borrowing over the threshold: err = %v, want ErrSubscriptionSuspended", err)
+	}
+
+	suspensions, err := uc.ListSubscriptionSuspensions(ctx, sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(suspensions) != 2 || suspensions[0].ReinstatedAt == nil || suspensions[0].ExpiresAt == nil ||
+		suspensions[1].StaffID != nil || suspensions[1].ReinstatedAt != nil {
+		t.Errorf("suspensions = %+v, want the reinstated one then an automatic one in force", suspensions)
+	}
+}
+
+func TestReinstatementSurvivesSuspensionRun(t *testing.T) {
+	ctx := context.Background()
+	srv := New()
+	f := seedCheckout(t, srv, 5, 2, 1)
+	uc := usecase.New(srv, nil, nil)
+	sub := f.subs[0]
+
+	one := 1
+	if _, err := uc.UpdateSetting(ctx, usecase.Setting{LibraryID: f.staff.LibraryID, AutoSuspendOverdue: &one}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := uc.CreateBorrowing(ctx, usecase.Borrowing{BookID: f.books[0].ID, SubscriptionID: sub.ID, StaffID: f.staff.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.UpdateBorrowing(ctx, usecase.Borrowing{ID: b.ID, DueAt: time.Now().Add(-time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := uc.SuspendDelinquentSubscriptions(ctx); err != nil || n != 1 {
+		t.Fatalf("suspended %d, err %v, want 1", n, err)
+	}
+
+	// staff make an exception for the overdue loan
+	if _, err := uc.ReinstateSubscription(ctx, usecase.ReinstateSubscriptionOption{ID: sub.ID, StaffID: &f.staff.ID}); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if n, err := uc.SuspendDelinquentSubscriptions(ctx); err != nil || n != 0 {
+			t.Fatalf("suspended %d after the reinstatement, err %v, want 0", n, err)
+		}
+	}
+	s, err := uc.GetSubscriptionByID(ctx, sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.SuspendedAt != nil {
+		t.Fatal("reinstatement undone by the next run")
+	}
+
+	// another loan falling overdue is not excused
+	b, err = uc.CreateBorrowing(ctx, usecase.Borrowing{BookID: f.books[1].ID, SubscriptionID: sub.ID, StaffID: f.staff.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.UpdateBorrowing(ctx, usecase.Borrowing{ID: b.ID, DueAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := uc.SuspendDelinquentSubscriptions(ctx); err != nil || n != 1 {
+		t.Fatalf("suspended %d for a new overdue loan, err %v, want 1", n, err)
+	}
+}
+
+func TestReinstatementExcusesOnlyPastFines(t *testing.T) {
+	ctx := context.Background()
+	srv := New()
+	f := seedCheckout(t, srv, 5, 2, 1)
+	uc := usecase.New(srv, nil, nil)
+	sub := f.subs[0]
+	libID := f.staff.LibraryID
+
+	limit := 100
+	if _, err := uc.UpdateSetting(ctx, usecase.Setting{LibraryID: libID, AutoSuspendFine: &limit}); err != nil {
+		t.Fatal(err)
+	}
+	charge := func(b usecase.Borrowing, amount int) {
+		t.Helper()
+		if _, err := srv.UpsertFineCharge(ctx, usecase.FineEntry{Amount: amount, UserID: sub.UserID, LibraryID: libID, BorrowingID: &b.ID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	suspend := func(want int) {
+		t.Helper()
+		if n, err := uc.SuspendDelinquentSubscriptions(ctx); err != nil || n != want {
+			t.Fatalf("suspended %d, err %v, want %d", n, err, want)
+		}
+	}
+
+	old, err := uc.CreateBorrowing(ctx, usecase.Borrowing{BookID: f.books[0].ID, SubscriptionID: sub.ID, StaffID: f.staff.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.UpdateBorrowing(ctx, usecase.Borrowing{ID: old.ID, DueAt: time.Now().Add(-time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	charge(old, 100)
+	suspend(1)
+	if _, err := uc.ReinstateSubscription(ctx, usecase.ReinstateSubscriptionOption{ID: sub.ID, StaffID: &f.staff.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a new fine below the threshold, on top of the excused one
+	b, err := uc.CreateBorrowing(ctx, usecase.Borrowing{BookID: f.books[1].ID, SubscriptionID: sub.ID, StaffID: f.staff.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.UpdateBorrowing(ctx, usecase.Borrowing{ID: b.ID, DueAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	charge(b, 50)
+	suspend(0)
+
+	// paying off the excused fine does not excuse the next ones
+	if _, err := uc.PayFine(ctx, usecase.FineEntry{Amount: 100, UserID: sub.UserID, LibraryID: libID, StaffID: &f.staff.ID}); err != nil {
+		t.Fatal(err)
+	}
+	charge(b, 150)
+	suspend(1)
+}
+
+func TestDeleteSubscription(t *testing.T) {
+	ctx := context.Background()
+	srv := New()
+	f := seedCheckout(t, srv, 5, 2, 2)
+	uc := usecase.New(srv, nil, nil)
+	sub, next := f.subs[0], f.subs[1]
+
+	b, err := uc.CreateBorrowing(ctx, usecase.Borrowing{BookID: f.books[0].ID, SubscriptionID: sub.ID, StaffID: f.staff.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ready, err := uc.PlaceHold(ctx, usecase.Hold{BookID: f.books[1].ID, SubscriptionID: sub.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waiting, err := uc.PlaceHold(ctx, usecase.Hold{BookID: f.books[1].ID, SubscriptionID: next.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := uc.DeleteSubscription(ctx, sub.ID); !errors.Is(err, usecase.ErrSubscriptionOnLoan) {
+		t.Fatalf("delete with a loan: err = %v, want ErrSubscriptionOnLoan", err)
+	}
+	if _, err := uc.ReturnBorrowing(ctx, b.ID, f.staff.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := uc.DeleteSubscription(ctx, sub.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []struct {
+		hold   usecase.Hold
+		status string
+	}{
+		{ready, usecase.HoldStatusCancelled},
+		{waiting, usecase.HoldStatusReady},
+	} {
+		h, err := uc.GetHoldByID(ctx, want.hold.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h.Status != want.status {
+			t.Errorf("hold %s: status = %s, want %s", h.ID, h.Status, want.status)
+		}
+	}
+}
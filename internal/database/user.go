@@ -36,14 +36,32 @@ func (s *service) ListUsers(ctx context.Context, opt usecase.ListUsersOption) ([
 
 	db := s.db.Model([]User{}).WithContext(ctx)
 
+	if opt.IncludeDeleted {
+		db = db.Unscoped()
+	}
+
 	if opt.Name != "" {
 		db = db.Where("name ILIKE ?", "%"+opt.Name+"%")
 	}
 
+	if opt.Email != "" {
+		db = db.Where("LOWER(email) = LOWER(?)", opt.Email)
+	}
+
 	if opt.IDs != nil {
 		db = db.Where("id IN ?", opt.IDs)
 	}
 
+	if opt.LibraryID != "" {
+		db = db.Where(`(id IN (
+			SELECT s.user_id FROM subscriptions s
+			JOIN memberships m ON m.id = s.membership_id
+			WHERE m.library_id = ? AND s.deleted_at IS NULL
+		) OR id IN (
+			SELECT user_id FROM staffs WHERE library_id = ? AND deleted_at IS NULL
+		))`, opt.LibraryID, opt.LibraryID)
+	}
+
 	var (
 		orderIn = "DESC"
 		orderBy = "created_at"
@@ -139,12 +157,16 @@ func (s *service) UpdateUser(ctx context.Context, user usecase.User) (usecase.Us
 }
 
 func (s *service) DeleteUser(ctx context.Context, id string) error {
-	err := s.db.WithContext(ctx).Where("id = ?", id).Delete(&User{}).Error
-	if err != nil {
-		return err
-	}
+	return s.softDelete(ctx, &User{}, id)
+}
+
+func (s *service) RestoreUser(ctx context.Context, id uuid.UUID) error {
+	return s.restore(ctx, &User{}, id)
+}
 
-	return nil
+// PurgeUser deletes the user for good; their sign-in records go with it.
+func (s *service) PurgeUser(ctx context.Context, id uuid.UUID) error {
+	return s.purge(ctx, &User{}, id)
 }
 
 // Convert core model to Usecase
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"librarease/internal/usecase"
+
+	"github.com/google/uuid"
+)
+
+func TestCreateUserEmailUnique(t *testing.T) {
+	ctx := context.Background()
+	srv := New()
+	email := uuid.NewString() + "@example.com"
+
+	if _, err := srv.CreateUser(ctx, usecase.User{Name: "first", Email: email}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := srv.CreateUser(ctx, usecase.User{Name: "second", Email: strings.ToUpper(email)})
+	if !errors.Is(err, usecase.ErrAlreadyExists) {
+		t.Errorf("same email, other case: err = %v, want ErrAlreadyExists", err)
+	}
+	// users created by staff may share an empty email
+	for range 2 {
+		if _, err := srv.CreateUser(ctx, usecase.User{Name: "walk-in"}); err != nil {
+			t.Errorf("user without email: %v", err)
+		}
+	}
+}
+
+func TestListUsersOfLibrary(t *testing.T) {
+	ctx := context.Background()
+	srv := New()
+	f := seedCheckout(t, srv, 5, 0, 1)
+	seedCheckout(t, srv, 5, 0, 1) // another library
+
+	users, n, err := srv.ListUsers(ctx, usecase.ListUsersOption{Limit: 10, LibraryID: f.staff.LibraryID.String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[uuid.UUID]bool)
+	for _, u := range users {
+		got[u.ID] = true
+	}
+	if n != 2 || !got[f.staff.UserID] || !got[f.subs[0].UserID] {
+		t.Errorf("users = %v, want the staff and the subscriber of the library", users)
+	}
+}
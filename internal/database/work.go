@@ -0,0 +1,280 @@
+package database
+
+import (
+	"context"
+	"librarease/internal/usecase"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Work struct {
+	ID        uuid.UUID       `gorm:"column:id;primaryKey;type:uuid;default:uuid_generate_v4()"`
+	Title     string          `gorm:"column:title;type:varchar(255);not null"`
+	Author    string          `gorm:"column:author;type:varchar(255)"`
+	Year      int             `gorm:"column:year;type:int"`
+	ISBN13    *string         `gorm:"column:isbn13;type:varchar(13);uniqueIndex:idx_works_library_id_isbn13,priority:2"`
+	ISBN10    *string         `gorm:"column:isbn10;type:varchar(10)"`
+	LibraryID uuid.UUID       `gorm:"column:library_id;type:uuid;not null;index;uniqueIndex:idx_works_library_id_isbn13,priority:1"`
+	CreatedAt time.Time       `gorm:"column:created_at"`
+	UpdatedAt time.Time       `gorm:"column:updated_at"`
+	DeletedAt *gorm.DeletedAt `gorm:"column:deleted_at"`
+	Library   *Library        `gorm:"foreignKey:LibraryID"`
+
+	CallNumber       string    `gorm:"column:call_number;type:varchar(64)"`
+	CallNumberScheme string    `gorm:"column:call_number_scheme;type:varchar(8)"`
+	Subjects         []Subject `gorm:"many2many:work_subjects"`
+
+	TotalCopies     int `gorm:"->;-:migration;column:total_copies"`
+	AvailableCopies int `gorm:"->;-:migration;column:available_copies"`
+}
+
+func (Work) TableName() string {
+	return "works"
+}
+
+// withCopyCounts selects the works along with the number of their copies
+// and of those available, as availableCond defines them.
+func withCopyCounts(db *gorm.DB) *gorm.DB {
+	return db.Select(`works.*,
+		(SELECT count(*) FROM books
+			WHERE books.work_id = works.id AND books.deleted_at IS NULL) AS total_copies,
+		(SELECT count(*) FROM books
+			WHERE books.work_id = works.id AND books.deleted_at IS NULL
+			AND `+availableCond+`) AS available_copies`, usecase.BookConditionLost, usecase.HoldStatusReady)
+}
+
+func (s *service) ListWorks(ctx context.Context, opt usecase.ListWorksOption) ([]usecase.Work, int, error) {
+	var (
+		works  []Work
+		uworks []usecase.Work
+		count  int64
+	)
+
+	db := s.db.Model([]Work{}).WithContext(ctx)
+
+	if opt.IncludeDeleted {
+		db = db.Unscoped()
+	}
+
+	if opt.LibraryIDs != nil {
+		db = db.Where("works.library_id IN ?", opt.LibraryIDs)
+	}
+	if opt.Title != "" {
+		db = db.Where("works.title ILIKE ?", "%"+opt.Title+"%")
+	}
+	if opt.IDs != nil {
+		db = db.Where("works.id IN ?", opt.IDs)
+	}
+	if opt.ISBN13s != nil {
+		db = db.Where("works.isbn13 IN ?", opt.ISBN13s)
+	}
+	if opt.Query != "" {
+		db = searchWorks(db, "works", opt.Query)
+	}
+	if opt.SubjectIDs != nil {
+		db = db.Where("works.id"+subjectsCond, opt.SubjectIDs, len(opt.SubjectIDs))
+	}
+
+	var (
+		orderIn = "DESC"
+		orderBy = "created_at"
+	)
+	if opt.SortBy != "" {
+		orderBy = opt.SortBy
+	}
+	if opt.SortIn != "" {
+		orderIn = opt.SortIn
+	}
+
+	err := db.Count(&count).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if opt.Query != "" && opt.SortBy == "" {
+		db = db.Order(searchRank("works", opt.Query))
+	}
+	err = withCopyCounts(db).
+		Preload("Library").
+		Preload("Subjects").
+		Limit(opt.Limit).
+		Offset(opt.Skip).
+		Order("works." + orderBy + " " + orderIn).
+		Find(&works).
+		Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, w := range works {
+		uw := w.ConvertToUsecase()
+		if w.Library != nil {
+			lib := w.Library.ConvertToUsecase()
+			uw.Library = &lib
+		}
+		uworks = append(uworks, uw)
+	}
+
+	return uworks, int(count), nil
+}
+
+func (s *service) GetWorkByID(ctx context.Context, id uuid.UUID) (usecase.Work, error) {
+	var w Work
+
+	err := withCopyCounts(s.db.WithContext(ctx).Model(&Work{})).
+		Preload("Library").
+		Preload("Subjects").
+		Where("works.id = ?", id).
+		First(&w).
+		Error
+	if err != nil {
+		return usecase.Work{}, err
+	}
+
+	work := w.ConvertToUsecase()
+	if w.Library != nil {
+		lib := w.Library.ConvertToUsecase()
+		work.Library = &lib
+	}
+
+	return work, nil
+}
+
+func (s *service) CreateWork(ctx context.Context, work usecase.Work) (usecase.Work, error) {
+	w := Work{
+		Title:     work.Title,
+		Author:    work.Author,
+		Year:      work.Year,
+		ISBN13:    nullString(work.ISBN13),
+		ISBN10:    nullString(work.ISBN10),
+		LibraryID: work.LibraryID,
+
+		CallNumber:       work.CallNumber,
+		CallNumberScheme: work.CallNumberScheme,
+	}
+
+	err := s.db.WithContext(ctx).Create(&w).Error
+	if err != nil {
+		return usecase.Work{}, err
+	}
+	return w.ConvertToUsecase(), nil
+}
+
+// CreateWorks inserts the works in batches and returns them with their
+// ids, in the same order.
+func (s *service) CreateWorks(ctx context.Context, works []usecase.Work) ([]usecase.Work, error) {
+	if len(works) == 0 {
+		return nil, nil
+	}
+
+	ws := make([]Work, 0, len(works))
+	for _, work := range works {
+		ws = append(ws, Work{
+			Title:     work.Title,
+			Author:    work.Author,
+			Year:      work.Year,
+			ISBN13:    nullString(work.ISBN13),
+			ISBN10:    nullString(work.ISBN10),
+			LibraryID: work.LibraryID,
+
+			CallNumber:       work.CallNumber,
+			CallNumberScheme: work.CallNumberScheme,
+		})
+	}
+
+	err := s.db.WithContext(ctx).CreateInBatches(&ws, batchSize).Error
+	if err != nil {
+		return nil, err
+	}
+
+	uworks := make([]usecase.Work, 0, len(ws))
+	for _, w := range ws {
+		uworks = append(uworks, w.ConvertToUsecase())
+	}
+	return uworks, nil
+}
+
+func (s *service) UpdateWork(ctx context.Context, work usecase.Work) (usecase.Work, error) {
+	w := Work{
+		ID:     work.ID,
+		Title:  work.Title,
+		Author: work.Author,
+		Year:   work.Year,
+		ISBN13: nullString(work.ISBN13),
+
+		CallNumber:       work.CallNumber,
+		CallNumberScheme: work.CallNumberScheme,
+	}
+
+	db := s.db.WithContext(ctx)
+	err := db.Updates(&w).Error
+	if err != nil {
+		return usecase.Work{}, err
+	}
+	if w.ISBN13 != nil {
+		// an ISBN-13 in the 979 range has no ISBN-10, so clear the old one
+		err = db.Model(&Work{ID: work.ID}).Update("isbn10", nullString(work.ISBN10)).Error
+		if err != nil {
+			return usecase.Work{}, err
+		}
+	}
+	return s.GetWorkByID(ctx, work.ID)
+}
+
+func (s *service) DeleteWork(ctx context.Context, id uuid.UUID) error {
+	return s.softDelete(ctx, &Work{}, id)
+}
+
+func (s *service) RestoreWork(ctx context.Context, id uuid.UUID) error {
+	return s.restore(ctx, &Work{}, id)
+}
+
+// PurgeWork deletes the work for good; its classifications go with it.
+func (s *service) PurgeWork(ctx context.Context, id uuid.UUID) error {
+	return s.purge(ctx, &Work{}, id)
+}
+
+// Convert core model to Usecase
+func (w Work) ConvertToUsecase() usecase.Work {
+	var d *time.Time
+	if w.DeletedAt != nil {
+		d = &w.DeletedAt.Time
+	}
+	var subjects []usecase.Subject
+	for _, sub := range w.Subjects {
+		subjects = append(subjects, sub.ConvertToUsecase())
+	}
+	return usecase.Work{
+		ID:               w.ID,
+		Title:            w.Title,
+		Author:           w.Author,
+		Year:             w.Year,
+		ISBN13:           derefString(w.ISBN13),
+		ISBN10:           derefString(w.ISBN10),
+		CallNumber:       w.CallNumber,
+		CallNumberScheme: w.CallNumberScheme,
+		LibraryID:        w.LibraryID,
+		Subjects:         subjects,
+		TotalCopies:      w.TotalCopies,
+		AvailableCopies:  w.AvailableCopies,
+		CreatedAt:        w.CreatedAt,
+		UpdatedAt:        w.UpdatedAt,
+		DeletedAt:        d,
+	}
+}
+
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,42 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"librarease/internal/usecase"
+)
+
+func TestWorkCopyCounts(t *testing.T) {
+	ctx := context.Background()
+	srv := New()
+	f := seedCheckout(t, srv, 5, 4, 1)
+	uc := usecase.New(srv, nil, nil)
+
+	if _, err := uc.CreateBorrowing(ctx, usecase.Borrowing{BookID: f.books[0].ID, SubscriptionID: f.subs[0].ID, StaffID: f.staff.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.UpdateBook(ctx, usecase.Book{ID: f.books[1].ID, Condition: usecase.BookConditionLost}); err != nil {
+		t.Fatal(err)
+	}
+	// a hold on an available copy puts it aside
+	if _, err := uc.PlaceHold(ctx, usecase.Hold{BookID: f.books[2].ID, SubscriptionID: f.subs[0].ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := uc.GetWorkByID(ctx, f.books[0].WorkID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.TotalCopies != 4 || w.AvailableCopies != 1 {
+		t.Errorf("copies = %d/%d, want 1/4", w.AvailableCopies, w.TotalCopies)
+	}
+
+	_, available, err := uc.ListBooks(ctx, usecase.ListBooksOption{WorkID: w.ID, Available: true, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if available != w.AvailableCopies {
+		t.Errorf("available books = %d, work counts %d", available, w.AvailableCopies)
+	}
+}
@@ -1,10 +1,13 @@
 package firebase
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"librarease/internal/usecase"
 	"log"
+	"net/http"
 	"os"
 
 	fb "firebase.google.com/go/v4"
@@ -13,7 +16,14 @@ import (
 	"google.golang.org/api/option"
 )
 
-var path = os.Getenv("FIREBASE_SERVICE_ACCOUNT_KEY_PATH")
+const identityToolkitURL = "https://identitytoolkit.googleapis.com/v1/accounts:"
+
+var (
+	path = os.Getenv("FIREBASE_SERVICE_ACCOUNT_KEY_PATH")
+	// Web API key of the project, needed for the sign-in REST endpoints
+	// that the Admin SDK does not cover.
+	apiKey = os.Getenv("FIREBASE_WEB_API_KEY")
+)
 
 func New() *Firebase {
 	ctx := context.Background()
@@ -45,6 +55,9 @@ func (f *Firebase) CreateUser(ctx context.Context, ru usecase.RegisterUser) (str
 	u.Disabled(false)
 
 	user, err := f.client.CreateUser(ctx, u)
+	if auth.IsEmailAlreadyExists(err) {
+		return "", usecase.ErrEmailAlreadyExists
+	}
 	if err != nil {
 		return "", err
 	}
@@ -52,3 +65,88 @@ func (f *Firebase) CreateUser(ctx context.Context, ru usecase.RegisterUser) (str
 
 	return user.UID, nil
 }
+
+func (f *Firebase) DeleteUser(ctx context.Context, uid string) error {
+	return f.client.DeleteUser(ctx, uid)
+}
+
+func (f *Firebase) VerifyIDToken(ctx context.Context, idToken string) (string, error) {
+	token, err := f.client.VerifyIDToken(ctx, idToken)
+	if err != nil {
+		return "", err
+	}
+
+	return token.UID, nil
+}
+
+func (f *Firebase) SignIn(ctx context.Context, email, password string) (string, error) {
+	var res struct {
+		LocalID string `json:"localId"`
+	}
+	err := call(ctx, "signInWithPassword", map[string]interface{}{
+		"email":             email,
+		"password":          password,
+		"returnSecureToken": true,
+	}, &res)
+	if err != nil {
+		return "", err
+	}
+
+	return res.LocalID, nil
+}
+
+// IssueToken mints a custom token for the UID and exchanges it for an ID token.
+func (f *Firebase) IssueToken(ctx context.Context, uid string) (string, error) {
+	custom, err := f.client.CustomToken(ctx, uid)
+	if err != nil {
+		return "", err
+	}
+
+	var res struct {
+		IDToken string `json:"idToken"`
+	}
+	err = call(ctx, "signInWithCustomToken", map[string]interface{}{
+		"token":             custom,
+		"returnSecureToken": true,
+	}, &res)
+	if err != nil {
+		return "", err
+	}
+
+	return res.IDToken, nil
+}
+
+// call posts to an Identity Toolkit REST method and decodes the response.
+func call(ctx context.Context, method string, body interface{}, out interface{}) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, identityToolkitURL+method+"?key="+apiKey, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&e)
+		switch e.Error.Message {
+		case "EMAIL_NOT_FOUND", "INVALID_PASSWORD", "INVALID_LOGIN_CREDENTIALS", "USER_DISABLED":
+			return usecase.ErrInvalidCredentials
+		}
+		return fmt.Errorf("firebase %s: %s", method, e.Error.Message)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,109 @@
+package localauth
+
+import (
+	"context"
+	"errors"
+	"librarease/internal/usecase"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	issuer   = "librarease"
+	tokenTTL = time.Hour
+)
+
+// Store persists the password credentials of local users.
+type Store interface {
+	CreatePasswordCredential(context.Context, usecase.PasswordCredential) (usecase.PasswordCredential, error)
+	GetPasswordCredentialByEmail(context.Context, string) (usecase.PasswordCredential, error)
+	DeletePasswordCredential(context.Context, string) error
+}
+
+// New returns a LocalAuth that signs its ID tokens with secret.
+func New(store Store, secret string) (*LocalAuth, error) {
+	if secret == "" {
+		return nil, errors.New("localauth: a secret is required to sign tokens")
+	}
+	return &LocalAuth{store: store, secret: []byte(secret)}, nil
+}
+
+// LocalAuth is an identity provider that keeps bcrypt password hashes
+// in our own database and signs its own HS256 ID tokens.
+type LocalAuth struct {
+	store  Store
+	secret []byte
+}
+
+func (l *LocalAuth) CreateUser(ctx context.Context, ru usecase.RegisterUser) (string, error) {
+	if _, err := l.store.GetPasswordCredentialByEmail(ctx, normalizeEmail(ru.Email)); err == nil {
+		return "", usecase.ErrEmailAlreadyExists
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(ru.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	c, err := l.store.CreatePasswordCredential(ctx, usecase.PasswordCredential{
+		UID:          uuid.NewString(),
+		Email:        normalizeEmail(ru.Email),
+		PasswordHash: string(hash),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return c.UID, nil
+}
+
+func (l *LocalAuth) DeleteUser(ctx context.Context, uid string) error {
+	return l.store.DeletePasswordCredential(ctx, uid)
+}
+
+// SignIn checks the password of the user with the email and returns its UID.
+func (l *LocalAuth) SignIn(ctx context.Context, email, password string) (string, error) {
+	c, err := l.store.GetPasswordCredentialByEmail(ctx, normalizeEmail(email))
+	if err != nil {
+		return "", usecase.ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(c.PasswordHash), []byte(password)); err != nil {
+		return "", usecase.ErrInvalidCredentials
+	}
+
+	return c.UID, nil
+}
+
+// IssueToken signs an ID token for the UID.
+func (l *LocalAuth) IssueToken(_ context.Context, uid string) (string, error) {
+	now := time.Now()
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    issuer,
+		Subject:   uid,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(tokenTTL)),
+	}).SignedString(l.secret)
+}
+
+func (l *LocalAuth) VerifyIDToken(_ context.Context, idToken string) (string, error) {
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(idToken, &claims, func(*jwt.Token) (interface{}, error) {
+		return l.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return "", err
+	}
+	if !claims.VerifyIssuer(issuer, true) {
+		return "", errors.New("token has invalid issuer")
+	}
+
+	return claims.Subject, nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,143 @@
+package localauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	"librarease/internal/usecase"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// memStore keeps password credentials by email.
+type memStore map[string]usecase.PasswordCredential
+
+func (m memStore) CreatePasswordCredential(_ context.Context, c usecase.PasswordCredential) (usecase.PasswordCredential, error) {
+	m[c.Email] = c
+	return c, nil
+}
+
+func (m memStore) GetPasswordCredentialByEmail(_ context.Context, email string) (usecase.PasswordCredential, error) {
+	c, ok := m[email]
+	if !ok {
+		return usecase.PasswordCredential{}, usecase.ErrNotFound
+	}
+	return c, nil
+}
+
+func (m memStore) DeletePasswordCredential(_ context.Context, uid string) error {
+	for email, c := range m {
+		if c.UID == uid {
+			delete(m, email)
+		}
+	}
+	return nil
+}
+
+func newTestAuth(t *testing.T) *LocalAuth {
+	t.Helper()
+	l, err := New(memStore{}, "test secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestNewRequiresSecret(t *testing.T) {
+	if _, err := New(memStore{}, ""); err == nil {
+		t.Error("New without a secret: err = nil, want an error")
+	}
+}
+
+func TestTokenRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	l := newTestAuth(t)
+
+	token, err := l.IssueToken(ctx, "uid-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uid, err := l.VerifyIDToken(ctx, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uid != "uid-1" {
+		t.Errorf("uid = %q, want %q", uid, "uid-1")
+	}
+}
+
+func TestVerifyIDTokenRejects(t *testing.T) {
+	ctx := context.Background()
+	l := newTestAuth(t)
+	now := time.Now()
+	valid := jwt.RegisteredClaims{
+		Issuer:    issuer,
+		Subject:   "uid-1",
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sign := func(method jwt.SigningMethod, claims jwt.RegisteredClaims, key interface{}) string {
+		t.Helper()
+		s, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	foreign := valid
+	foreign.Issuer = "someone-else"
+	expired := valid
+	expired.IssuedAt = jwt.NewNumericDate(now.Add(-2 * time.Hour))
+	expired.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Hour))
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"wrong signature", sign(jwt.SigningMethodHS256, valid, []byte("other secret"))},
+		{"foreign issuer", sign(jwt.SigningMethodHS256, foreign, l.secret)},
+		{"expired", sign(jwt.SigningMethodHS256, expired, l.secret)},
+		{"alg none", sign(jwt.SigningMethodNone, valid, jwt.UnsafeAllowNoneSignatureType)},
+		{"alg RS256", sign(jwt.SigningMethodRS256, valid, rsaKey)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if uid, err := l.VerifyIDToken(ctx, tt.token); err == nil {
+				t.Errorf("verified as %q, want an error", uid)
+			}
+		})
+	}
+}
+
+func TestSignIn(t *testing.T) {
+	ctx := context.Background()
+	l := newTestAuth(t)
+
+	uid, err := l.CreateUser(ctx, usecase.RegisterUser{Email: "Reader@Example.com", Password: "correct horse"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := l.SignIn(ctx, " reader@example.com", "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != uid {
+		t.Errorf("uid = %q, want %q", got, uid)
+	}
+	if _, err := l.SignIn(ctx, "reader@example.com", "wrong horse"); !errors.Is(err, usecase.ErrInvalidCredentials) {
+		t.Errorf("wrong password: err = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := l.SignIn(ctx, "nobody@example.com", "correct horse"); !errors.Is(err, usecase.ErrInvalidCredentials) {
+		t.Errorf("unknown email: err = %v, want ErrInvalidCredentials", err)
+	}
+}
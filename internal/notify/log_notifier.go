@@ -0,0 +1,27 @@
+// Package notify delivers notifications to users.
+package notify
+
+import (
+	"context"
+	"librarease/internal/config"
+	"librarease/internal/usecase"
+	"log"
+	"os"
+)
+
+// New returns the notifier selected by NOTIFIER, logging by default.
+func New() usecase.Notifier {
+	if os.Getenv(config.ENV_KEY_NOTIFIER) == config.NOTIFIER_SMTP {
+		return NewSMTP()
+	}
+	return Log{}
+}
+
+// Log is a notifier that only logs the notifications, for development
+// and deployments that do not send email.
+type Log struct{}
+
+func (Log) Notify(_ context.Context, n usecase.Notification) error {
+	log.Printf("notify %s <%s>: %s", n.Name, n.Email, n.Subject)
+	return nil
+}
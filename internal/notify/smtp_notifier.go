@@ -0,0 +1,127 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"librarease/internal/usecase"
+	"log"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+
+	_ "github.com/joho/godotenv/autoload"
+)
+
+const smtpTimeout = 30 * time.Second
+
+var (
+	smtpHost     = os.Getenv("SMTP_HOST")
+	smtpPort     = os.Getenv("SMTP_PORT")
+	smtpUsername = os.Getenv("SMTP_USERNAME")
+	smtpPassword = os.Getenv("SMTP_PASSWORD")
+	smtpFrom     = os.Getenv("SMTP_FROM")
+)
+
+// SMTP is a notifier that emails the notifications through an SMTP
+// server, upgrading the connection with STARTTLS when it is offered.
+type SMTP struct {
+	addr string
+	host string
+	auth smtp.Auth
+	from mail.Address
+}
+
+func NewSMTP() *SMTP {
+	if smtpHost == "" || smtpFrom == "" {
+		log.Fatal("SMTP_HOST and SMTP_FROM are required for the smtp notifier")
+	}
+	port := smtpPort
+	if port == "" {
+		port = "587"
+	}
+	from, err := mail.ParseAddress(smtpFrom)
+	if err != nil {
+		log.Fatalf("SMTP_FROM: %v", err)
+	}
+
+	s := newSMTP(net.JoinHostPort(smtpHost, port), *from)
+	if smtpUsername != "" {
+		s.auth = smtp.PlainAuth("", smtpUsername, smtpPassword, smtpHost)
+	}
+	return s
+}
+
+func newSMTP(addr string, from mail.Address) *SMTP {
+	host, _, _ := net.SplitHostPort(addr)
+	return &SMTP{addr: addr, host: host, from: from}
+}
+
+func (s *SMTP) Notify(ctx context.Context, n usecase.Notification) error {
+	to := mail.Address{Name: n.Name, Address: n.Email}
+
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return err
+		}
+	}
+	if s.auth != nil {
+		if err := c.Auth(s.auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(s.from.Address); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to.Address); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(s.message(to, n)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// message formats the notification as a plain text email.
+func (s *SMTP) message(to mail.Address, n usecase.Notification) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", s.from.String())
+	fmt.Fprintf(&b, "To: %s\r\n", to.String())
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", n.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(n.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return b.Bytes()
+}
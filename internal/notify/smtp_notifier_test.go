@@ -0,0 +1,102 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"librarease/internal/usecase"
+)
+
+// fakeSMTP accepts a single mail on a local port and sends its envelope
+// and data on the returned channel.
+func fakeSMTP(t *testing.T) (string, <-chan []string) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	got := make(chan []string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+
+		var lines []string
+		reply("220 fake ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			switch cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); cmd {
+			case "EHLO", "HELO":
+				reply("250 fake")
+			case "MAIL", "RCPT":
+				lines = append(lines, line)
+				reply("250 ok")
+			case "DATA":
+				reply("354 go ahead")
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					l = strings.TrimRight(l, "\r\n")
+					if l == "." {
+						break
+					}
+					lines = append(lines, l)
+				}
+				reply("250 queued")
+			case "QUIT":
+				reply("221 bye")
+				got <- lines
+				return
+			default:
+				reply("502 not implemented")
+			}
+		}
+	}()
+	return l.Addr().String(), got
+}
+
+func TestSMTPNotify(t *testing.T) {
+	addr, got := fakeSMTP(t)
+	s := newSMTP(addr, mail.Address{Name: "Librarease", Address: "noreply@example.com"})
+
+	err := s.Notify(context.Background(), usecase.Notification{
+		Email:   "ada@example.com",
+		Name:    "Ada",
+		Subject: `"Dune" is due on Monday`,
+		Body:    "Hello Ada,\n\nPlease return it.\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := <-got
+	msg := strings.Join(lines, "\n")
+	for _, want := range []string{
+		"MAIL FROM:<noreply@example.com>",
+		"RCPT TO:<ada@example.com>",
+		`From: "Librarease" <noreply@example.com>`,
+		`To: "Ada" <ada@example.com>`,
+		`Subject: "Dune" is due on Monday`,
+		"Please return it.",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message lacks %q:\n%s", want, msg)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// Package scheduler runs background jobs on one replica of the API at a
+// time, the one holding a lock shared by all of them.
+package scheduler
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Lock is held by the leader until it unlocks it or loses it, as when
+// the connection of a database lock breaks.
+type Lock interface {
+	// Held reports whether the lock is still held.
+	Held(context.Context) bool
+	Unlock() error
+}
+
+// TryLockFunc takes the lock, returning a nil Lock when another replica
+// holds it.
+type TryLockFunc func(context.Context) (Lock, error)
+
+// Job is run every Every while the replica leads, first when it becomes
+// the leader.
+type Job struct {
+	Name  string
+	Every time.Duration
+	Run   func(context.Context) error
+}
+
+type Scheduler struct {
+	tryLock TryLockFunc
+	jobs    []Job
+	// Check is how often followers try to take the lock and the leader
+	// checks it still holds it.
+	Check time.Duration
+}
+
+func New(tryLock TryLockFunc, jobs ...Job) *Scheduler {
+	return &Scheduler{tryLock: tryLock, jobs: jobs, Check: time.Minute}
+}
+
+// Run runs the jobs whenever the replica leads, until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	for {
+		lock, err := s.tryLock(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("scheduler: take lock: %v", err)
+		}
+		if lock != nil {
+			s.lead(ctx, lock)
+			if err := lock.Unlock(); err != nil {
+				log.Printf("scheduler: release lock: %v", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.Check):
+		}
+	}
+}
+
+// lead runs the jobs until ctx is done or the lock is lost.
+func (s *Scheduler) lead(ctx context.Context, lock Lock) {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+	for _, j := range s.jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			loop(ctx, j)
+		}()
+	}
+
+	t := time.NewTicker(s.Check)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if !lock.Held(ctx) {
+				log.Print("scheduler: lost the lock, stopping jobs")
+				return
+			}
+		}
+	}
+}
+
+func loop(ctx context.Context, j Job) {
+	t := time.NewTicker(j.Every)
+	defer t.Stop()
+	for {
+		if err := j.Run(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("scheduler: %s: %v", j.Name, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeLocker is a lock shared by the schedulers of a test, which the
+// test can take away from the leader.
+type fakeLocker struct {
+	mu     sync.Mutex
+	holder *fakeLock
+}
+
+type fakeLock struct {
+	l *fakeLocker
+}
+
+func (l *fakeLocker) tryLock(context.Context) (Lock, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.holder != nil {
+		return nil, nil
+	}
+	l.holder = &fakeLock{l}
+	return l.holder, nil
+}
+
+// revoke drops the lock as a broken connection would.
+func (l *fakeLocker) revoke() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.holder = nil
+}
+
+func (f *fakeLock) Held(context.Context) bool {
+	f.l.mu.Lock()
+	defer f.l.mu.Unlock()
+	return f.l.holder == f
+}
+
+func (f *fakeLock) Unlock() error {
+	f.l.mu.Lock()
+	defer f.l.mu.Unlock()
+	if f.l.holder == f {
+		f.l.holder = nil
+	}
+	return nil
+}
+
+func TestSchedulerRunsJobsOnLeaderOnly(t *testing.T) {
+	var locker fakeLocker
+	var runs [2]atomic.Int32
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for i := range runs {
+		s := New(locker.tryLock, Job{
+			Name:  "count",
+			Every: 5 * time.Millisecond,
+			Run: func(context.Context) error {
+				runs[i].Add(1)
+				return nil
+			},
+		})
+		s.Check = 10 * time.Millisecond
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Run(ctx)
+		}()
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	wg.Wait()
+
+	a, b := runs[0].Load(), runs[1].Load()
+	if (a == 0) == (b == 0) {
+		t.Errorf("jobs ran %d and %d times, want them to run on one scheduler only", a, b)
+	}
+}
+
+func TestSchedulerStopsWhenLockIsLost(t *testing.T) {
+	var locker fakeLocker
+	var runs atomic.Int32
+
+	s := New(locker.tryLock, Job{
+		Name:  "count",
+		Every: 5 * time.Millisecond,
+		Run: func(context.Context) error {
+			runs.Add(1)
+			return nil
+		},
+	})
+	s.Check = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	time.Sleep(30 * time.Millisecond)
+	// another replica takes the lock as soon as it is free
+	locker.revoke()
+	other, _ := locker.tryLock(ctx)
+	if other == nil {
+		t.Fatal("lock not free after revoking it")
+	}
+	time.Sleep(30 * time.Millisecond)
+	before := runs.Load()
+	time.Sleep(30 * time.Millisecond)
+	if after := runs.Load(); after != before {
+		t.Errorf("jobs ran %d more times after losing the lock", after-before)
+	}
+
+	cancel()
+	<-done
+}
@@ -19,19 +19,24 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	ID    string `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
-	Token string `json:"token"`
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Email        string `json:"email"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
 }
 
 func (s *Server) RegisterUser(ctx echo.Context) error {
 	var req RegisterUserRequest
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(400, map[string]string{"error": err.Error()})
+		return err
 	}
 	if err := s.validator.Struct(req); err != nil {
-		return ctx.JSON(422, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	u, err := s.server.RegisterUser(ctx.Request().Context(), usecase.RegisterUser{
@@ -39,9 +44,8 @@ func (s *Server) RegisterUser(ctx echo.Context) error {
 		Email:    req.Email,
 		Password: req.Password,
 	})
-
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	return ctx.JSON(200, User{
@@ -51,3 +55,64 @@ func (s *Server) RegisterUser(ctx echo.Context) error {
 		UpdatedAt: u.UpdatedAt.Format(time.RFC3339),
 	})
 }
+
+func (s *Server) Login(ctx echo.Context) error {
+	var req LoginRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	sess, err := s.server.Login(ctx.Request().Context(), req.Email, req.Password)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(200, Res{Data: ConvertSessionFrom(sess)})
+}
+
+func (s *Server) RefreshToken(ctx echo.Context) error {
+	var req RefreshTokenRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	sess, err := s.server.RefreshSession(ctx.Request().Context(), req.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(200, Res{Data: ConvertSessionFrom(sess)})
+}
+
+func (s *Server) Logout(ctx echo.Context) error {
+	var req RefreshTokenRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	err := s.server.Logout(ctx.Request().Context(), req.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	return ctx.NoContent(204)
+}
+
+func ConvertSessionFrom(sess usecase.Session) LoginResponse {
+	return LoginResponse{
+		ID:           sess.User.ID.String(),
+		Name:         sess.User.Name,
+		Email:        sess.User.Email,
+		Token:        sess.AccessToken,
+		RefreshToken: sess.RefreshToken,
+	}
+}
@@ -1,7 +1,9 @@
 package server
 
 import (
+	"librarease/internal/catalog"
 	"librarease/internal/usecase"
+	"net/http"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,7 +15,14 @@ type Book struct {
 	Title     string   `json:"title"`
 	Author    string   `json:"author,omitempty"`
 	Year      int      `json:"year,omitempty"`
+	ISBN13    string   `json:"isbn_13,omitempty"`
+	ISBN10    string   `json:"isbn_10,omitempty"`
 	Code      string   `json:"code"`
+	Location  string   `json:"location,omitempty"`
+	Condition string   `json:"condition,omitempty"`
+	Status    string   `json:"status,omitempty"`
+	DueAt     *string  `json:"due_at,omitempty"`
+	WorkID    string   `json:"work_id,omitempty"`
 	LibraryID string   `json:"library_id,omitempty"`
 	CreatedAt string   `json:"created_at,omitempty"`
 	UpdatedAt string   `json:"updated_at,omitempty"`
@@ -22,21 +31,28 @@ type Book struct {
 }
 
 type ListBooksRequest struct {
-	LibraryID string `query:"library_id" validate:"omitempty,uuid"`
-	Skip      int    `query:"skip"`
-	Limit     int    `query:"limit" validate:"required,gte=1,lte=100"`
-	Title     string `query:"title" validate:"omitempty"`
-	SortBy    string `query:"sort_by" validate:"omitempty,oneof=created_at updated_at title author year code"`
-	SortIn    string `query:"sort_in" validate:"omitempty,oneof=asc desc"`
+	LibraryID      string   `query:"library_id" validate:"omitempty,uuid"`
+	WorkID         string   `query:"work_id" validate:"omitempty,uuid"`
+	ISBN           string   `query:"isbn" validate:"omitempty,isbn"`
+	Skip           int      `query:"skip"`
+	Limit          int      `query:"limit" validate:"required,gte=1,lte=100"`
+	Title          string   `query:"title" validate:"omitempty"`
+	Q              string   `query:"q" validate:"omitempty,max=200"`
+	Available      bool     `query:"available"`
+	SubjectIDs     []string `query:"subject_id" validate:"omitempty,dive,uuid"`
+	Facets         bool     `query:"facets"`
+	SortBy         string   `query:"sort_by" validate:"omitempty,oneof=created_at updated_at title author year code"`
+	SortIn         string   `query:"sort_in" validate:"omitempty,oneof=asc desc"`
+	IncludeDeleted bool     `query:"include_deleted"`
 }
 
 func (s *Server) ListBooks(ctx echo.Context) error {
 	var req ListBooksRequest
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(400, map[string]string{"error": err.Error()})
+		return err
 	}
 	if err := s.validator.Struct(req); err != nil {
-		return ctx.JSON(422, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	var libIDs uuid.UUIDs
@@ -44,37 +60,43 @@ func (s *Server) ListBooks(ctx echo.Context) error {
 		id, _ := uuid.Parse(req.LibraryID)
 		libIDs = append(libIDs, id)
 	}
+	workID, _ := uuid.Parse(req.WorkID)
+	isbn13, _, _ := usecase.ParseISBN(req.ISBN)
 
-	list, total, err := s.server.ListBooks(ctx.Request().Context(), usecase.ListBooksOption{
-		Skip:       req.Skip,
-		Limit:      req.Limit,
-		LibraryIDs: libIDs,
-		Title:      req.Title,
-		SortBy:     req.SortBy,
-		SortIn:     req.SortIn,
-	})
+	opt := usecase.ListBooksOption{
+		Skip:           req.Skip,
+		Limit:          req.Limit,
+		LibraryIDs:     libIDs,
+		WorkID:         workID,
+		ISBN13:         isbn13,
+		Title:          req.Title,
+		Query:          req.Q,
+		Available:      req.Available,
+		SubjectIDs:     parseUUIDs(req.SubjectIDs),
+		SortBy:         req.SortBy,
+		SortIn:         req.SortIn,
+		IncludeDeleted: req.IncludeDeleted,
+	}
+	list, total, err := s.server.ListBooks(ctx.Request().Context(), opt)
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 
-	books := make([]Book, 0, len(list))
-	for _, b := range list {
-		var d *string
-		if b.DeletedAt != nil {
-			ds := b.DeletedAt.String()
-			d = &ds
+	var facets []Facet
+	if req.Facets {
+		fs, err := s.server.BookFacets(ctx.Request().Context(), opt)
+		if err != nil {
+			return err
 		}
-		book := Book{
-			ID:        b.ID.String(),
-			Title:     b.Title,
-			Author:    b.Author,
-			Year:      b.Year,
-			Code:      b.Code,
-			LibraryID: b.LibraryID.String(),
-			CreatedAt: b.CreatedAt.Format(time.RFC3339),
-			UpdatedAt: b.UpdatedAt.Format(time.RFC3339),
-			DeletedAt: d,
+		facets = make([]Facet, 0, len(fs))
+		for _, f := range fs {
+			facets = append(facets, ConvertFacetFrom(f))
 		}
+	}
+
+	books := make([]Book, 0, len(list))
+	for _, b := range list {
+		book := ConvertBookFrom(b)
 		if b.Library != nil {
 			lib := Library{
 				ID:   b.Library.ID.String(),
@@ -90,9 +112,10 @@ func (s *Server) ListBooks(ctx echo.Context) error {
 	return ctx.JSON(200, Res{
 		Data: books,
 		Meta: &Meta{
-			Total: total,
-			Skip:  req.Skip,
-			Limit: req.Limit,
+			Total:  total,
+			Skip:   req.Skip,
+			Limit:  req.Limit,
+			Facets: facets,
 		},
 	})
 }
@@ -104,33 +127,18 @@ type GetBookByIDRequest struct {
 func (s *Server) GetBookByID(ctx echo.Context) error {
 	var req GetBookByIDRequest
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(400, map[string]string{"error": err.Error()})
+		return err
 	}
 	if err := s.validator.Struct(req); err != nil {
-		return ctx.JSON(422, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	id, _ := uuid.Parse(req.ID)
 	b, err := s.server.GetBookByID(ctx.Request().Context(), id)
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
-	}
-	var d *string
-	if b.DeletedAt != nil {
-		ds := b.DeletedAt.String()
-		d = &ds
-	}
-	book := Book{
-		ID:        b.ID.String(),
-		Title:     b.Title,
-		Author:    b.Author,
-		Year:      b.Year,
-		Code:      b.Code,
-		LibraryID: b.LibraryID.String(),
-		CreatedAt: b.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: b.UpdatedAt.Format(time.RFC3339),
-		DeletedAt: d,
+		return err
 	}
+	book := ConvertBookFrom(b)
 	if b.Library != nil {
 		lib := Library{
 			ID:        b.Library.ID.String(),
@@ -146,101 +154,276 @@ func (s *Server) GetBookByID(ctx echo.Context) error {
 	})
 }
 
+// CreateBookRequest adds a copy of the work, or of a new work made from
+// the title, author and year when no work_id is given.
 type CreateBookRequest struct {
-	Title     string `json:"title" validate:"required"`
-	Author    string `json:"author" validate:"required"`
-	Year      int    `json:"year" validate:"required,gte=1500"`
+	WorkID    string `json:"work_id" validate:"omitempty,uuid"`
+	Title     string `json:"title" validate:"required_without=WorkID"`
+	Author    string `json:"author"`
+	Year      int    `json:"year" validate:"omitempty,gte=1500"`
+	ISBN      string `json:"isbn" validate:"omitempty,isbn"`
 	Code      string `json:"code" validate:"required"`
+	Location  string `json:"location"`
+	Condition string `json:"condition" validate:"omitempty,oneof=NEW GOOD FAIR POOR DAMAGED LOST"`
 	LibraryID string `json:"library_id" validate:"required,uuid"`
 }
 
 func (s *Server) CreateBook(ctx echo.Context) error {
 	var req CreateBookRequest
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(400, map[string]string{"error": err.Error()})
+		return err
 	}
 	if err := s.validator.Struct(req); err != nil {
-		return ctx.JSON(422, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	libID, _ := uuid.Parse(req.LibraryID)
+	workID, _ := uuid.Parse(req.WorkID)
+	isbn13, isbn10, _ := usecase.ParseISBN(req.ISBN)
 	b, err := s.server.CreateBook(ctx.Request().Context(), usecase.Book{
 		Title:     req.Title,
 		Author:    req.Author,
 		Year:      req.Year,
+		ISBN13:    isbn13,
+		ISBN10:    isbn10,
 		Code:      req.Code,
+		Location:  req.Location,
+		Condition: req.Condition,
+		WorkID:    workID,
 		LibraryID: libID,
 	})
 
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 
-	var d *string
-	if b.DeletedAt != nil {
-		ds := b.DeletedAt.String()
-		d = &ds
-	}
-	return ctx.JSON(201, Res{Data: Book{
-		ID:        b.ID.String(),
-		Title:     b.Title,
-		Author:    b.Author,
-		Year:      b.Year,
-		Code:      b.Code,
-		LibraryID: b.LibraryID.String(),
-		CreatedAt: b.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: b.UpdatedAt.Format(time.RFC3339),
-		DeletedAt: d,
-	}})
+	return ctx.JSON(201, Res{Data: ConvertBookFrom(b)})
 }
 
+// UpdateBookRequest updates the copy. The title, author and year belong
+// to its work and are updated through PUT /works/:id; an isbn is set on
+// the work too.
 type UpdateBookRequest struct {
 	ID        string `param:"id" validate:"required,uuid"`
-	Title     string `json:"title"`
-	Author    string `json:"author"`
-	Year      int    `json:"year" validate:"gte=1500"`
+	WorkID    string `json:"work_id" validate:"omitempty,uuid"`
+	ISBN      string `json:"isbn" validate:"omitempty,isbn"`
 	Code      string `json:"code"`
-	LibraryID string `json:"library_id" validate:"omitempty,uuid"`
+	Location  string `json:"location"`
+	Condition string `json:"condition" validate:"omitempty,oneof=NEW GOOD FAIR POOR DAMAGED LOST"`
 }
 
 func (s *Server) UpdateBook(ctx echo.Context) error {
 	var req UpdateBookRequest
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(400, map[string]string{"error": err.Error()})
+		return err
 	}
 	if err := s.validator.Struct(req); err != nil {
-		return ctx.JSON(422, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	id, _ := uuid.Parse(req.ID)
-	libID, _ := uuid.Parse(req.LibraryID)
+	workID, _ := uuid.Parse(req.WorkID)
+	isbn13, isbn10, _ := usecase.ParseISBN(req.ISBN)
 	b, err := s.server.UpdateBook(ctx.Request().Context(), usecase.Book{
 		ID:        id,
-		Title:     req.Title,
-		Author:    req.Author,
-		Year:      req.Year,
+		WorkID:    workID,
+		ISBN13:    isbn13,
+		ISBN10:    isbn10,
 		Code:      req.Code,
-		LibraryID: libID,
+		Location:  req.Location,
+		Condition: req.Condition,
 	})
 
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 
+	return ctx.JSON(200, Res{Data: ConvertBookFrom(b)})
+}
+
+func ConvertBookFrom(b usecase.Book) Book {
 	var d *string
 	if b.DeletedAt != nil {
 		ds := b.DeletedAt.String()
 		d = &ds
 	}
-	return ctx.JSON(200, Res{Data: Book{
+	var due *string
+	if b.DueAt != nil {
+		ds := b.DueAt.Format(time.RFC3339)
+		due = &ds
+	}
+	return Book{
 		ID:        b.ID.String(),
 		Title:     b.Title,
 		Author:    b.Author,
 		Year:      b.Year,
+		ISBN13:    b.ISBN13,
+		ISBN10:    b.ISBN10,
 		Code:      b.Code,
+		Location:  b.Location,
+		Condition: b.Condition,
+		Status:    b.Status,
+		DueAt:     due,
+		WorkID:    b.WorkID.String(),
 		LibraryID: b.LibraryID.String(),
 		CreatedAt: b.CreatedAt.Format(time.RFC3339),
 		UpdatedAt: b.UpdatedAt.Format(time.RFC3339),
 		DeletedAt: d,
-	}})
+	}
+}
+
+type ImportBooksRequest struct {
+	LibraryID string `query:"library_id" validate:"required,uuid"`
+	Format    string `query:"format" validate:"omitempty,oneof=csv marc marcxml"`
+	DryRun    bool   `query:"dry_run"`
+}
+
+type ImportError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type ImportResult struct {
+	Rows         int           `json:"rows"`
+	WorksCreated int           `json:"works_created"`
+	BooksCreated int           `json:"books_created"`
+	DryRun       bool          `json:"dry_run"`
+	Errors       []ImportError `json:"errors"`
+}
+
+// ImportBooks imports the catalogue uploaded as the multipart "file". The
+// format is guessed from the file name unless given.
+func (s *Server) ImportBooks(ctx echo.Context) error {
+	var req ImportBooksRequest
+	if err := (&echo.DefaultBinder{}).BindQueryParams(ctx, &req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	fh, err := ctx.FormFile("file")
+	if err != nil {
+		return echo.NewHTTPError(400, "file is required")
+	}
+	format := req.Format
+	if format == "" {
+		format = catalog.FormatFromFilename(fh.Filename)
+	}
+	if format == "" {
+		return echo.NewHTTPError(400, "format is required for "+fh.Filename)
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	rows, err := catalog.Read(f, format)
+	if err != nil {
+		return err
+	}
+
+	libID, _ := uuid.Parse(req.LibraryID)
+	res, err := s.server.ImportBooks(ctx.Request().Context(), usecase.ImportBooksOption{
+		LibraryID: libID,
+		DryRun:    req.DryRun,
+	}, rows)
+	if err != nil {
+		return err
+	}
+
+	result := ImportResult{
+		Rows:         res.Rows,
+		WorksCreated: res.WorksCreated,
+		BooksCreated: res.BooksCreated,
+		DryRun:       res.DryRun,
+		Errors:       make([]ImportError, 0, len(res.Errors)),
+	}
+	for _, e := range res.Errors {
+		result.Errors = append(result.Errors, ImportError{Row: e.Row, Field: e.Field, Message: e.Message})
+	}
+
+	switch {
+	case len(result.Errors) > 0:
+		return ctx.JSON(422, Res{
+			Data:    result,
+			Error:   "IMPORT_INVALID",
+			Message: "some rows are invalid, nothing was imported",
+		})
+	case result.DryRun:
+		return ctx.JSON(200, Res{Data: result})
+	}
+	return ctx.JSON(201, Res{Data: result})
+}
+
+type ExportBooksRequest struct {
+	LibraryID  string   `query:"library_id" validate:"required,uuid"`
+	WorkID     string   `query:"work_id" validate:"omitempty,uuid"`
+	ISBN       string   `query:"isbn" validate:"omitempty,isbn"`
+	Title      string   `query:"title" validate:"omitempty"`
+	Q          string   `query:"q" validate:"omitempty,max=200"`
+	Available  bool     `query:"available"`
+	Codes      []string `query:"code" validate:"omitempty,dive,required"`
+	SubjectIDs []string `query:"subject_id" validate:"omitempty,dive,uuid"`
+	Format     string   `query:"format" validate:"required,oneof=csv marcxml dcjson"`
+}
+
+// ExportBooks streams the library's books matching the filters as a file
+// of the format.
+func (s *Server) ExportBooks(ctx echo.Context) error {
+	var req ExportBooksRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	libID, _ := uuid.Parse(req.LibraryID)
+	workID, _ := uuid.Parse(req.WorkID)
+	isbn13, _, _ := usecase.ParseISBN(req.ISBN)
+
+	contentType, ext := catalog.ContentType(req.Format)
+	header := ctx.Response().Header()
+	header.Set(echo.HeaderContentType, contentType)
+	header.Set(echo.HeaderContentDisposition, `attachment; filename="books`+ext+`"`)
+
+	// a large catalogue takes longer to send than the server's write timeout
+	_ = http.NewResponseController(ctx.Response()).SetWriteDeadline(time.Time{})
+
+	w, _ := catalog.NewWriter(ctx.Response(), req.Format)
+	err := s.server.ExportBooks(ctx.Request().Context(), usecase.ListBooksOption{
+		LibraryIDs: uuid.UUIDs{libID},
+		WorkID:     workID,
+		ISBN13:     isbn13,
+		Codes:      req.Codes,
+		Title:      req.Title,
+		Query:      req.Q,
+		SubjectIDs: parseUUIDs(req.SubjectIDs),
+		Available:  req.Available,
+	}, w.Write)
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil && !ctx.Response().Committed {
+		// let the error handler answer with JSON instead
+		header.Del(echo.HeaderContentType)
+		header.Del(echo.HeaderContentDisposition)
+	}
+	return err
+}
+
+func (s *Server) DeleteBook(ctx echo.Context) error {
+	return s.noContentByID(ctx, s.server.DeleteBook)
+}
+
+func (s *Server) RestoreBook(ctx echo.Context) error {
+	return s.noContentByID(ctx, s.server.RestoreBook)
+}
+
+func (s *Server) PurgeBook(ctx echo.Context) error {
+	return s.noContentByID(ctx, s.server.PurgeBook)
 }
@@ -9,16 +9,19 @@ import (
 )
 
 type Borrowing struct {
-	ID             string  `json:"id"`
-	BookID         string  `json:"book_id"`
-	SubscriptionID string  `json:"subscription_id"`
-	StaffID        string  `json:"staff_id"`
-	BorrowedAt     string  `json:"borrowed_at"`
-	DueAt          string  `json:"due_at"`
-	ReturnedAt     *string `json:"returned_at"`
-	CreatedAt      string  `json:"created_at"`
-	UpdatedAt      string  `json:"updated_at"`
-	DeletedAt      *string `json:"deleted_at,omitempty"`
+	ID              string  `json:"id"`
+	BookID          string  `json:"book_id"`
+	SubscriptionID  string  `json:"subscription_id"`
+	StaffID         string  `json:"staff_id"`
+	BorrowedAt      string  `json:"borrowed_at"`
+	DueAt           string  `json:"due_at"`
+	ReturnedAt      *string `json:"returned_at"`
+	ReturnedStaffID *string `json:"returned_staff_id"`
+	Fine            int     `json:"fine"`
+	RenewalCount    int     `json:"renewal_count"`
+	CreatedAt       string  `json:"created_at"`
+	UpdatedAt       string  `json:"updated_at"`
+	DeletedAt       *string `json:"deleted_at,omitempty"`
 
 	Book         *Book         `json:"book"`
 	Subscription *Subscription `json:"subscription"`
@@ -42,22 +45,23 @@ type ListBorrowingsOption struct {
 	ReturnedAt     *string `query:"returned_at" validate:"omitempty"`
 	IsActive       bool    `query:"is_active"`
 	IsExpired      bool    `query:"is_expired"`
+	IncludeDeleted bool    `query:"include_deleted"`
 }
 
 func (s *Server) ListBorrowings(ctx echo.Context) error {
 	var req ListBorrowingsOption
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(400, map[string]string{"error": err.Error()})
+		return err
 	}
 	if err := s.validator.Struct(req); err != nil {
-		return ctx.JSON(422, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	var borrowedAt time.Time
 	if req.BorrowedAt != "" {
 		t, err := time.Parse(time.RFC3339, req.BorrowedAt)
 		if err != nil {
-			return ctx.JSON(400, map[string]string{"error": err.Error()})
+			return echo.NewHTTPError(400, err.Error())
 		}
 		borrowedAt = t
 	}
@@ -66,7 +70,7 @@ func (s *Server) ListBorrowings(ctx echo.Context) error {
 	if req.DueAt != "" {
 		t, err := time.Parse(time.RFC3339, req.DueAt)
 		if err != nil {
-			return ctx.JSON(400, map[string]string{"error": err.Error()})
+			return echo.NewHTTPError(400, err.Error())
 		}
 		dueAt = t
 	}
@@ -75,7 +79,7 @@ func (s *Server) ListBorrowings(ctx echo.Context) error {
 	if req.ReturnedAt != nil {
 		t, err := time.Parse(time.RFC3339, *req.ReturnedAt)
 		if err != nil {
-			return ctx.JSON(400, map[string]string{"error": err.Error()})
+			return echo.NewHTTPError(400, err.Error())
 		}
 		returnedAt = &t
 	}
@@ -96,9 +100,10 @@ func (s *Server) ListBorrowings(ctx echo.Context) error {
 		IsExpired:      req.IsExpired,
 		SortBy:         req.SortBy,
 		SortIn:         req.SortIn,
+		IncludeDeleted: req.IncludeDeleted,
 	})
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	list := make([]Borrowing, 0, len(borrows))
@@ -114,17 +119,25 @@ func (s *Server) ListBorrowings(ctx echo.Context) error {
 			tmp := borrow.ReturnedAt.Format(time.RFC3339)
 			r = &tmp
 		}
+		var rs *string
+		if borrow.ReturnedStaffID != nil {
+			tmp := borrow.ReturnedStaffID.String()
+			rs = &tmp
+		}
 		m := Borrowing{
-			ID:             borrow.ID.String(),
-			BookID:         borrow.BookID.String(),
-			SubscriptionID: borrow.SubscriptionID.String(),
-			StaffID:        borrow.StaffID.String(),
-			BorrowedAt:     borrow.BorrowedAt.Format(time.RFC3339),
-			DueAt:          borrow.DueAt.Format(time.RFC3339),
-			ReturnedAt:     r,
-			CreatedAt:      borrow.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:      borrow.UpdatedAt.Format(time.RFC3339),
-			DeletedAt:      d,
+			ID:              borrow.ID.String(),
+			BookID:          borrow.BookID.String(),
+			SubscriptionID:  borrow.SubscriptionID.String(),
+			StaffID:         borrow.StaffID.String(),
+			BorrowedAt:      borrow.BorrowedAt.Format(time.RFC3339),
+			DueAt:           borrow.DueAt.Format(time.RFC3339),
+			ReturnedAt:      r,
+			ReturnedStaffID: rs,
+			Fine:            borrow.Fine,
+			RenewalCount:    borrow.RenewalCount,
+			CreatedAt:       borrow.CreatedAt.Format(time.RFC3339),
+			UpdatedAt:       borrow.UpdatedAt.Format(time.RFC3339),
+			DeletedAt:       d,
 		}
 
 		if borrow.Book != nil {
@@ -201,16 +214,16 @@ type GetBorrowingByIDRequest struct {
 func (s *Server) GetBorrowingByID(ctx echo.Context) error {
 	var req GetBorrowingByIDRequest
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(400, map[string]string{"error": err.Error()})
+		return err
 	}
 	if err := s.validator.Struct(req); err != nil {
-		return ctx.JSON(422, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	id, _ := uuid.Parse(req.ID)
 	borrow, err := s.server.GetBorrowingByID(ctx.Request().Context(), id)
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	var d *string
@@ -223,17 +236,25 @@ func (s *Server) GetBorrowingByID(ctx echo.Context) error {
 		tmp := borrow.ReturnedAt.Format(time.RFC3339)
 		r = &tmp
 	}
+	var rs *string
+	if borrow.ReturnedStaffID != nil {
+		tmp := borrow.ReturnedStaffID.String()
+		rs = &tmp
+	}
 	m := Borrowing{
-		ID:             borrow.ID.String(),
-		BookID:         borrow.BookID.String(),
-		SubscriptionID: borrow.SubscriptionID.String(),
-		StaffID:        borrow.StaffID.String(),
-		BorrowedAt:     borrow.BorrowedAt.Format(time.RFC3339),
-		DueAt:          borrow.DueAt.Format(time.RFC3339),
-		ReturnedAt:     r,
-		CreatedAt:      borrow.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:      borrow.UpdatedAt.Format(time.RFC3339),
-		DeletedAt:      d,
+		ID:              borrow.ID.String(),
+		BookID:          borrow.BookID.String(),
+		SubscriptionID:  borrow.SubscriptionID.String(),
+		StaffID:         borrow.StaffID.String(),
+		BorrowedAt:      borrow.BorrowedAt.Format(time.RFC3339),
+		DueAt:           borrow.DueAt.Format(time.RFC3339),
+		ReturnedAt:      r,
+		ReturnedStaffID: rs,
+		Fine:            borrow.Fine,
+		RenewalCount:    borrow.RenewalCount,
+		CreatedAt:       borrow.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:       borrow.UpdatedAt.Format(time.RFC3339),
+		DeletedAt:       d,
 	}
 
 	if borrow.Book != nil {
@@ -288,6 +309,7 @@ func (s *Server) GetBorrowingByID(ctx echo.Context) error {
 			UpdatedAt:       borrow.Subscription.UpdatedAt.Format(time.RFC3339),
 			ExpiresAt:       borrow.Subscription.ExpiresAt.Format(time.RFC3339),
 			FinePerDay:      borrow.Subscription.FinePerDay,
+			MaxRenewals:     borrow.Subscription.MaxRenewals,
 			LoanPeriod:      borrow.Subscription.LoanPeriod,
 			ActiveLoanLimit: borrow.Subscription.ActiveLoanLimit,
 		}
@@ -308,6 +330,7 @@ func (s *Server) GetBorrowingByID(ctx echo.Context) error {
 				ActiveLoanLimit: borrow.Subscription.Membership.ActiveLoanLimit,
 				LoanPeriod:      borrow.Subscription.Membership.LoanPeriod,
 				FinePerDay:      borrow.Subscription.Membership.FinePerDay,
+				MaxRenewals:     borrow.Subscription.Membership.MaxRenewals,
 				CreatedAt:       borrow.Subscription.Membership.CreatedAt.Format(time.RFC3339),
 				UpdatedAt:       borrow.Subscription.Membership.UpdatedAt.Format(time.RFC3339),
 			}
@@ -329,64 +352,48 @@ func (s *Server) GetBorrowingByID(ctx echo.Context) error {
 }
 
 type CreateBorrowingRequest struct {
-	BookID         string  `json:"book_id" validate:"required,uuid"`
-	SubscriptionID string  `json:"subscription_id" validate:"required,uuid"`
-	StaffID        string  `json:"staff_id" validate:"required,uuid"`
-	BorrowedAt     string  `json:"borrowed_at" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
-	DueAt          string  `json:"due_at" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
-	ReturnedAt     *string `json:"returned_at" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	BookID         string `json:"book_id" validate:"required,uuid"`
+	SubscriptionID string `json:"subscription_id" validate:"required,uuid"`
+	BorrowedAt     string `json:"borrowed_at" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
 }
 
 func (s *Server) CreateBorrowing(ctx echo.Context) error {
 	var req CreateBorrowingRequest
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(400, map[string]string{"error": err.Error()})
+		return err
 	}
 	if err := s.validator.Struct(req); err != nil {
-		return ctx.JSON(422, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	bookID, _ := uuid.Parse(req.BookID)
 	subscriptionID, _ := uuid.Parse(req.SubscriptionID)
-	staffID, _ := uuid.Parse(req.StaffID)
+	book, err := s.server.GetBookByID(ctx.Request().Context(), bookID)
+	if err != nil {
+		return err
+	}
+	staffID, err := s.callerStaffIn(ctx, book.LibraryID)
+	if err != nil {
+		return err
+	}
 
 	var borrowedAt time.Time
 	if req.BorrowedAt != "" {
 		t, err := time.Parse(time.RFC3339, req.BorrowedAt)
 		if err != nil {
-			return ctx.JSON(400, map[string]string{"error": err.Error()})
+			return echo.NewHTTPError(400, err.Error())
 		}
 		borrowedAt = t
 	}
 
-	var dueAt time.Time
-	if req.DueAt != "" {
-		t, err := time.Parse(time.RFC3339, req.DueAt)
-		if err != nil {
-			return ctx.JSON(400, map[string]string{"error": err.Error()})
-		}
-		dueAt = t
-	}
-
-	var returnedAt *time.Time
-	if req.ReturnedAt != nil {
-		t, err := time.Parse(time.RFC3339, *req.ReturnedAt)
-		if err != nil {
-			return ctx.JSON(400, map[string]string{"error": err.Error()})
-		}
-		returnedAt = &t
-	}
-
 	borrow, err := s.server.CreateBorrowing(ctx.Request().Context(), usecase.Borrowing{
 		BookID:         bookID,
 		SubscriptionID: subscriptionID,
 		StaffID:        staffID,
 		BorrowedAt:     borrowedAt,
-		DueAt:          dueAt,
-		ReturnedAt:     returnedAt,
 	})
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	var r *string
@@ -408,67 +415,36 @@ func (s *Server) CreateBorrowing(ctx echo.Context) error {
 }
 
 type UpdateBorrowingRequest struct {
-	ID             string  `param:"id" validate:"required,uuid"`
-	BookID         string  `json:"book_id" validate:"omitempty,uuid"`
-	SubscriptionID string  `json:"subscription_id" validate:"omitempty,uuid"`
-	StaffID        string  `json:"staff_id" validate:"omitempty,uuid"`
-	BorrowedAt     string  `json:"borrowed_at" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
-	DueAt          string  `json:"due_at" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
-	ReturnedAt     *string `json:"returned_at" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	ID         string `param:"id" validate:"required,uuid"`
+	BorrowedAt string `json:"borrowed_at" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
 }
 
 func (s *Server) UpdateBorrowing(ctx echo.Context) error {
 	var req UpdateBorrowingRequest
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(400, map[string]string{"error": err.Error()})
+		return err
 	}
 	if err := s.validator.Struct(req); err != nil {
-		return ctx.JSON(422, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	id, _ := uuid.Parse(req.ID)
-	bookID, _ := uuid.Parse(req.BookID)
-	subscriptionID, _ := uuid.Parse(req.SubscriptionID)
-	staffID, _ := uuid.Parse(req.StaffID)
 
 	var borrowedAt time.Time
 	if req.BorrowedAt != "" {
 		t, err := time.Parse(time.RFC3339, req.BorrowedAt)
 		if err != nil {
-			return ctx.JSON(400, map[string]string{"error": err.Error()})
+			return echo.NewHTTPError(400, err.Error())
 		}
 		borrowedAt = t
 	}
 
-	var dueAt time.Time
-	if req.DueAt != "" {
-		t, err := time.Parse(time.RFC3339, req.DueAt)
-		if err != nil {
-			return ctx.JSON(400, map[string]string{"error": err.Error()})
-		}
-		dueAt = t
-	}
-
-	var returnedAt *time.Time
-	if req.ReturnedAt != nil {
-		t, err := time.Parse(time.RFC3339, *req.ReturnedAt)
-		if err != nil {
-			return ctx.JSON(400, map[string]string{"error": err.Error()})
-		}
-		returnedAt = &t
-	}
-
 	borrow, err := s.server.UpdateBorrowing(ctx.Request().Context(), usecase.Borrowing{
-		ID:             id,
-		BookID:         bookID,
-		SubscriptionID: subscriptionID,
-		StaffID:        staffID,
-		BorrowedAt:     borrowedAt,
-		DueAt:          dueAt,
-		ReturnedAt:     returnedAt,
+		ID:         id,
+		BorrowedAt: borrowedAt,
 	})
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	var r *string
@@ -488,3 +464,143 @@ func (s *Server) UpdateBorrowing(ctx echo.Context) error {
 		UpdatedAt:      borrow.UpdatedAt.Format(time.RFC3339),
 	}})
 }
+
+type ReturnBorrowingRequest struct {
+	ID string `param:"id" validate:"required,uuid"`
+}
+
+// ReturnBorrowing records the caller as the staff receiving the book.
+func (s *Server) ReturnBorrowing(ctx echo.Context) error {
+	var req ReturnBorrowingRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	id, _ := uuid.Parse(req.ID)
+	staffID, err := s.callerStaffID(ctx, borrowingLibrary)
+	if err != nil {
+		return err
+	}
+
+	borrow, err := s.server.ReturnBorrowing(ctx.Request().Context(), id, staffID)
+	if err != nil {
+		return err
+	}
+
+	r := borrow.ReturnedAt.Format(time.RFC3339)
+	rs := borrow.ReturnedStaffID.String()
+	return ctx.JSON(200, Res{Data: Borrowing{
+		ID:              borrow.ID.String(),
+		BookID:          borrow.BookID.String(),
+		SubscriptionID:  borrow.SubscriptionID.String(),
+		StaffID:         borrow.StaffID.String(),
+		BorrowedAt:      borrow.BorrowedAt.Format(time.RFC3339),
+		DueAt:           borrow.DueAt.Format(time.RFC3339),
+		ReturnedAt:      &r,
+		ReturnedStaffID: &rs,
+		Fine:            borrow.Fine,
+		RenewalCount:    borrow.RenewalCount,
+		CreatedAt:       borrow.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:       borrow.UpdatedAt.Format(time.RFC3339),
+	}})
+}
+
+type RenewBorrowingRequest struct {
+	ID string `param:"id" validate:"required,uuid"`
+}
+
+func (s *Server) RenewBorrowing(ctx echo.Context) error {
+	var req RenewBorrowingRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	id, _ := uuid.Parse(req.ID)
+	libraryID, err := borrowingLibrary(s, ctx)
+	if err != nil {
+		return err
+	}
+	// members renewing their own loans are recorded without staff
+	staffID, err := s.callerStaffOrNil(ctx, libraryID)
+	if err != nil {
+		return err
+	}
+
+	borrow, err := s.server.RenewBorrowing(ctx.Request().Context(), id, staffID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(200, Res{Data: Borrowing{
+		ID:             borrow.ID.String(),
+		BookID:         borrow.BookID.String(),
+		SubscriptionID: borrow.SubscriptionID.String(),
+		StaffID:        borrow.StaffID.String(),
+		BorrowedAt:     borrow.BorrowedAt.Format(time.RFC3339),
+		DueAt:          borrow.DueAt.Format(time.RFC3339),
+		RenewalCount:   borrow.RenewalCount,
+		CreatedAt:      borrow.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:      borrow.UpdatedAt.Format(time.RFC3339),
+	}})
+}
+
+type BorrowingRenewal struct {
+	ID            string  `json:"id"`
+	BorrowingID   string  `json:"borrowing_id"`
+	StaffID       *string `json:"staff_id"`
+	PreviousDueAt string  `json:"previous_due_at"`
+	DueAt         string  `json:"due_at"`
+	CreatedAt     string  `json:"created_at"`
+}
+
+func (s *Server) ListBorrowingRenewals(ctx echo.Context) error {
+	var req GetBorrowingByIDRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	id, _ := uuid.Parse(req.ID)
+	renewals, err := s.server.ListBorrowingRenewals(ctx.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	list := make([]BorrowingRenewal, 0, len(renewals))
+	for _, r := range renewals {
+		br := BorrowingRenewal{
+			ID:            r.ID.String(),
+			BorrowingID:   r.BorrowingID.String(),
+			PreviousDueAt: r.PreviousDueAt.Format(time.RFC3339),
+			DueAt:         r.DueAt.Format(time.RFC3339),
+			CreatedAt:     r.CreatedAt.Format(time.RFC3339),
+		}
+		if r.StaffID != nil {
+			sid := r.StaffID.String()
+			br.StaffID = &sid
+		}
+		list = append(list, br)
+	}
+
+	return ctx.JSON(200, Res{Data: list})
+}
+
+func (s *Server) DeleteBorrowing(ctx echo.Context) error {
+	return s.noContentByID(ctx, s.server.DeleteBorrowing)
+}
+
+func (s *Server) RestoreBorrowing(ctx echo.Context) error {
+	return s.noContentByID(ctx, s.server.RestoreBorrowing)
+}
+
+func (s *Server) PurgeBorrowing(ctx echo.Context) error {
+	return s.noContentByID(ctx, s.server.PurgeBorrowing)
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"librarease/internal/usecase"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+// errorCodes maps usecase errors to their status and machine-readable
+// code. The first match wins, so specific errors come before the
+// generic ones they wrap.
+var errorCodes = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{usecase.ErrEmailAlreadyExists, 409, "EMAIL_ALREADY_EXISTS"},
+	{usecase.ErrInvalidCredentials, 401, "INVALID_CREDENTIALS"},
+	{usecase.ErrInvalidRefreshToken, 401, "INVALID_REFRESH_TOKEN"},
+	{usecase.ErrInvalidISBN, 422, "INVALID_ISBN"},
+	{usecase.ErrMembershipInUse, 409, "MEMBERSHIP_IN_USE"},
+	{usecase.ErrWorkInUse, 409, "WORK_IN_USE"},
+	{usecase.ErrBookOnLoan, 409, "BOOK_ON_LOAN"},
+	{usecase.ErrLibraryOnLoan, 409, "LIBRARY_ON_LOAN"},
+	{usecase.ErrSubscriptionOnLoan, 409, "SUBSCRIPTION_ON_LOAN"},
+	{usecase.ErrMembershipExpired, 422, "MEMBERSHIP_EXPIRED"},
+	{usecase.ErrActiveLoanLimitReached, 422, "ACTIVE_LOAN_LIMIT_REACHED"},
+	{usecase.ErrBookNotAvailable, 422, "BOOK_NOT_AVAILABLE"},
+	{usecase.ErrStaffNotInLibrary, 422, "STAFF_NOT_IN_LIBRARY"},
+	{usecase.ErrAlreadyReturned, 409, "ALREADY_RETURNED"},
+	{usecase.ErrOutstandingFines, 422, "OUTSTANDING_FINES"},
+	{usecase.ErrBorrowingOverdue, 422, "BORROWING_OVERDUE"},
+	{usecase.ErrRenewalLimitReached, 422, "RENEWAL_LIMIT_REACHED"},
+	{usecase.ErrBookOnHold, 422, "BOOK_ON_HOLD"},
+	{usecase.ErrHoldClosed, 409, "HOLD_CLOSED"},
+	{usecase.ErrSubscriptionSuspended, 422, "SUBSCRIPTION_SUSPENDED"},
+	{usecase.ErrNotFound, 404, "NOT_FOUND"},
+	{usecase.ErrAlreadyExists, 409, "ALREADY_EXISTS"},
+	{usecase.ErrReferenceNotFound, 422, "REFERENCE_NOT_FOUND"},
+	{usecase.ErrInvalidArgument, 422, "INVALID_ARGUMENT"},
+	{usecase.ErrInUse, 409, "IN_USE"},
+}
+
+// HTTPErrorHandler writes errors returned by handlers as Res with the
+// status and code of the error. Unknown errors are logged and reported
+// as 500 without details, as are errors of handlers that already began
+// their response, such as streams.
+func (s *Server) HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		c.Logger().Error(err)
+		return
+	}
+
+	status, res := errorResponse(err)
+	if status == http.StatusInternalServerError {
+		c.Logger().Error(err)
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(status)
+	} else {
+		err = c.JSON(status, res)
+	}
+	if err != nil {
+		c.Logger().Error(err)
+	}
+}
+
+func errorResponse(err error) (int, Res) {
+	var (
+		he *echo.HTTPError
+		ve validator.ValidationErrors
+	)
+	switch {
+	case errors.As(err, &he):
+		return he.Code, Res{Error: statusCode(he.Code), Message: fmt.Sprint(he.Message)}
+	case errors.As(err, &ve):
+		return 422, Res{Error: "VALIDATION_FAILED", Message: ve.Error()}
+	}
+
+	for _, ec := range errorCodes {
+		if errors.Is(err, ec.err) {
+			return ec.status, Res{Error: ec.code, Message: err.Error()}
+		}
+	}
+
+	return 500, Res{Error: "INTERNAL", Message: "internal server error"}
+}
+
+// statusCode turns a status into a code, e.g. 404 into NOT_FOUND.
+func statusCode(status int) string {
+	return strings.ToUpper(strings.ReplaceAll(http.StatusText(status), " ", "_"))
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"librarease/internal/usecase"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+func TestErrorResponse(t *testing.T) {
+	validationErr := validator.New().Struct(struct {
+		Name string `validate:"required"`
+	}{})
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"not found", fmt.Errorf("get book: %w", usecase.ErrNotFound), 404, "NOT_FOUND"},
+		{"unique violation", usecase.ErrAlreadyExists, 409, "ALREADY_EXISTS"},
+		{"email taken", usecase.ErrEmailAlreadyExists, 409, "EMAIL_ALREADY_EXISTS"},
+		{"expired", fmt.Errorf("%w: subscription", usecase.ErrMembershipExpired), 422, "MEMBERSHIP_EXPIRED"},
+		{"book unavailable", usecase.ErrBookNotAvailable, 422, "BOOK_NOT_AVAILABLE"},
+		{"validation", validationErr, 422, "VALIDATION_FAILED"},
+		{"echo", echo.ErrMethodNotAllowed, 405, "METHOD_NOT_ALLOWED"},
+		{"unknown", errors.New("connection reset"), 500, "INTERNAL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, res := errorResponse(tt.err)
+			if status != tt.status || res.Error != tt.code {
+				t.Errorf("errorResponse() = %d %s, want %d %s", status, res.Error, tt.status, tt.code)
+			}
+		})
+	}
+}
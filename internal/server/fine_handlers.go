@@ -0,0 +1,211 @@
+package server
+
+import (
+	"context"
+	"librarease/internal/usecase"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type FineEntry struct {
+	ID          string  `json:"id"`
+	Kind        string  `json:"kind"`
+	Amount      int     `json:"amount"`
+	UserID      string  `json:"user_id"`
+	LibraryID   string  `json:"library_id"`
+	BorrowingID *string `json:"borrowing_id"`
+	StaffID     *string `json:"staff_id"`
+	Note        string  `json:"note,omitempty"`
+	CreatedAt   string  `json:"created_at"`
+	UpdatedAt   string  `json:"updated_at"`
+
+	User    *User    `json:"user,omitempty"`
+	Library *Library `json:"library,omitempty"`
+	Staff   *Staff   `json:"staff,omitempty"`
+}
+
+type FineBalance struct {
+	UserID      string `json:"user_id"`
+	LibraryID   string `json:"library_id"`
+	Charged     int    `json:"charged"`
+	Paid        int    `json:"paid"`
+	Waived      int    `json:"waived"`
+	Outstanding int    `json:"outstanding"`
+}
+
+type ListFineEntriesRequest struct {
+	Skip        int    `query:"skip"`
+	Limit       int    `query:"limit" validate:"required,gte=1,lte=100"`
+	UserID      string `query:"user_id" validate:"omitempty,uuid"`
+	LibraryID   string `query:"library_id" validate:"omitempty,uuid"`
+	BorrowingID string `query:"borrowing_id" validate:"omitempty,uuid"`
+	Kind        string `query:"kind" validate:"omitempty,oneof=CHARGE PAYMENT WAIVER"`
+	SortBy      string `query:"sort_by" validate:"omitempty,oneof=created_at updated_at amount"`
+	SortIn      string `query:"sort_in" validate:"omitempty,oneof=asc desc"`
+}
+
+func (s *Server) ListFineEntries(ctx echo.Context) error {
+	var req ListFineEntriesRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	entries, total, err := s.server.ListFineEntries(ctx.Request().Context(), usecase.ListFineEntriesOption{
+		Skip:        req.Skip,
+		Limit:       req.Limit,
+		UserID:      req.UserID,
+		LibraryID:   req.LibraryID,
+		BorrowingID: req.BorrowingID,
+		Kind:        req.Kind,
+		SortBy:      req.SortBy,
+		SortIn:      req.SortIn,
+	})
+	if err != nil {
+		return err
+	}
+
+	list := make([]FineEntry, 0, len(entries))
+	for _, e := range entries {
+		fe := ConvertFineEntryFrom(e)
+		if e.User != nil {
+			fe.User = &User{
+				ID:   e.User.ID.String(),
+				Name: e.User.Name,
+			}
+		}
+		if e.Library != nil {
+			fe.Library = &Library{
+				ID:   e.Library.ID.String(),
+				Name: e.Library.Name,
+			}
+		}
+		if e.Staff != nil {
+			fe.Staff = &Staff{
+				ID:   e.Staff.ID.String(),
+				Name: e.Staff.Name,
+			}
+		}
+		list = append(list, fe)
+	}
+
+	return ctx.JSON(200, Res{
+		Data: list,
+		Meta: &Meta{
+			Total: total,
+			Skip:  req.Skip,
+			Limit: req.Limit,
+		},
+	})
+}
+
+type GetFineBalanceRequest struct {
+	UserID    string `query:"user_id" validate:"required,uuid"`
+	LibraryID string `query:"library_id" validate:"required,uuid"`
+}
+
+func (s *Server) GetFineBalance(ctx echo.Context) error {
+	var req GetFineBalanceRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	userID, _ := uuid.Parse(req.UserID)
+	libraryID, _ := uuid.Parse(req.LibraryID)
+	bal, err := s.server.GetFineBalance(ctx.Request().Context(), userID, libraryID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(200, Res{Data: FineBalance{
+		UserID:      bal.UserID.String(),
+		LibraryID:   bal.LibraryID.String(),
+		Charged:     bal.Charged,
+		Paid:        bal.Paid,
+		Waived:      bal.Waived,
+		Outstanding: bal.Outstanding,
+	}})
+}
+
+type SettleFineRequest struct {
+	UserID    string `json:"user_id" validate:"required,uuid"`
+	LibraryID string `json:"library_id" validate:"required,uuid"`
+	Amount    int    `json:"amount" validate:"required,gt=0"`
+	Note      string `json:"note" validate:"omitempty,max=255"`
+}
+
+func (s *Server) PayFine(ctx echo.Context) error {
+	return s.settleFine(ctx, s.server.PayFine)
+}
+
+func (s *Server) WaiveFine(ctx echo.Context) error {
+	return s.settleFine(ctx, s.server.WaiveFine)
+}
+
+func (s *Server) settleFine(ctx echo.Context, settle func(context.Context, usecase.FineEntry) (usecase.FineEntry, error)) error {
+	var req SettleFineRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	userID, _ := uuid.Parse(req.UserID)
+	libraryID, _ := uuid.Parse(req.LibraryID)
+	staffID, err := s.callerStaffIn(ctx, libraryID)
+	if err != nil {
+		return err
+	}
+
+	e, err := settle(ctx.Request().Context(), usecase.FineEntry{
+		Amount:    req.Amount,
+		UserID:    userID,
+		LibraryID: libraryID,
+		StaffID:   &staffID,
+		Note:      req.Note,
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(201, Res{Data: ConvertFineEntryFrom(e)})
+}
+
+func (s *Server) AccrueFines(ctx echo.Context) error {
+	n, err := s.server.AccrueFines(ctx.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(200, Res{Data: map[string]int{"charged": n}})
+}
+
+func ConvertFineEntryFrom(e usecase.FineEntry) FineEntry {
+	fe := FineEntry{
+		ID:        e.ID.String(),
+		Kind:      e.Kind,
+		Amount:    e.Amount,
+		UserID:    e.UserID.String(),
+		LibraryID: e.LibraryID.String(),
+		Note:      e.Note,
+		CreatedAt: e.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: e.UpdatedAt.Format(time.RFC3339),
+	}
+	if e.BorrowingID != nil {
+		id := e.BorrowingID.String()
+		fe.BorrowingID = &id
+	}
+	if e.StaffID != nil {
+		id := e.StaffID.String()
+		fe.StaffID = &id
+	}
+	return fe
+}
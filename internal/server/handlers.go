@@ -1,12 +1,14 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"time"
 
 	"github.com/coder/websocket"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
@@ -49,3 +51,26 @@ func (s *Server) websocketHandler(c echo.Context) error {
 	}
 	return nil
 }
+
+type IDRequest struct {
+	ID string `param:"id" validate:"required,uuid"`
+}
+
+// noContentByID calls fn with the id in the path and answers 204 No
+// Content, as the delete, restore and purge routes do.
+func (s *Server) noContentByID(ctx echo.Context, fn func(context.Context, uuid.UUID) error) error {
+	var req IDRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	id, _ := uuid.Parse(req.ID)
+	if err := fn(ctx.Request().Context(), id); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(204)
+}
@@ -0,0 +1,224 @@
+package server
+
+import (
+	"librarease/internal/usecase"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type Hold struct {
+	ID             string  `json:"id"`
+	BookID         string  `json:"book_id"`
+	SubscriptionID string  `json:"subscription_id"`
+	StaffID        *string `json:"staff_id"`
+	Status         string  `json:"status"`
+	ReadyAt        *string `json:"ready_at"`
+	ExpiresAt      *string `json:"expires_at"`
+	ClosedAt       *string `json:"closed_at"`
+	BorrowingID    *string `json:"borrowing_id"`
+	CreatedAt      string  `json:"created_at"`
+	UpdatedAt      string  `json:"updated_at"`
+
+	Book         *Book         `json:"book,omitempty"`
+	Subscription *Subscription `json:"subscription,omitempty"`
+}
+
+type ListHoldsRequest struct {
+	Skip           int    `query:"skip"`
+	Limit          int    `query:"limit" validate:"required,gte=1,lte=100"`
+	BookID         string `query:"book_id" validate:"omitempty,uuid"`
+	SubscriptionID string `query:"subscription_id" validate:"omitempty,uuid"`
+	UserID         string `query:"user_id" validate:"omitempty,uuid"`
+	LibraryID      string `query:"library_id" validate:"omitempty,uuid"`
+	Status         string `query:"status" validate:"omitempty,oneof=WAITING READY FULFILLED CANCELLED EXPIRED"`
+	SortBy         string `query:"sort_by" validate:"omitempty,oneof=created_at updated_at expires_at"`
+	SortIn         string `query:"sort_in" validate:"omitempty,oneof=asc desc"`
+}
+
+func (s *Server) ListHolds(ctx echo.Context) error {
+	var req ListHoldsRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	var statuses []string
+	if req.Status != "" {
+		statuses = []string{req.Status}
+	}
+	holds, total, err := s.server.ListHolds(ctx.Request().Context(), usecase.ListHoldsOption{
+		Skip:           req.Skip,
+		Limit:          req.Limit,
+		BookID:         req.BookID,
+		SubscriptionID: req.SubscriptionID,
+		UserID:         req.UserID,
+		LibraryID:      req.LibraryID,
+		Statuses:       statuses,
+		SortBy:         req.SortBy,
+		SortIn:         req.SortIn,
+	})
+	if err != nil {
+		return err
+	}
+
+	list := make([]Hold, 0, len(holds))
+	for _, h := range holds {
+		list = append(list, ConvertHoldFrom(h))
+	}
+
+	return ctx.JSON(200, Res{
+		Data: list,
+		Meta: &Meta{
+			Total: total,
+			Skip:  req.Skip,
+			Limit: req.Limit,
+		},
+	})
+}
+
+type GetHoldByIDRequest struct {
+	ID string `param:"id" validate:"required,uuid"`
+}
+
+func (s *Server) GetHoldByID(ctx echo.Context) error {
+	var req GetHoldByIDRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	id, _ := uuid.Parse(req.ID)
+	h, err := s.server.GetHoldByID(ctx.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(200, Res{Data: ConvertHoldFrom(h)})
+}
+
+type PlaceHoldRequest struct {
+	BookID         string `json:"book_id" validate:"required,uuid"`
+	SubscriptionID string `json:"subscription_id" validate:"required,uuid"`
+}
+
+func (s *Server) PlaceHold(ctx echo.Context) error {
+	var req PlaceHoldRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	bookID, _ := uuid.Parse(req.BookID)
+	subscriptionID, _ := uuid.Parse(req.SubscriptionID)
+	book, err := s.server.GetBookByID(ctx.Request().Context(), bookID)
+	if err != nil {
+		return err
+	}
+	// members placing their own holds are recorded without staff
+	staffID, err := s.callerStaffOrNil(ctx, book.LibraryID)
+	if err != nil {
+		return err
+	}
+
+	h, err := s.server.PlaceHold(ctx.Request().Context(), usecase.Hold{
+		BookID:         bookID,
+		SubscriptionID: subscriptionID,
+		StaffID:        staffID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(201, Res{Data: ConvertHoldFrom(h)})
+}
+
+func (s *Server) CancelHold(ctx echo.Context) error {
+	var req GetHoldByIDRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	id, _ := uuid.Parse(req.ID)
+	h, err := s.server.CancelHold(ctx.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(200, Res{Data: ConvertHoldFrom(h)})
+}
+
+func (s *Server) ExpireHolds(ctx echo.Context) error {
+	n, err := s.server.ExpireHolds(ctx.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(200, Res{Data: map[string]int{"expired": n}})
+}
+
+func ConvertHoldFrom(h usecase.Hold) Hold {
+	formatTime := func(t *time.Time) *string {
+		if t == nil {
+			return nil
+		}
+		s := t.Format(time.RFC3339)
+		return &s
+	}
+	formatID := func(id *uuid.UUID) *string {
+		if id == nil {
+			return nil
+		}
+		s := id.String()
+		return &s
+	}
+
+	hold := Hold{
+		ID:             h.ID.String(),
+		BookID:         h.BookID.String(),
+		SubscriptionID: h.SubscriptionID.String(),
+		StaffID:        formatID(h.StaffID),
+		Status:         h.Status,
+		ReadyAt:        formatTime(h.ReadyAt),
+		ExpiresAt:      formatTime(h.ExpiresAt),
+		ClosedAt:       formatTime(h.ClosedAt),
+		BorrowingID:    formatID(h.BorrowingID),
+		CreatedAt:      h.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:      h.UpdatedAt.Format(time.RFC3339),
+	}
+	if h.Book != nil {
+		hold.Book = &Book{
+			ID:        h.Book.ID.String(),
+			Code:      h.Book.Code,
+			Title:     h.Book.Title,
+			Author:    h.Book.Author,
+			Year:      h.Book.Year,
+			LibraryID: h.Book.LibraryID.String(),
+		}
+	}
+	if h.Subscription != nil {
+		hold.Subscription = &Subscription{
+			ID:           h.Subscription.ID.String(),
+			UserID:       h.Subscription.UserID.String(),
+			MembershipID: h.Subscription.MembershipID.String(),
+			ExpiresAt:    h.Subscription.ExpiresAt.Format(time.RFC3339),
+		}
+		if h.Subscription.User != nil {
+			hold.Subscription.User = &User{
+				ID:   h.Subscription.User.ID.String(),
+				Name: h.Subscription.User.Name,
+			}
+		}
+	}
+	return hold
+}
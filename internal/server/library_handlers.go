@@ -12,36 +12,39 @@ type Library struct {
 	ID   string `json:"id" param:"id"`
 	Name string `json:"name" validate:"required"`
 	// Location  string `json:"location" validate:"required"`
-	CreatedAt string `json:"created_at,omitempty"`
-	UpdatedAt string `json:"updated_at,omitempty"`
+	CreatedAt string  `json:"created_at,omitempty"`
+	UpdatedAt string  `json:"updated_at,omitempty"`
+	DeletedAt *string `json:"deleted_at,omitempty"`
 }
 
 type ListLibrariesRequest struct {
-	Skip   int    `query:"skip"`
-	Limit  int    `query:"limit" validate:"required,gte=1,lte=100"`
-	Name   string `query:"name" validate:"omitempty"`
-	SortBy string `query:"sort_by" validate:"omitempty,oneof=created_at updated_at name"`
-	SortIn string `query:"sort_in" validate:"omitempty,oneof=asc desc"`
+	Skip           int    `query:"skip"`
+	Limit          int    `query:"limit" validate:"required,gte=1,lte=100"`
+	Name           string `query:"name" validate:"omitempty"`
+	SortBy         string `query:"sort_by" validate:"omitempty,oneof=created_at updated_at name"`
+	SortIn         string `query:"sort_in" validate:"omitempty,oneof=asc desc"`
+	IncludeDeleted bool   `query:"include_deleted"`
 }
 
 func (s *Server) ListLibraries(ctx echo.Context) error {
 	var req ListLibrariesRequest
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(400, map[string]string{"error": err.Error()})
+		return err
 	}
 	if err := s.validator.Struct(req); err != nil {
-		return ctx.JSON(422, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	libraries, total, err := s.server.ListLibraries(ctx.Request().Context(), usecase.ListLibrariesOption{
-		Skip:   req.Skip,
-		Limit:  req.Limit,
-		Name:   req.Name,
-		SortBy: req.SortBy,
-		SortIn: req.SortIn,
+		Skip:           req.Skip,
+		Limit:          req.Limit,
+		Name:           req.Name,
+		SortBy:         req.SortBy,
+		SortIn:         req.SortIn,
+		IncludeDeleted: req.IncludeDeleted,
 	})
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	list := make([]Library, 0, len(libraries))
@@ -53,6 +56,7 @@ func (s *Server) ListLibraries(ctx echo.Context) error {
 			// Location:  l.Location,
 			CreatedAt: l.CreatedAt.Format(time.RFC3339),
 			UpdatedAt: l.UpdatedAt.Format(time.RFC3339),
+			DeletedAt: optionalTime(l.DeleteAt),
 		})
 	}
 
@@ -71,7 +75,7 @@ func (s *Server) GetLibraryByID(ctx echo.Context) error {
 	id := ctx.Param("id")
 	l, err := s.server.GetLibraryByID(ctx.Request().Context(), id)
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	lib := ConverLibraryFrom(l)
@@ -82,12 +86,12 @@ func (s *Server) GetLibraryByID(ctx echo.Context) error {
 func (s *Server) CreateLibrary(ctx echo.Context) error {
 	var library Library
 	if err := ctx.Bind(&library); err != nil {
-		return ctx.JSON(400, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	err := s.validator.Struct(library)
 	if err != nil {
-		return ctx.JSON(422, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	l, err := s.server.CreateLibrary(ctx.Request().Context(), usecase.Library{
@@ -95,7 +99,7 @@ func (s *Server) CreateLibrary(ctx echo.Context) error {
 		// Location: library.Location,
 	})
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	return ctx.JSON(200, Res{Data: Library{
@@ -110,12 +114,12 @@ func (s *Server) CreateLibrary(ctx echo.Context) error {
 func (s *Server) UpdateLibrary(ctx echo.Context) error {
 	var library Library
 	if err := ctx.Bind(&library); err != nil {
-		return ctx.JSON(400, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	err := s.validator.Struct(library)
 	if err != nil {
-		return ctx.JSON(422, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	id, _ := uuid.Parse(library.ID)
@@ -126,7 +130,7 @@ func (s *Server) UpdateLibrary(ctx echo.Context) error {
 		// Location: library.Location,
 	})
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	return ctx.JSON(200, Res{Data: Library{
@@ -142,12 +146,20 @@ func (s *Server) DeleteLibrary(ctx echo.Context) error {
 	id := ctx.Param("id")
 	err := s.server.DeleteLibrary(ctx.Request().Context(), id)
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	return ctx.NoContent(204)
 }
 
+func (s *Server) RestoreLibrary(ctx echo.Context) error {
+	return s.noContentByID(ctx, s.server.RestoreLibrary)
+}
+
+func (s *Server) PurgeLibrary(ctx echo.Context) error {
+	return s.noContentByID(ctx, s.server.PurgeLibrary)
+}
+
 func ConverLibraryFrom(lib usecase.Library) Library {
 	return Library{
 		ID:   lib.ID.String(),
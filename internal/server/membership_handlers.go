@@ -16,6 +16,7 @@ type Membership struct {
 	ActiveLoanLimit int      `json:"active_loan_limit,omitempty"`
 	LoanPeriod      int      `json:"loan_period,omitempty"`
 	FinePerDay      int      `json:"fine_per_day,omitempty"`
+	MaxRenewals     int      `json:"max_renewals,omitempty"`
 	CreatedAt       string   `json:"created_at,omitempty"`
 	UpdatedAt       string   `json:"updated_at,omitempty"`
 	DeletedAt       string   `json:"deleted_at,omitempty"`
@@ -23,27 +24,29 @@ type Membership struct {
 }
 
 type ListMembershipsRequest struct {
-	LibraryID string `query:"library_id" validate:"omitempty,uuid"`
-	Skip      int    `query:"skip"`
-	Limit     int    `query:"limit" validate:"required,gte=1,lte=100"`
+	LibraryID      string `query:"library_id" validate:"omitempty,uuid"`
+	Skip           int    `query:"skip"`
+	Limit          int    `query:"limit" validate:"required,gte=1,lte=100"`
+	IncludeDeleted bool   `query:"include_deleted"`
 }
 
 func (s *Server) ListMemberships(ctx echo.Context) error {
 	var req ListMembershipsRequest
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(400, map[string]string{"error": err.Error()})
+		return err
 	}
 	if err := s.validator.Struct(req); err != nil {
-		return ctx.JSON(422, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	memberships, total, err := s.server.ListMemberships(ctx.Request().Context(), usecase.ListMembershipsOption{
-		Skip:      req.Skip,
-		Limit:     req.Limit,
-		LibraryID: req.LibraryID,
+		Skip:           req.Skip,
+		Limit:          req.Limit,
+		LibraryID:      req.LibraryID,
+		IncludeDeleted: req.IncludeDeleted,
 	})
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 	list := make([]Membership, 0, len(memberships))
 
@@ -60,6 +63,7 @@ func (s *Server) ListMemberships(ctx echo.Context) error {
 			ActiveLoanLimit: mem.ActiveLoanLimit,
 			LoanPeriod:      mem.LoanPeriod,
 			FinePerDay:      mem.FinePerDay,
+			MaxRenewals:     mem.MaxRenewals,
 			CreatedAt:       mem.CreatedAt.Format(time.RFC3339),
 			UpdatedAt:       mem.UpdatedAt.Format(time.RFC3339),
 			DeletedAt:       d,
@@ -92,15 +96,15 @@ type GetMembershipByIDRequest struct {
 func (s *Server) GetMembershipByID(ctx echo.Context) error {
 	var req GetMembershipByIDRequest
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(400, map[string]string{"error": err.Error()})
+		return err
 	}
 	if err := s.validator.Struct(req); err != nil {
-		return ctx.JSON(422, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	mem, err := s.server.GetMembershipByID(ctx.Request().Context(), req.ID)
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	var d string
@@ -115,6 +119,7 @@ func (s *Server) GetMembershipByID(ctx echo.Context) error {
 		ActiveLoanLimit: mem.ActiveLoanLimit,
 		LoanPeriod:      mem.LoanPeriod,
 		FinePerDay:      mem.FinePerDay,
+		MaxRenewals:     mem.MaxRenewals,
 		CreatedAt:       mem.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:       mem.UpdatedAt.Format(time.RFC3339),
 		DeletedAt:       d,
@@ -137,15 +142,16 @@ type CreateMembershipRequest struct {
 	ActiveLoanLimit int    `json:"active_loan_limit" validate:"required,number"`
 	LoanPeriod      int    `json:"loan_period" validate:"required,number"`
 	FinePerDay      int    `json:"fine_per_day" validate:"number"`
+	MaxRenewals     int    `json:"max_renewals" validate:"number"`
 }
 
 func (s *Server) CreateMembership(ctx echo.Context) error {
 	var req CreateMembershipRequest
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(400, map[string]string{"error": err.Error()})
+		return err
 	}
 	if err := s.validator.Struct(req); err != nil {
-		return ctx.JSON(422, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	uid, _ := uuid.Parse(req.LibraryID)
@@ -156,9 +162,10 @@ func (s *Server) CreateMembership(ctx echo.Context) error {
 		ActiveLoanLimit: req.ActiveLoanLimit,
 		LoanPeriod:      req.LoanPeriod,
 		FinePerDay:      req.FinePerDay,
+		MaxRenewals:     req.MaxRenewals,
 	})
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 	return ctx.JSON(201, Res{Data: Membership{
 		ID:              mem.ID.String(),
@@ -168,6 +175,7 @@ func (s *Server) CreateMembership(ctx echo.Context) error {
 		ActiveLoanLimit: mem.ActiveLoanLimit,
 		LoanPeriod:      mem.LoanPeriod,
 		FinePerDay:      mem.FinePerDay,
+		MaxRenewals:     mem.MaxRenewals,
 		CreatedAt:       mem.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:       mem.UpdatedAt.Format(time.RFC3339),
 	}})
@@ -181,15 +189,16 @@ type UpdateMembershipRequest struct {
 	ActiveLoanLimit int    `json:"active_loan_limit" validate:"number"`
 	LoanPeriod      int    `json:"loan_period" validate:"number"`
 	FinePerDay      int    `json:"fine_per_day" validate:"number"`
+	MaxRenewals     int    `json:"max_renewals" validate:"number"`
 }
 
 func (s *Server) UpdateMembership(ctx echo.Context) error {
 	var req UpdateMembershipRequest
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(400, map[string]string{"error": err.Error()})
+		return err
 	}
 	if err := s.validator.Struct(req); err != nil {
-		return ctx.JSON(422, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	id, _ := uuid.Parse(req.ID)
@@ -201,9 +210,10 @@ func (s *Server) UpdateMembership(ctx echo.Context) error {
 		ActiveLoanLimit: req.ActiveLoanLimit,
 		LoanPeriod:      req.LoanPeriod,
 		FinePerDay:      req.FinePerDay,
+		MaxRenewals:     req.MaxRenewals,
 	})
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 	return ctx.JSON(200, Res{Data: Membership{
 		ID:              mem.ID.String(),
@@ -213,7 +223,20 @@ func (s *Server) UpdateMembership(ctx echo.Context) error {
 		ActiveLoanLimit: mem.ActiveLoanLimit,
 		LoanPeriod:      mem.LoanPeriod,
 		FinePerDay:      mem.FinePerDay,
+		MaxRenewals:     mem.MaxRenewals,
 		CreatedAt:       mem.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:       mem.UpdatedAt.Format(time.RFC3339),
 	}})
 }
+
+func (s *Server) DeleteMembership(ctx echo.Context) error {
+	return s.noContentByID(ctx, s.server.DeleteMembership)
+}
+
+func (s *Server) RestoreMembership(ctx echo.Context) error {
+	return s.noContentByID(ctx, s.server.RestoreMembership)
+}
+
+func (s *Server) PurgeMembership(ctx echo.Context) error {
+	return s.noContentByID(ctx, s.server.PurgeMembership)
+}
@@ -1,9 +1,14 @@
 package server
 
 import (
+	"fmt"
 	"librarease/internal/config"
+	"librarease/internal/usecase"
 	"os"
+	"strconv"
+	"strings"
 
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
@@ -12,16 +17,155 @@ var (
 	isLocal = AppEnv == "local"
 )
 
-func WithUserID() echo.MiddlewareFunc {
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	UID        string
+	UserID     uuid.UUID
+	GlobalRole string
+	// Staffs maps the libraries the caller is staff of to its role there.
+	Staffs map[uuid.UUID]string
+}
+
+func newPrincipal(au usecase.AuthUser) Principal {
+	p := Principal{
+		UID:        au.UID,
+		UserID:     au.UserID,
+		GlobalRole: au.GlobalRole,
+		Staffs:     make(map[uuid.UUID]string),
+	}
+	if au.User != nil {
+		for _, st := range au.User.Staffs {
+			p.Staffs[st.LibraryID] = st.Role
+		}
+	}
+	return p
+}
+
+// GetPrincipal returns the principal set by WithUserID, if any.
+func GetPrincipal(c echo.Context) (Principal, bool) {
+	p, ok := c.Get(config.CTX_KEY_PRINCIPAL).(Principal)
+	return p, ok
+}
+
+// callerStaffID returns the id of the caller's staff record in the
+// library the request targets, so handlers attribute actions to the
+// authenticated caller rather than to a staff named in the body.
+func (s *Server) callerStaffID(c echo.Context, library resolver) (uuid.UUID, error) {
+	libraryID, err := library(s, c)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return s.callerStaffIn(c, libraryID)
+}
+
+// callerStaffIn is callerStaffID for a library the handler already knows.
+func (s *Server) callerStaffIn(c echo.Context, libraryID uuid.UUID) (uuid.UUID, error) {
+	p, ok := GetPrincipal(c)
+	if !ok {
+		return uuid.Nil, echo.NewHTTPError(401, "authentication required")
+	}
+	staffs, _, err := s.server.ListStaffs(c.Request().Context(), usecase.ListStaffsOption{
+		Limit:     1,
+		LibraryID: libraryID.String(),
+		UserID:    p.UserID.String(),
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if len(staffs) == 0 {
+		return uuid.Nil, fmt.Errorf("%w: user %s, library %s", usecase.ErrStaffNotInLibrary, p.UserID, libraryID)
+	}
+	return staffs[0].ID, nil
+}
+
+// callerStaffOrNil is callerStaffIn for routes members may call for
+// themselves: it returns nil unless the caller is staff of the library.
+func (s *Server) callerStaffOrNil(c echo.Context, libraryID uuid.UUID) (*uuid.UUID, error) {
+	p, _ := GetPrincipal(c)
+	if _, ok := p.Staffs[libraryID]; !ok {
+		return nil, nil
+	}
+	id, err := s.callerStaffIn(c, libraryID)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// WithUserID verifies the bearer ID token of the request and puts the
+// resolved Principal on the context. In local mode the X-User-Id header
+// is trusted instead when no token is sent. Requests without credentials
+// pass through anonymously; Authorize decides whether that is allowed.
+func (s *Server) WithUserID() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			if isLocal {
+			ctx := c.Request().Context()
+
+			token, ok := strings.CutPrefix(c.Request().Header.Get(config.HEADER_KEY_AUTHORIZATION), "Bearer ")
+			if !ok || token == "" {
 				userID := c.Request().Header.Get(config.HEADER_KEY_X_USER_ID)
-				c.Set(config.HEADER_KEY_X_USER_ID, userID)
+				if !isLocal || userID == "" {
+					return next(c)
+				}
+				id, err := uuid.Parse(userID)
+				if err != nil {
+					return c.JSON(401, Res{Error: "UNAUTHENTICATED", Message: "invalid user id"})
+				}
+				au, err := s.server.GetAuthUserByUserID(ctx, id)
+				if err != nil {
+					return c.JSON(401, Res{Error: "UNAUTHENTICATED", Message: "unknown user"})
+				}
+				c.Set(config.CTX_KEY_PRINCIPAL, newPrincipal(au))
 				return next(c)
 			}
-			// TODO: Implement logic to get user ID from JWT token.
+
+			au, err := s.server.VerifyIDToken(ctx, token)
+			if err != nil {
+				return c.JSON(401, Res{Error: "UNAUTHENTICATED", Message: "invalid token"})
+			}
+			c.Set(config.CTX_KEY_PRINCIPAL, newPrincipal(au))
 			return next(c)
 		}
 	}
 }
+
+// Authorize enforces the access declared in policies for the matched route.
+// Routes without a declared policy are treated as not found.
+func (s *Server) Authorize() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			a, ok := policies[c.Request().Method+" "+c.Path()]
+			if !ok {
+				return c.JSON(404, Res{Error: "NOT_FOUND", Message: "route not found"})
+			}
+			if deleted, _ := strconv.ParseBool(c.QueryParam("include_deleted")); deleted && a.IncludeDeleted != nil {
+				a = *a.IncludeDeleted
+			}
+			if a.Public {
+				return next(c)
+			}
+
+			p, ok := GetPrincipal(c)
+			if !ok {
+				return c.JSON(401, Res{Error: "UNAUTHENTICATED", Message: "authentication required"})
+			}
+			if a.Allows(p, uuid.Nil, uuid.Nil) {
+				return next(c)
+			}
+
+			// Only look up the target resource when roles alone are not enough.
+			var libraryID, ownerID uuid.UUID
+			if a.Library != nil {
+				libraryID, _ = a.Library(s, c)
+			}
+			if a.Owner != nil {
+				ownerID, _ = a.Owner(s, c)
+			}
+			if a.Allows(p, libraryID, ownerID) {
+				return next(c)
+			}
+
+			return c.JSON(403, Res{Error: "FORBIDDEN", Message: "insufficient permissions"})
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"librarease/internal/usecase"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// stubIdentityProvider stands in for Firebase and verifies tokens
+// signed with a local HMAC key.
+type stubIdentityProvider struct {
+	key []byte
+}
+
+func (p stubIdentityProvider) CreateUser(context.Context, usecase.RegisterUser) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+func (p stubIdentityProvider) DeleteUser(context.Context, string) error {
+	return errors.New("not implemented")
+}
+
+func (p stubIdentityProvider) SignIn(context.Context, string, string) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+func (p stubIdentityProvider) IssueToken(context.Context, string) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+func (p stubIdentityProvider) VerifyIDToken(_ context.Context, token string) (string, error) {
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return p.key, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return "", err
+	}
+	return claims.Subject, nil
+}
+
+func (p stubIdentityProvider) sign(t *testing.T, uid string, exp time.Time) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   uid,
+		ExpiresAt: jwt.NewNumericDate(exp),
+	}).SignedString(p.key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return token
+}
+
+// stubRepo serves auth users from memory; any other call panics.
+type stubRepo struct {
+	usecase.Repository
+	authUsers []usecase.AuthUser
+}
+
+func (r stubRepo) GetAuthUserByUID(_ context.Context, uid string) (usecase.AuthUser, error) {
+	for _, au := range r.authUsers {
+		if au.UID == uid {
+			return au, nil
+		}
+	}
+	return usecase.AuthUser{}, errors.New("record not found")
+}
+
+func (r stubRepo) GetAuthUserByUserID(_ context.Context, id uuid.UUID) (usecase.AuthUser, error) {
+	for _, au := range r.authUsers {
+		if au.UserID == id {
+			return au, nil
+		}
+	}
+	return usecase.AuthUser{}, errors.New("record not found")
+}
+
+func TestWithUserID(t *testing.T) {
+	ip := stubIdentityProvider{key: []byte("local-test-key")}
+	other := stubIdentityProvider{key: []byte("some-other-key")}
+	libID := uuid.New()
+	admin := usecase.AuthUser{UID: "uid-admin", UserID: uuid.New(), GlobalRole: "ADMIN"}
+	admin.User = &usecase.User{
+		ID:     admin.UserID,
+		Staffs: []usecase.Staff{{LibraryID: libID, Role: usecase.StaffRoleAdmin}},
+	}
+	s := &Server{server: usecase.New(stubRepo{authUsers: []usecase.AuthUser{admin}}, ip, nil)}
+
+	tests := []struct {
+		name          string
+		header        string
+		status        int
+		authenticated bool
+	}{
+		{"valid token", "Bearer " + ip.sign(t, admin.UID, time.Now().Add(time.Hour)), 200, true},
+		{"missing token", "", 200, false},
+		{"not bearer", "Basic abc", 200, false},
+		{"expired token", "Bearer " + ip.sign(t, admin.UID, time.Now().Add(-time.Minute)), 401, false},
+		{"foreign key", "Bearer " + other.sign(t, admin.UID, time.Now().Add(time.Hour)), 401, false},
+		{"unknown uid", "Bearer " + ip.sign(t, "uid-nobody", time.Now().Add(time.Hour)), 401, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			resp := httptest.NewRecorder()
+			c := e.NewContext(req, resp)
+
+			var (
+				got Principal
+				ok  bool
+			)
+			err := s.WithUserID()(func(c echo.Context) error {
+				got, ok = GetPrincipal(c)
+				return c.NoContent(200)
+			})(c)
+			if err != nil {
+				t.Fatalf("middleware error = %v", err)
+			}
+			if resp.Code != tt.status {
+				t.Fatalf("status = %d, want %d", resp.Code, tt.status)
+			}
+			if ok != tt.authenticated {
+				t.Fatalf("authenticated = %v, want %v", ok, tt.authenticated)
+			}
+			if !ok {
+				return
+			}
+			if got.UID != admin.UID || got.UserID != admin.UserID || got.GlobalRole != admin.GlobalRole {
+				t.Errorf("principal = %+v, want %+v", got, admin)
+			}
+			if got.Staffs[libID] != usecase.StaffRoleAdmin {
+				t.Errorf("staff role = %q, want %q", got.Staffs[libID], usecase.StaffRoleAdmin)
+			}
+		})
+	}
+}
@@ -0,0 +1,402 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"librarease/internal/usecase"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// Access declares who may call a route.
+type Access struct {
+	// Public routes need no authentication.
+	Public bool
+	// Authenticated allows any signed-in caller.
+	Authenticated bool
+	// GlobalRoles may call the route for any library.
+	GlobalRoles []string
+	// StaffRoles may call the route within the library returned by Library.
+	StaffRoles []string
+	Library    resolver
+	// Owner returns the user the target resource belongs to, who may
+	// call the route as well.
+	Owner resolver
+	// IncludeDeleted, when set, is the access needed to list the
+	// soft-deleted records too with include_deleted=true.
+	IncludeDeleted *Access
+}
+
+// resolver looks up the id of the library or user a request targets.
+type resolver func(*Server, echo.Context) (uuid.UUID, error)
+
+// Allows reports whether the principal may call the route, given the
+// library and owner the request targets. Pass uuid.Nil when unresolved.
+func (a Access) Allows(p Principal, libraryID, ownerID uuid.UUID) bool {
+	switch {
+	case a.Public, a.Authenticated:
+		return true
+	case slices.Contains(a.GlobalRoles, p.GlobalRole):
+		return true
+	case ownerID != uuid.Nil && ownerID == p.UserID:
+		return true
+	}
+
+	if libraryID == uuid.Nil {
+		return false
+	}
+	role, ok := p.Staffs[libraryID]
+	return ok && slices.Contains(a.StaffRoles, role)
+}
+
+var (
+	superAdmins   = []string{usecase.GlobalRoleSuperAdmin}
+	admins        = []string{usecase.GlobalRoleSuperAdmin, usecase.GlobalRoleAdmin}
+	libraryAdmins = []string{usecase.StaffRoleAdmin}
+	libraryStaffs = []string{usecase.StaffRoleStaff, usecase.StaffRoleAdmin}
+)
+
+// deletedOfLibrary lets the staff of a library list its soft-deleted
+// records, which members do not see.
+var deletedOfLibrary = Access{GlobalRoles: admins, StaffRoles: libraryStaffs, Library: queryID("library_id")}
+
+// policies maps "METHOD path" of every /api/v1 route to its access.
+var policies = map[string]Access{
+	"GET /api/v1/users":              {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: queryID("library_id")},
+	"POST /api/v1/users":             {GlobalRoles: admins},
+	"GET /api/v1/users/me":           {Authenticated: true},
+	"GET /api/v1/users/:id":          {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: userLibrary, Owner: paramID},
+	"PUT /api/v1/users/:id":          {GlobalRoles: admins, Owner: paramID},
+	"DELETE /api/v1/users/:id":       {GlobalRoles: superAdmins},
+	"POST /api/v1/users/:id/restore": {GlobalRoles: superAdmins},
+	"DELETE /api/v1/users/:id/purge": {GlobalRoles: superAdmins},
+
+	"GET /api/v1/libraries":              {Authenticated: true, IncludeDeleted: &Access{GlobalRoles: admins}},
+	"POST /api/v1/libraries":             {GlobalRoles: admins},
+	"GET /api/v1/libraries/:id":          {Authenticated: true},
+	"PUT /api/v1/libraries/:id":          {GlobalRoles: admins, StaffRoles: libraryAdmins, Library: paramID},
+	"DELETE /api/v1/libraries/:id":       {GlobalRoles: admins},
+	"POST /api/v1/libraries/:id/restore": {GlobalRoles: admins},
+	"DELETE /api/v1/libraries/:id/purge": {GlobalRoles: admins},
+
+	"GET /api/v1/libraries/:id/settings": {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: paramID},
+	"PUT /api/v1/libraries/:id/settings": {GlobalRoles: admins, StaffRoles: libraryAdmins, Library: paramID},
+
+	"GET /api/v1/staffs":              {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: queryID("library_id")},
+	"POST /api/v1/staffs":             {GlobalRoles: admins, StaffRoles: libraryAdmins, Library: bodyID("library_id")},
+	"GET /api/v1/staffs/:id":          {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: staffLibrary},
+	"PUT /api/v1/staffs/:id":          {GlobalRoles: admins, StaffRoles: libraryAdmins, Library: staffLibrary},
+	"DELETE /api/v1/staffs/:id":       {GlobalRoles: admins, StaffRoles: libraryAdmins, Library: staffLibrary},
+	"POST /api/v1/staffs/:id/restore": {GlobalRoles: admins},
+	"DELETE /api/v1/staffs/:id/purge": {GlobalRoles: admins},
+
+	"GET /api/v1/memberships":              {Authenticated: true, IncludeDeleted: &deletedOfLibrary},
+	"POST /api/v1/memberships":             {GlobalRoles: admins, StaffRoles: libraryAdmins, Library: bodyID("library_id")},
+	"GET /api/v1/memberships/:id":          {Authenticated: true},
+	"PUT /api/v1/memberships/:id":          {GlobalRoles: admins, StaffRoles: libraryAdmins, Library: membershipLibrary},
+	"DELETE /api/v1/memberships/:id":       {GlobalRoles: admins, StaffRoles: libraryAdmins, Library: membershipLibrary},
+	"POST /api/v1/memberships/:id/restore": {GlobalRoles: admins},
+	"DELETE /api/v1/memberships/:id/purge": {GlobalRoles: admins},
+
+	"GET /api/v1/works":              {Authenticated: true, IncludeDeleted: &deletedOfLibrary},
+	"POST /api/v1/works":             {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: bodyID("library_id")},
+	"GET /api/v1/works/:id":          {Authenticated: true},
+	"PUT /api/v1/works/:id":          {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: workLibrary},
+	"DELETE /api/v1/works/:id":       {GlobalRoles: admins, StaffRoles: libraryAdmins, Library: workLibrary},
+	"POST /api/v1/works/:id/restore": {GlobalRoles: admins},
+	"DELETE /api/v1/works/:id/purge": {GlobalRoles: admins},
+
+	"GET /api/v1/subjects":        {Authenticated: true},
+	"POST /api/v1/subjects":       {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: bodyID("library_id")},
+	"GET /api/v1/subjects/:id":    {Authenticated: true},
+	"PUT /api/v1/subjects/:id":    {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: subjectLibrary},
+	"DELETE /api/v1/subjects/:id": {GlobalRoles: admins, StaffRoles: libraryAdmins, Library: subjectLibrary},
+
+	"GET /api/v1/books":              {Authenticated: true, IncludeDeleted: &deletedOfLibrary},
+	"POST /api/v1/books":             {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: bodyID("library_id")},
+	"POST /api/v1/books/import":      {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: queryID("library_id")},
+	"GET /api/v1/books/export":       {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: queryID("library_id")},
+	"GET /api/v1/books/:id":          {Authenticated: true},
+	"PUT /api/v1/books/:id":          {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: bookLibrary},
+	"DELETE /api/v1/books/:id":       {GlobalRoles: admins, StaffRoles: libraryAdmins, Library: bookLibrary},
+	"POST /api/v1/books/:id/restore": {GlobalRoles: admins},
+	"DELETE /api/v1/books/:id/purge": {GlobalRoles: admins},
+
+	"GET /api/v1/subscriptions":              {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: queryID("library_id")},
+	"POST /api/v1/subscriptions":             {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: bodyMembershipLibrary},
+	"GET /api/v1/subscriptions/:id":          {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: subscriptionLibrary, Owner: subscriptionOwner},
+	"PUT /api/v1/subscriptions/:id":          {GlobalRoles: admins, StaffRoles: libraryAdmins, Library: subscriptionLibrary},
+	"DELETE /api/v1/subscriptions/:id":       {GlobalRoles: admins, StaffRoles: libraryAdmins, Library: subscriptionLibrary},
+	"POST /api/v1/subscriptions/:id/restore": {GlobalRoles: admins},
+	"DELETE /api/v1/subscriptions/:id/purge": {GlobalRoles: admins},
+
+	"POST /api/v1/subscriptions/:id/renew":      {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: subscriptionLibrary},
+	"GET /api/v1/subscriptions/:id/renewals":    {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: subscriptionLibrary, Owner: subscriptionOwner},
+	"POST /api/v1/subscriptions/:id/suspend":    {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: subscriptionLibrary},
+	"POST /api/v1/subscriptions/:id/reinstate":  {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: subscriptionLibrary},
+	"GET /api/v1/subscriptions/:id/suspensions": {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: subscriptionLibrary, Owner: subscriptionOwner},
+
+	"GET /api/v1/borrowings":              {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: queryID("library_id")},
+	"POST /api/v1/borrowings":             {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: bodyBookLibrary},
+	"GET /api/v1/borrowings/:id":          {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: borrowingLibrary, Owner: borrowingOwner},
+	"PUT /api/v1/borrowings/:id":          {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: borrowingLibrary},
+	"DELETE /api/v1/borrowings/:id":       {GlobalRoles: admins, StaffRoles: libraryAdmins, Library: borrowingLibrary},
+	"POST /api/v1/borrowings/:id/restore": {GlobalRoles: admins},
+	"DELETE /api/v1/borrowings/:id/purge": {GlobalRoles: admins},
+	"POST /api/v1/borrowings/:id/return":  {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: borrowingLibrary},
+	"POST /api/v1/borrowings/:id/renew":   {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: borrowingLibrary, Owner: borrowingOwner},
+	"GET /api/v1/borrowings/:id/renewals": {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: borrowingLibrary, Owner: borrowingOwner},
+
+	"GET /api/v1/holds":             {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: queryID("library_id"), Owner: queryID("user_id")},
+	"POST /api/v1/holds":            {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: bodyBookLibrary, Owner: bodySubscriptionOwner},
+	"POST /api/v1/holds/expire":     {GlobalRoles: superAdmins},
+	"GET /api/v1/holds/:id":         {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: holdLibrary, Owner: holdOwner},
+	"POST /api/v1/holds/:id/cancel": {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: holdLibrary, Owner: holdOwner},
+
+	"GET /api/v1/fines":           {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: queryID("library_id"), Owner: queryID("user_id")},
+	"GET /api/v1/fines/balance":   {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: queryID("library_id"), Owner: queryID("user_id")},
+	"POST /api/v1/fines/payments": {GlobalRoles: admins, StaffRoles: libraryStaffs, Library: bodyID("library_id")},
+	"POST /api/v1/fines/waivers":  {GlobalRoles: admins, StaffRoles: libraryAdmins, Library: bodyID("library_id")},
+	"POST /api/v1/fines/accrue":   {GlobalRoles: superAdmins},
+
+	"POST /api/v1/auth/register": {Public: true},
+	"POST /api/v1/auth/login":    {Public: true},
+	"POST /api/v1/auth/refresh":  {Public: true},
+	"POST /api/v1/auth/logout":   {Public: true},
+}
+
+func paramID(_ *Server, c echo.Context) (uuid.UUID, error) {
+	return uuid.Parse(c.Param("id"))
+}
+
+// queryID reads a uuid query parameter.
+func queryID(name string) resolver {
+	return func(_ *Server, c echo.Context) (uuid.UUID, error) {
+		return uuid.Parse(c.QueryParam(name))
+	}
+}
+
+// bodyID reads a uuid field from the JSON body, leaving the body intact
+// for the handler to bind.
+func bodyID(field string) resolver {
+	return func(_ *Server, c echo.Context) (uuid.UUID, error) {
+		b, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		c.Request().Body = io.NopCloser(bytes.NewReader(b))
+
+		var m map[string]interface{}
+		if err := json.Unmarshal(b, &m); err != nil {
+			return uuid.Nil, err
+		}
+		v, ok := m[field].(string)
+		if !ok {
+			return uuid.Nil, fmt.Errorf("%s is required", field)
+		}
+		return uuid.Parse(v)
+	}
+}
+
+// userLibrary returns a library the caller is staff of where the user is
+// subscribed or staff, so staff only see the users of their libraries.
+func userLibrary(s *Server, c echo.Context) (uuid.UUID, error) {
+	id, err := paramID(s, c)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	p, _ := GetPrincipal(c)
+	for libraryID := range p.Staffs {
+		_, n, err := s.server.ListUsers(c.Request().Context(), usecase.ListUsersOption{
+			Limit:     1,
+			IDs:       uuid.UUIDs{id},
+			LibraryID: libraryID.String(),
+		})
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if n > 0 {
+			return libraryID, nil
+		}
+	}
+	return uuid.Nil, fmt.Errorf("user %s is not in the caller's libraries", id)
+}
+
+func staffLibrary(s *Server, c echo.Context) (uuid.UUID, error) {
+	st, err := s.server.GetStaffByID(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return st.LibraryID, nil
+}
+
+func membershipLibrary(s *Server, c echo.Context) (uuid.UUID, error) {
+	m, err := s.server.GetMembershipByID(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return m.LibraryID, nil
+}
+
+func bodyMembershipLibrary(s *Server, c echo.Context) (uuid.UUID, error) {
+	id, err := bodyID("membership_id")(s, c)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	m, err := s.server.GetMembershipByID(c.Request().Context(), id.String())
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return m.LibraryID, nil
+}
+
+func workLibrary(s *Server, c echo.Context) (uuid.UUID, error) {
+	id, err := paramID(s, c)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	w, err := s.server.GetWorkByID(c.Request().Context(), id)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return w.LibraryID, nil
+}
+
+func subjectLibrary(s *Server, c echo.Context) (uuid.UUID, error) {
+	id, err := paramID(s, c)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	sub, err := s.server.GetSubjectByID(c.Request().Context(), id)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return sub.LibraryID, nil
+}
+
+func bookLibrary(s *Server, c echo.Context) (uuid.UUID, error) {
+	id, err := paramID(s, c)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	b, err := s.server.GetBookByID(c.Request().Context(), id)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return b.LibraryID, nil
+}
+
+func bodyBookLibrary(s *Server, c echo.Context) (uuid.UUID, error) {
+	id, err := bodyID("book_id")(s, c)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	b, err := s.server.GetBookByID(c.Request().Context(), id)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return b.LibraryID, nil
+}
+
+func subscriptionLibrary(s *Server, c echo.Context) (uuid.UUID, error) {
+	id, err := paramID(s, c)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	sub, err := s.server.GetSubscriptionByID(c.Request().Context(), id)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if sub.Membership == nil {
+		return uuid.Nil, fmt.Errorf("subscription %s has no membership", id)
+	}
+	return sub.Membership.LibraryID, nil
+}
+
+func subscriptionOwner(s *Server, c echo.Context) (uuid.UUID, error) {
+	id, err := paramID(s, c)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	sub, err := s.server.GetSubscriptionByID(c.Request().Context(), id)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return sub.UserID, nil
+}
+
+func borrowingLibrary(s *Server, c echo.Context) (uuid.UUID, error) {
+	id, err := paramID(s, c)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	b, err := s.server.GetBorrowingByID(c.Request().Context(), id)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if b.Book == nil {
+		return uuid.Nil, fmt.Errorf("borrowing %s has no book", id)
+	}
+	return b.Book.LibraryID, nil
+}
+
+func borrowingOwner(s *Server, c echo.Context) (uuid.UUID, error) {
+	id, err := paramID(s, c)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	b, err := s.server.GetBorrowingByID(c.Request().Context(), id)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if b.Subscription == nil {
+		return uuid.Nil, fmt.Errorf("borrowing %s has no subscription", id)
+	}
+	return b.Subscription.UserID, nil
+}
+
+func bodySubscriptionOwner(s *Server, c echo.Context) (uuid.UUID, error) {
+	id, err := bodyID("subscription_id")(s, c)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	sub, err := s.server.GetSubscriptionByID(c.Request().Context(), id)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return sub.UserID, nil
+}
+
+func holdLibrary(s *Server, c echo.Context) (uuid.UUID, error) {
+	id, err := paramID(s, c)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	h, err := s.server.GetHoldByID(c.Request().Context(), id)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if h.Book == nil {
+		return uuid.Nil, fmt.Errorf("hold %s has no book", id)
+	}
+	return h.Book.LibraryID, nil
+}
+
+func holdOwner(s *Server, c echo.Context) (uuid.UUID, error) {
+	id, err := paramID(s, c)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	h, err := s.server.GetHoldByID(c.Request().Context(), id)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if h.Subscription == nil {
+		return uuid.Nil, fmt.Errorf("hold %s has no subscription", id)
+	}
+	return h.Subscription.UserID, nil
+}
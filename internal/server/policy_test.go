@@ -0,0 +1,378 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"librarease/internal/config"
+	"librarease/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+func TestPoliciesCoverRoutes(t *testing.T) {
+	s := &Server{}
+	e := s.RegisterRoutes().(*echo.Echo)
+
+	registered := make(map[string]bool)
+	for _, r := range e.Routes() {
+		if r.Method == echo.RouteNotFound || !strings.HasPrefix(r.Path, "/api/v1/") {
+			continue
+		}
+		key := r.Method + " " + r.Path
+		registered[key] = true
+		if _, ok := policies[key]; !ok {
+			t.Errorf("route %s has no policy", key)
+		}
+	}
+	for key := range policies {
+		if !registered[key] {
+			t.Errorf("policy %s has no route", key)
+		}
+	}
+}
+
+func TestAccessAllows(t *testing.T) {
+	libA, libB := uuid.New(), uuid.New()
+	user := Principal{UserID: uuid.New(), GlobalRole: usecase.GlobalRoleUser}
+	admin := Principal{UserID: uuid.New(), GlobalRole: usecase.GlobalRoleAdmin}
+	superAdmin := Principal{UserID: uuid.New(), GlobalRole: usecase.GlobalRoleSuperAdmin}
+	staffA := Principal{
+		UserID:     uuid.New(),
+		GlobalRole: usecase.GlobalRoleUser,
+		Staffs:     map[uuid.UUID]string{libA: usecase.StaffRoleStaff},
+	}
+	adminA := Principal{
+		UserID:     uuid.New(),
+		GlobalRole: usecase.GlobalRoleUser,
+		Staffs:     map[uuid.UUID]string{libA: usecase.StaffRoleAdmin},
+	}
+
+	tests := []struct {
+		route     string
+		principal Principal
+		library   uuid.UUID
+		owner     uuid.UUID
+		want      bool
+	}{
+		{"POST /api/v1/libraries", user, uuid.Nil, uuid.Nil, false},
+		{"POST /api/v1/libraries", admin, uuid.Nil, uuid.Nil, true},
+		{"DELETE /api/v1/users/:id", admin, uuid.Nil, uuid.Nil, false},
+		{"DELETE /api/v1/users/:id", superAdmin, uuid.Nil, uuid.Nil, true},
+		{"PUT /api/v1/users/:id", user, uuid.Nil, user.UserID, true},
+		{"PUT /api/v1/users/:id", user, uuid.Nil, uuid.New(), false},
+		{"GET /api/v1/users", user, uuid.Nil, uuid.Nil, false},
+		{"GET /api/v1/users", staffA, libA, uuid.Nil, true},
+		{"GET /api/v1/users", staffA, libB, uuid.Nil, false},
+		{"GET /api/v1/users", staffA, uuid.Nil, uuid.Nil, false},
+		{"GET /api/v1/users", admin, uuid.Nil, uuid.Nil, true},
+		{"POST /api/v1/users", staffA, uuid.Nil, uuid.Nil, false},
+		{"POST /api/v1/users", admin, uuid.Nil, uuid.Nil, true},
+		{"PUT /api/v1/memberships/:id", adminA, libA, uuid.Nil, true},
+		{"PUT /api/v1/memberships/:id", adminA, libB, uuid.Nil, false},
+		{"PUT /api/v1/memberships/:id", staffA, libA, uuid.Nil, false},
+		{"POST /api/v1/borrowings", staffA, libA, uuid.Nil, true},
+		{"POST /api/v1/borrowings", staffA, libB, uuid.Nil, false},
+		{"POST /api/v1/borrowings", user, uuid.Nil, uuid.Nil, false},
+		{"GET /api/v1/borrowings/:id", user, libA, user.UserID, true},
+		{"GET /api/v1/borrowings/:id", user, libA, uuid.New(), false},
+		{"GET /api/v1/books", user, uuid.Nil, uuid.Nil, true},
+		{"GET /api/v1/fines/balance", user, libA, user.UserID, true},
+		{"GET /api/v1/fines/balance", staffA, libB, uuid.New(), false},
+		{"POST /api/v1/fines/waivers", staffA, libA, uuid.Nil, false},
+		{"POST /api/v1/fines/waivers", adminA, libA, uuid.Nil, true},
+		// staff only see the records of their own library
+		{"GET /api/v1/borrowings", staffA, libA, uuid.Nil, true},
+		{"GET /api/v1/borrowings", staffA, libB, uuid.Nil, false},
+		{"GET /api/v1/borrowings", staffA, uuid.Nil, uuid.Nil, false},
+		{"GET /api/v1/borrowings", admin, uuid.Nil, uuid.Nil, true},
+		{"GET /api/v1/subscriptions", staffA, libA, uuid.Nil, true},
+		{"GET /api/v1/subscriptions", staffA, libB, uuid.Nil, false},
+		{"GET /api/v1/subscriptions", staffA, uuid.Nil, uuid.Nil, false},
+		{"GET /api/v1/holds", staffA, libA, uuid.Nil, true},
+		{"GET /api/v1/holds", staffA, libB, uuid.Nil, false},
+		{"GET /api/v1/holds", user, uuid.Nil, user.UserID, true},
+		{"GET /api/v1/staffs", staffA, libA, uuid.Nil, true},
+		{"GET /api/v1/staffs", staffA, libB, uuid.Nil, false},
+		{"GET /api/v1/staffs/:id", staffA, libA, uuid.Nil, true},
+		{"GET /api/v1/staffs/:id", staffA, libB, uuid.Nil, false},
+	}
+
+	for _, tt := range tests {
+		a, ok := policies[tt.route]
+		if !ok {
+			t.Fatalf("no policy for %s", tt.route)
+		}
+		if got := a.Allows(tt.principal, tt.library, tt.owner); got != tt.want {
+			t.Errorf("%s by %+v (library %s) = %v, want %v", tt.route, tt.principal, tt.library, got, tt.want)
+		}
+	}
+}
+
+// stubService serves memberships, staffs and the users of libraries from
+// memory; any other call panics.
+type stubService struct {
+	Service
+	memberships map[string]usecase.Membership
+	staffs      []usecase.Staff
+	members     map[uuid.UUID]uuid.UUIDs
+}
+
+func (s stubService) ListUsers(_ context.Context, opt usecase.ListUsersOption) ([]usecase.User, int, error) {
+	var list []usecase.User
+	for _, id := range s.members[uuid.MustParse(opt.LibraryID)] {
+		if slices.Contains(opt.IDs, id) {
+			list = append(list, usecase.User{ID: id})
+		}
+	}
+	return list, len(list), nil
+}
+
+func (s stubService) ListStaffs(_ context.Context, opt usecase.ListStaffsOption) ([]usecase.Staff, int, error) {
+	var list []usecase.Staff
+	for _, st := range s.staffs {
+		if st.LibraryID.String() == opt.LibraryID && st.UserID.String() == opt.UserID {
+			list = append(list, st)
+		}
+	}
+	return list, len(list), nil
+}
+
+func (s stubService) GetMembershipByID(_ context.Context, id string) (usecase.Membership, error) {
+	m, ok := s.memberships[id]
+	if !ok {
+		return usecase.Membership{}, errors.New("record not found")
+	}
+	return m, nil
+}
+
+func TestAuthorize(t *testing.T) {
+	libA, libB := uuid.New(), uuid.New()
+	memA := usecase.Membership{ID: uuid.New(), LibraryID: libA}
+	memB := usecase.Membership{ID: uuid.New(), LibraryID: libB}
+	s := &Server{server: stubService{memberships: map[string]usecase.Membership{
+		memA.ID.String(): memA,
+		memB.ID.String(): memB,
+	}}}
+	adminA := Principal{
+		UserID:     uuid.New(),
+		GlobalRole: usecase.GlobalRoleUser,
+		Staffs:     map[uuid.UUID]string{libA: usecase.StaffRoleAdmin},
+	}
+
+	tests := []struct {
+		name      string
+		principal *Principal
+		target    uuid.UUID
+		status    int
+	}{
+		{"anonymous", nil, memA.ID, 401},
+		{"admin of library", &adminA, memA.ID, 204},
+		{"admin of other library", &adminA, memB.ID, 403},
+		{"unknown membership", &adminA, uuid.New(), 403},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			withPrincipal := func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					if tt.principal != nil {
+						c.Set(config.CTX_KEY_PRINCIPAL, *tt.principal)
+					}
+					return next(c)
+				}
+			}
+			e.PUT("/api/v1/memberships/:id", func(c echo.Context) error {
+				return c.NoContent(204)
+			}, withPrincipal, s.Authorize())
+
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/memberships/"+tt.target.String(), nil)
+			resp := httptest.NewRecorder()
+			e.ServeHTTP(resp, req)
+
+			if resp.Code != tt.status {
+				t.Errorf("status = %d, want %d", resp.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestAuthorizeIncludeDeleted(t *testing.T) {
+	libA := uuid.New()
+	s := &Server{}
+	member := Principal{UserID: uuid.New(), GlobalRole: usecase.GlobalRoleUser}
+	staffA := Principal{
+		UserID:     uuid.New(),
+		GlobalRole: usecase.GlobalRoleUser,
+		Staffs:     map[uuid.UUID]string{libA: usecase.StaffRoleStaff},
+	}
+	admin := Principal{UserID: uuid.New(), GlobalRole: usecase.GlobalRoleAdmin}
+
+	tests := []struct {
+		name      string
+		path      string
+		principal Principal
+		status    int
+	}{
+		{"member", "/api/v1/books?library_id=" + libA.String(), member, 204},
+		{"member with deleted", "/api/v1/books?include_deleted=true&library_id=" + libA.String(), member, 403},
+		{"staff with deleted", "/api/v1/books?include_deleted=true&library_id=" + libA.String(), staffA, 204},
+		{"staff with deleted of all libraries", "/api/v1/books?include_deleted=true", staffA, 403},
+		{"member with deleted libraries", "/api/v1/libraries?include_deleted=true", member, 403},
+		{"staff with deleted libraries", "/api/v1/libraries?include_deleted=true", staffA, 403},
+		{"admin with deleted libraries", "/api/v1/libraries?include_deleted=true", admin, 204},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			withPrincipal := func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					c.Set(config.CTX_KEY_PRINCIPAL, tt.principal)
+					return next(c)
+				}
+			}
+			ok := func(c echo.Context) error { return c.NoContent(204) }
+			e.GET("/api/v1/books", ok, withPrincipal, s.Authorize())
+			e.GET("/api/v1/libraries", ok, withPrincipal, s.Authorize())
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			resp := httptest.NewRecorder()
+			e.ServeHTTP(resp, req)
+
+			if resp.Code != tt.status {
+				t.Errorf("status = %d, want %d", resp.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestAuthorizeUserOfLibrary(t *testing.T) {
+	libA, libB := uuid.New(), uuid.New()
+	memberA, memberB := uuid.New(), uuid.New()
+	s := &Server{server: stubService{members: map[uuid.UUID]uuid.UUIDs{
+		libA: {memberA},
+		libB: {memberB},
+	}}}
+	staffA := Principal{
+		UserID:     uuid.New(),
+		GlobalRole: usecase.GlobalRoleUser,
+		Staffs:     map[uuid.UUID]string{libA: usecase.StaffRoleStaff},
+	}
+
+	tests := []struct {
+		name   string
+		target uuid.UUID
+		status int
+	}{
+		{"member of library", memberA, 204},
+		{"member of other library", memberB, 403},
+		{"unknown user", uuid.New(), 403},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			withPrincipal := func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					c.Set(config.CTX_KEY_PRINCIPAL, staffA)
+					return next(c)
+				}
+			}
+			e.GET("/api/v1/users/:id", func(c echo.Context) error {
+				return c.NoContent(204)
+			}, withPrincipal, s.Authorize())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/users/"+tt.target.String(), nil)
+			resp := httptest.NewRecorder()
+			e.ServeHTTP(resp, req)
+
+			if resp.Code != tt.status {
+				t.Errorf("status = %d, want %d", resp.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestCallerStaffID(t *testing.T) {
+	libA, libB := uuid.New(), uuid.New()
+	memA := usecase.Membership{ID: uuid.New(), LibraryID: libA}
+	memB := usecase.Membership{ID: uuid.New(), LibraryID: libB}
+	staffA := usecase.Staff{ID: uuid.New(), LibraryID: libA, UserID: uuid.New()}
+	s := &Server{server: stubService{
+		memberships: map[string]usecase.Membership{
+			memA.ID.String(): memA,
+			memB.ID.String(): memB,
+		},
+		staffs: []usecase.Staff{staffA},
+	}}
+	p := Principal{UserID: staffA.UserID, GlobalRole: usecase.GlobalRoleUser}
+
+	tests := []struct {
+		name   string
+		target uuid.UUID
+		want   uuid.UUID
+		err    error
+	}{
+		{"staff of library", memA.ID, staffA.ID, nil},
+		{"not staff of library", memB.ID, uuid.Nil, usecase.ErrStaffNotInLibrary},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/", nil), httptest.NewRecorder())
+			c.SetParamNames("id")
+			c.SetParamValues(tt.target.String())
+			c.Set(config.CTX_KEY_PRINCIPAL, p)
+
+			got, err := s.callerStaffID(c, membershipLibrary)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("staff = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallerStaffOrNil(t *testing.T) {
+	libA := uuid.New()
+	staffA := usecase.Staff{ID: uuid.New(), LibraryID: libA, UserID: uuid.New()}
+	s := &Server{server: stubService{staffs: []usecase.Staff{staffA}}}
+
+	tests := []struct {
+		name      string
+		principal Principal
+		want      *uuid.UUID
+	}{
+		{"member", Principal{UserID: uuid.New(), GlobalRole: usecase.GlobalRoleUser}, nil},
+		{"staff of library", Principal{
+			UserID:     staffA.UserID,
+			GlobalRole: usecase.GlobalRoleUser,
+			Staffs:     map[uuid.UUID]string{libA: usecase.StaffRoleStaff},
+		}, &staffA.ID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/", nil), httptest.NewRecorder())
+			c.Set(config.CTX_KEY_PRINCIPAL, tt.principal)
+
+			got, err := s.callerStaffOrNil(c, libA)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+				t.Errorf("staff = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
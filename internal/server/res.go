@@ -4,6 +4,8 @@ type Meta struct {
 	Total int `json:"total"`
 	Skip  int `json:"skip"`
 	Limit int `json:"limit"`
+	// Facets are returned by lists asked for them.
+	Facets []Facet `json:"facets,omitempty"`
 }
 
 type Res struct {
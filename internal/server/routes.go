@@ -9,6 +9,7 @@ import (
 
 func (s *Server) RegisterRoutes() http.Handler {
 	e := echo.New()
+	e.HTTPErrorHandler = s.HTTPErrorHandler
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
 
@@ -26,54 +27,120 @@ func (s *Server) RegisterRoutes() http.Handler {
 
 	e.GET("/websocket", s.websocketHandler)
 
-	var userGroup = e.Group("/api/v1/users")
+	// Every /api/v1 route must declare its access in policies.
+	var api = e.Group("/api/v1", s.WithUserID(), s.Authorize())
+
+	var userGroup = api.Group("/users")
 	userGroup.GET("", s.ListUsers)
 	userGroup.POST("", s.CreateUser)
 	userGroup.GET("/:id", s.GetUserByID)
 	userGroup.PUT("/:id", s.UpdateUser)
 	userGroup.DELETE("/:id", s.DeleteUser)
-	userGroup.GET("/me", s.GetMe, WithUserID())
+	userGroup.POST("/:id/restore", s.RestoreUser)
+	userGroup.DELETE("/:id/purge", s.PurgeUser)
+	userGroup.GET("/me", s.GetMe)
 
-	var libraryGroup = e.Group("/api/v1/libraries")
+	var libraryGroup = api.Group("/libraries")
 	libraryGroup.GET("", s.ListLibraries)
 	libraryGroup.POST("", s.CreateLibrary)
 	libraryGroup.GET("/:id", s.GetLibraryByID)
 	libraryGroup.PUT("/:id", s.UpdateLibrary)
 	libraryGroup.DELETE("/:id", s.DeleteLibrary)
+	libraryGroup.POST("/:id/restore", s.RestoreLibrary)
+	libraryGroup.DELETE("/:id/purge", s.PurgeLibrary)
+	libraryGroup.GET("/:id/settings", s.GetSetting)
+	libraryGroup.PUT("/:id/settings", s.UpdateSetting)
 
-	var staffGroup = e.Group("/api/v1/staffs")
+	var staffGroup = api.Group("/staffs")
 	staffGroup.GET("", s.ListStaffs)
 	staffGroup.POST("", s.CreateStaff)
 	staffGroup.GET("/:id", s.GetStaffByID)
 	staffGroup.PUT("/:id", s.UpdateStaff)
+	staffGroup.DELETE("/:id", s.DeleteStaff)
+	staffGroup.POST("/:id/restore", s.RestoreStaff)
+	staffGroup.DELETE("/:id/purge", s.PurgeStaff)
 
-	var membershipGroup = e.Group("/api/v1/memberships")
+	var membershipGroup = api.Group("/memberships")
 	membershipGroup.GET("", s.ListMemberships)
 	membershipGroup.POST("", s.CreateMembership)
 	membershipGroup.GET("/:id", s.GetMembershipByID)
 	membershipGroup.PUT("/:id", s.UpdateMembership)
-	// membershipGroup.DELETE("/:id", s.DeleteMembership)
-
-	var bookGroup = e.Group("/api/v1/books")
+	membershipGroup.DELETE("/:id", s.DeleteMembership)
+	membershipGroup.POST("/:id/restore", s.RestoreMembership)
+	membershipGroup.DELETE("/:id/purge", s.PurgeMembership)
+
+	var workGroup = api.Group("/works")
+	workGroup.GET("", s.ListWorks)
+	workGroup.POST("", s.CreateWork)
+	workGroup.GET("/:id", s.GetWorkByID)
+	workGroup.PUT("/:id", s.UpdateWork)
+	workGroup.DELETE("/:id", s.DeleteWork)
+	workGroup.POST("/:id/restore", s.RestoreWork)
+	workGroup.DELETE("/:id/purge", s.PurgeWork)
+
+	var subjectGroup = api.Group("/subjects")
+	subjectGroup.GET("", s.ListSubjects)
+	subjectGroup.POST("", s.CreateSubject)
+	subjectGroup.GET("/:id", s.GetSubjectByID)
+	subjectGroup.PUT("/:id", s.UpdateSubject)
+	subjectGroup.DELETE("/:id", s.DeleteSubject)
+
+	var bookGroup = api.Group("/books")
 	bookGroup.GET("", s.ListBooks)
 	bookGroup.POST("", s.CreateBook)
+	bookGroup.POST("/import", s.ImportBooks)
+	bookGroup.GET("/export", s.ExportBooks)
 	bookGroup.GET("/:id", s.GetBookByID)
 	bookGroup.PUT("/:id", s.UpdateBook)
+	bookGroup.DELETE("/:id", s.DeleteBook)
+	bookGroup.POST("/:id/restore", s.RestoreBook)
+	bookGroup.DELETE("/:id/purge", s.PurgeBook)
 
-	var subscriptionGroup = e.Group("/api/v1/subscriptions")
+	var subscriptionGroup = api.Group("/subscriptions")
 	subscriptionGroup.GET("", s.ListSubscriptions)
 	subscriptionGroup.POST("", s.CreateSubscription)
 	subscriptionGroup.GET("/:id", s.GetSubscriptionByID)
 	subscriptionGroup.PUT("/:id", s.UpdateSubscription)
-
-	var borrowingGroup = e.Group("/api/v1/borrowings")
+	subscriptionGroup.DELETE("/:id", s.DeleteSubscription)
+	subscriptionGroup.POST("/:id/restore", s.RestoreSubscription)
+	subscriptionGroup.DELETE("/:id/purge", s.PurgeSubscription)
+	subscriptionGroup.POST("/:id/renew", s.RenewSubscription)
+	subscriptionGroup.GET("/:id/renewals", s.ListSubscriptionRenewals)
+	subscriptionGroup.POST("/:id/suspend", s.SuspendSubscription)
+	subscriptionGroup.POST("/:id/reinstate", s.ReinstateSubscription)
+	subscriptionGroup.GET("/:id/suspensions", s.ListSubscriptionSuspensions)
+
+	var borrowingGroup = api.Group("/borrowings")
 	borrowingGroup.GET("", s.ListBorrowings)
 	borrowingGroup.POST("", s.CreateBorrowing)
 	borrowingGroup.GET("/:id", s.GetBorrowingByID)
 	borrowingGroup.PUT("/:id", s.UpdateBorrowing)
-
-	var authGroup = e.Group("/api/v1/auth")
+	borrowingGroup.DELETE("/:id", s.DeleteBorrowing)
+	borrowingGroup.POST("/:id/restore", s.RestoreBorrowing)
+	borrowingGroup.DELETE("/:id/purge", s.PurgeBorrowing)
+	borrowingGroup.POST("/:id/return", s.ReturnBorrowing)
+	borrowingGroup.POST("/:id/renew", s.RenewBorrowing)
+	borrowingGroup.GET("/:id/renewals", s.ListBorrowingRenewals)
+
+	var holdGroup = api.Group("/holds")
+	holdGroup.GET("", s.ListHolds)
+	holdGroup.POST("", s.PlaceHold)
+	holdGroup.POST("/expire", s.ExpireHolds)
+	holdGroup.GET("/:id", s.GetHoldByID)
+	holdGroup.POST("/:id/cancel", s.CancelHold)
+
+	var fineGroup = api.Group("/fines")
+	fineGroup.GET("", s.ListFineEntries)
+	fineGroup.GET("/balance", s.GetFineBalance)
+	fineGroup.POST("/payments", s.PayFine)
+	fineGroup.POST("/waivers", s.WaiveFine)
+	fineGroup.POST("/accrue", s.AccrueFines)
+
+	var authGroup = api.Group("/auth")
 	authGroup.POST("/register", s.RegisterUser)
+	authGroup.POST("/login", s.Login)
+	authGroup.POST("/refresh", s.RefreshToken)
+	authGroup.POST("/logout", s.Logout)
 
 	return e
 }
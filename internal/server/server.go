@@ -2,7 +2,9 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
 	"strconv"
@@ -12,8 +14,12 @@ import (
 	"github.com/google/uuid"
 	_ "github.com/joho/godotenv/autoload"
 
+	"librarease/internal/config"
 	"librarease/internal/database"
 	"librarease/internal/firebase"
+	"librarease/internal/localauth"
+	"librarease/internal/notify"
+	"librarease/internal/scheduler"
 	"librarease/internal/usecase"
 )
 
@@ -34,40 +40,103 @@ type Service interface {
 	CreateUser(context.Context, usecase.User) (usecase.User, error)
 	UpdateUser(context.Context, usecase.User) (usecase.User, error)
 	DeleteUser(context.Context, string) error
+	RestoreUser(context.Context, uuid.UUID) error
+	PurgeUser(context.Context, uuid.UUID) error
 
 	ListLibraries(context.Context, usecase.ListLibrariesOption) ([]usecase.Library, int, error)
 	GetLibraryByID(context.Context, string) (usecase.Library, error)
 	CreateLibrary(context.Context, usecase.Library) (usecase.Library, error)
 	UpdateLibrary(context.Context, usecase.Library) (usecase.Library, error)
 	DeleteLibrary(context.Context, string) error
+	RestoreLibrary(context.Context, uuid.UUID) error
+	PurgeLibrary(context.Context, uuid.UUID) error
 
 	ListStaffs(context.Context, usecase.ListStaffsOption) ([]usecase.Staff, int, error)
 	CreateStaff(context.Context, usecase.Staff) (usecase.Staff, error)
 	GetStaffByID(context.Context, string) (usecase.Staff, error)
 	UpdateStaff(context.Context, usecase.Staff) (usecase.Staff, error)
+	DeleteStaff(context.Context, uuid.UUID) error
+	RestoreStaff(context.Context, uuid.UUID) error
+	PurgeStaff(context.Context, uuid.UUID) error
+
+	ListWorks(context.Context, usecase.ListWorksOption) ([]usecase.Work, int, error)
+	GetWorkByID(context.Context, uuid.UUID) (usecase.Work, error)
+	CreateWork(context.Context, usecase.Work) (usecase.Work, error)
+	UpdateWork(context.Context, usecase.Work) (usecase.Work, error)
+	DeleteWork(context.Context, uuid.UUID) error
+	RestoreWork(context.Context, uuid.UUID) error
+	PurgeWork(context.Context, uuid.UUID) error
+
+	ListSubjects(context.Context, usecase.ListSubjectsOption) ([]usecase.Subject, int, error)
+	GetSubjectByID(context.Context, uuid.UUID) (usecase.Subject, error)
+	CreateSubject(context.Context, usecase.Subject) (usecase.Subject, error)
+	UpdateSubject(context.Context, usecase.Subject) (usecase.Subject, error)
+	DeleteSubject(context.Context, uuid.UUID) error
 
 	ListBooks(context.Context, usecase.ListBooksOption) ([]usecase.Book, int, error)
 	GetBookByID(context.Context, uuid.UUID) (usecase.Book, error)
 	CreateBook(context.Context, usecase.Book) (usecase.Book, error)
 	UpdateBook(context.Context, usecase.Book) (usecase.Book, error)
+	ExportBooks(context.Context, usecase.ListBooksOption, func(usecase.Book) error) error
+	ImportBooks(context.Context, usecase.ImportBooksOption, []usecase.ImportRow) (usecase.ImportResult, error)
+	BookFacets(context.Context, usecase.ListBooksOption) ([]usecase.Facet, error)
+	DeleteBook(context.Context, uuid.UUID) error
+	RestoreBook(context.Context, uuid.UUID) error
+	PurgeBook(context.Context, uuid.UUID) error
 
 	ListMemberships(context.Context, usecase.ListMembershipsOption) ([]usecase.Membership, int, error)
 	GetMembershipByID(context.Context, string) (usecase.Membership, error)
 	CreateMembership(context.Context, usecase.Membership) (usecase.Membership, error)
 	UpdateMembership(context.Context, usecase.Membership) (usecase.Membership, error)
-	// DeleteMembership(context.Context, string) error
+	DeleteMembership(context.Context, uuid.UUID) error
+	RestoreMembership(context.Context, uuid.UUID) error
+	PurgeMembership(context.Context, uuid.UUID) error
 
 	ListSubscriptions(context.Context, usecase.ListSubscriptionsOption) ([]usecase.Subscription, int, error)
 	GetSubscriptionByID(context.Context, uuid.UUID) (usecase.Subscription, error)
 	CreateSubscription(context.Context, usecase.Subscription) (usecase.Subscription, error)
 	UpdateSubscription(context.Context, usecase.Subscription) (usecase.Subscription, error)
+	RenewSubscription(context.Context, usecase.RenewSubscriptionOption) (usecase.Subscription, error)
+	ListSubscriptionRenewals(context.Context, uuid.UUID) ([]usecase.SubscriptionRenewal, error)
+	SuspendSubscription(context.Context, usecase.SuspendSubscriptionOption) (usecase.Subscription, error)
+	ReinstateSubscription(context.Context, usecase.ReinstateSubscriptionOption) (usecase.Subscription, error)
+	ListSubscriptionSuspensions(context.Context, uuid.UUID) ([]usecase.SubscriptionSuspension, error)
+	DeleteSubscription(context.Context, uuid.UUID) error
+	RestoreSubscription(context.Context, uuid.UUID) error
+	PurgeSubscription(context.Context, uuid.UUID) error
 
 	ListBorrowings(context.Context, usecase.ListBorrowingsOption) ([]usecase.Borrowing, int, error)
 	GetBorrowingByID(context.Context, uuid.UUID) (usecase.Borrowing, error)
 	CreateBorrowing(context.Context, usecase.Borrowing) (usecase.Borrowing, error)
 	UpdateBorrowing(context.Context, usecase.Borrowing) (usecase.Borrowing, error)
+	ReturnBorrowing(context.Context, uuid.UUID, uuid.UUID) (usecase.Borrowing, error)
+	RenewBorrowing(context.Context, uuid.UUID, *uuid.UUID) (usecase.Borrowing, error)
+	ListBorrowingRenewals(context.Context, uuid.UUID) ([]usecase.BorrowingRenewal, error)
+	DeleteBorrowing(context.Context, uuid.UUID) error
+	RestoreBorrowing(context.Context, uuid.UUID) error
+	PurgeBorrowing(context.Context, uuid.UUID) error
+
+	ListFineEntries(context.Context, usecase.ListFineEntriesOption) ([]usecase.FineEntry, int, error)
+	GetFineBalance(context.Context, uuid.UUID, uuid.UUID) (usecase.FineBalance, error)
+	PayFine(context.Context, usecase.FineEntry) (usecase.FineEntry, error)
+	WaiveFine(context.Context, usecase.FineEntry) (usecase.FineEntry, error)
+	AccrueFines(context.Context) (int, error)
+
+	ListHolds(context.Context, usecase.ListHoldsOption) ([]usecase.Hold, int, error)
+	GetHoldByID(context.Context, uuid.UUID) (usecase.Hold, error)
+	PlaceHold(context.Context, usecase.Hold) (usecase.Hold, error)
+	CancelHold(context.Context, uuid.UUID) (usecase.Hold, error)
+	ExpireHolds(context.Context) (int, error)
+
+	GetSetting(context.Context, uuid.UUID) (usecase.Setting, error)
+	UpdateSetting(context.Context, usecase.Setting) (usecase.Setting, error)
 
 	RegisterUser(context.Context, usecase.RegisterUser) (usecase.User, error)
+	VerifyIDToken(context.Context, string) (usecase.AuthUser, error)
+	GetAuthUserByUserID(context.Context, uuid.UUID) (usecase.AuthUser, error)
+	Login(context.Context, string, string) (usecase.Session, error)
+	RefreshSession(context.Context, string) (usecase.Session, error)
+	Logout(context.Context, string) error
 }
 
 type Server struct {
@@ -77,11 +146,22 @@ type Server struct {
 	validator *validator.Validate
 }
 
-func NewServer() *http.Server {
+func NewServer() (*http.Server, error) {
 	repo := database.New()
-	fb := firebase.New()
-	sv := usecase.New(repo, fb)
-	v := validator.New()
+
+	var ip usecase.IdentityProvider
+	switch os.Getenv(config.ENV_KEY_IDENTITY_PROVIDER) {
+	case config.IDENTITY_PROVIDER_LOCAL:
+		la, err := localauth.New(repo, os.Getenv(config.ENV_KEY_LOCAL_AUTH_SECRET))
+		if err != nil {
+			return nil, err
+		}
+		ip = la
+	default:
+		ip = firebase.New()
+	}
+	sv := usecase.New(repo, ip, notify.New())
+	v := newValidator()
 
 	port, _ := strconv.Atoi(os.Getenv("PORT"))
 	NewServer := &Server{
@@ -99,5 +179,87 @@ func NewServer() *http.Server {
 		WriteTimeout: 30 * time.Second,
 	}
 
-	return server
+	ctx, cancel := context.WithCancel(context.Background())
+	server.RegisterOnShutdown(cancel)
+	go newScheduler(repo, sv).Run(ctx)
+
+	return server, nil
+}
+
+// schedulerLockID is the key of the advisory lock electing the replica
+// that runs the background jobs.
+const schedulerLockID int64 = 0x6a6f6273 // "jobs"
+
+type advisoryLocker interface {
+	TryAdvisoryLock(context.Context, int64) (*database.AdvisoryLock, error)
+}
+
+func newScheduler(repo advisoryLocker, sv usecase.Usecase) *scheduler.Scheduler {
+	dueSoonDays := 2
+	if d, err := strconv.Atoi(os.Getenv("REMINDER_DUE_SOON_DAYS")); err == nil {
+		dueSoonDays = d
+	}
+	noticeDays := 7
+	if d, err := strconv.Atoi(os.Getenv("SUBSCRIPTION_EXPIRY_NOTICE_DAYS")); err == nil {
+		noticeDays = d
+	}
+
+	tryLock := func(ctx context.Context) (scheduler.Lock, error) {
+		l, err := repo.TryAdvisoryLock(ctx, schedulerLockID)
+		if l == nil {
+			return nil, err
+		}
+		return l, nil
+	}
+	return scheduler.New(tryLock,
+		scheduler.Job{
+			Name:  "reminders",
+			Every: time.Hour,
+			Run: func(ctx context.Context) error {
+				n, err := sv.SendReminders(ctx, dueSoonDays)
+				if n > 0 {
+					log.Printf("sent %d reminders", n)
+				}
+				return err
+			},
+		},
+		scheduler.Job{
+			Name:  "subscriptions",
+			Every: time.Hour,
+			Run: func(ctx context.Context) error {
+				// failed notices do not hold the expiry back
+				n, notifyErr := sv.NotifyExpiringSubscriptions(ctx, noticeDays)
+				if n > 0 {
+					log.Printf("told %d members their subscription expires soon", n)
+				}
+				n, err := sv.ExpireSubscriptions(ctx)
+				if n > 0 {
+					log.Printf("expired %d subscriptions", n)
+				}
+				return errors.Join(notifyErr, err)
+			},
+		},
+		scheduler.Job{
+			Name:  "suspensions",
+			Every: time.Hour,
+			Run: func(ctx context.Context) error {
+				n, err := sv.SuspendDelinquentSubscriptions(ctx)
+				if n > 0 {
+					log.Printf("suspended %d subscriptions", n)
+				}
+				return err
+			},
+		},
+	)
+}
+
+// newValidator returns a validator whose isbn tag accepts ISBNs written
+// with hyphens or spaces, which the built-in one rejects.
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterValidation("isbn", func(fl validator.FieldLevel) bool {
+		_, _, err := usecase.ParseISBN(fl.Field().String())
+		return err == nil
+	})
+	return v
 }
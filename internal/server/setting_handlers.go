@@ -0,0 +1,88 @@
+package server
+
+import (
+	"librarease/internal/usecase"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type Setting struct {
+	LibraryID          string `json:"library_id"`
+	MaxOutstandingFine *int   `json:"max_outstanding_fine"`
+	HoldPickupDays     *int   `json:"hold_pickup_days"`
+	AutoSuspendFine    *int   `json:"auto_suspend_fine"`
+	AutoSuspendOverdue *int   `json:"auto_suspend_overdue"`
+	CreatedAt          string `json:"created_at,omitempty"`
+	UpdatedAt          string `json:"updated_at,omitempty"`
+}
+
+type GetSettingRequest struct {
+	LibraryID string `param:"id" validate:"required,uuid"`
+}
+
+func (s *Server) GetSetting(ctx echo.Context) error {
+	var req GetSettingRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	libraryID, _ := uuid.Parse(req.LibraryID)
+	st, err := s.server.GetSetting(ctx.Request().Context(), libraryID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(200, Res{Data: ConvertSettingFrom(st)})
+}
+
+type UpdateSettingRequest struct {
+	LibraryID          string `param:"id" validate:"required,uuid"`
+	MaxOutstandingFine *int   `json:"max_outstanding_fine" validate:"omitempty,gte=0"`
+	HoldPickupDays     *int   `json:"hold_pickup_days" validate:"omitempty,gte=1"`
+	AutoSuspendFine    *int   `json:"auto_suspend_fine" validate:"omitempty,gte=1"`
+	AutoSuspendOverdue *int   `json:"auto_suspend_overdue" validate:"omitempty,gte=1"`
+}
+
+func (s *Server) UpdateSetting(ctx echo.Context) error {
+	var req UpdateSettingRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	libraryID, _ := uuid.Parse(req.LibraryID)
+	st, err := s.server.UpdateSetting(ctx.Request().Context(), usecase.Setting{
+		LibraryID:          libraryID,
+		MaxOutstandingFine: req.MaxOutstandingFine,
+		HoldPickupDays:     req.HoldPickupDays,
+		AutoSuspendFine:    req.AutoSuspendFine,
+		AutoSuspendOverdue: req.AutoSuspendOverdue,
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(200, Res{Data: ConvertSettingFrom(st)})
+}
+
+func ConvertSettingFrom(st usecase.Setting) Setting {
+	s := Setting{
+		LibraryID:          st.LibraryID.String(),
+		MaxOutstandingFine: st.MaxOutstandingFine,
+		HoldPickupDays:     st.HoldPickupDays,
+		AutoSuspendFine:    st.AutoSuspendFine,
+		AutoSuspendOverdue: st.AutoSuspendOverdue,
+	}
+	if !st.CreatedAt.IsZero() {
+		s.CreatedAt = st.CreatedAt.Format(time.RFC3339)
+		s.UpdatedAt = st.UpdatedAt.Format(time.RFC3339)
+	}
+	return s
+}
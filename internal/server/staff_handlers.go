@@ -13,45 +13,49 @@ type Staff struct {
 	Name      string   `json:"name"`
 	LibraryID string   `json:"library_id,omitempty"`
 	UserID    string   `json:"user_id,omitempty"`
+	Role      string   `json:"role,omitempty"`
 	CreatedAt string   `json:"created_at,omitempty"`
 	UpdatedAt string   `json:"updated_at,omitempty"`
+	DeletedAt *string  `json:"deleted_at,omitempty"`
 	User      *User    `json:"user,omitempty"`
 	Library   *Library `json:"library,omitempty"`
 }
 
 type ListStaffsRequest struct {
-	LibraryID string `query:"library_id" validate:"omitempty,uuid"`
-	UserID    string `query:"user_id" validate:"omitempty,uuid"`
-	Skip      int    `query:"skip"`
-	Limit     int    `query:"limit" validate:"required,gte=1,lte=100"`
-	Name      string `query:"name" validate:"omitempty"`
-	SortBy    string `query:"sort_by" validate:"omitempty,oneof=created_at updated_at"`
-	SortIn    string `query:"sort_in" validate:"omitempty,oneof=asc desc"`
+	LibraryID      string `query:"library_id" validate:"omitempty,uuid"`
+	UserID         string `query:"user_id" validate:"omitempty,uuid"`
+	Skip           int    `query:"skip"`
+	Limit          int    `query:"limit" validate:"required,gte=1,lte=100"`
+	Name           string `query:"name" validate:"omitempty"`
+	SortBy         string `query:"sort_by" validate:"omitempty,oneof=created_at updated_at"`
+	SortIn         string `query:"sort_in" validate:"omitempty,oneof=asc desc"`
+	IncludeDeleted bool   `query:"include_deleted"`
 }
 
 func (s *Server) ListStaffs(ctx echo.Context) error {
 
 	var req ListStaffsRequest
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(400, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	err := s.validator.Struct(req)
 	if err != nil {
-		return ctx.JSON(422, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	staffs, total, err := s.server.ListStaffs(ctx.Request().Context(), usecase.ListStaffsOption{
-		LibraryID: req.LibraryID,
-		UserID:    req.UserID,
-		Skip:      req.Skip,
-		Limit:     req.Limit,
-		Name:      req.Name,
-		SortBy:    req.SortBy,
-		SortIn:    req.SortIn,
+		LibraryID:      req.LibraryID,
+		UserID:         req.UserID,
+		Skip:           req.Skip,
+		Limit:          req.Limit,
+		Name:           req.Name,
+		SortBy:         req.SortBy,
+		SortIn:         req.SortIn,
+		IncludeDeleted: req.IncludeDeleted,
 	})
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	list := make([]Staff, 0, len(staffs))
@@ -62,8 +66,10 @@ func (s *Server) ListStaffs(ctx echo.Context) error {
 			Name:      st.Name,
 			LibraryID: st.LibraryID.String(),
 			UserID:    st.UserID.String(),
+			Role:      st.Role,
 			CreatedAt: st.CreatedAt.Format(time.RFC3339),
 			UpdatedAt: st.UpdatedAt.Format(time.RFC3339),
+			DeletedAt: optionalTime(st.DeleteAt),
 		}
 		if st.User != nil {
 			staff.User = &User{
@@ -98,17 +104,18 @@ type CreateStaffRequest struct {
 	Name      string `json:"name" validate:"required"`
 	LibraryID string `json:"library_id" validate:"required,uuid"`
 	UserID    string `json:"user_id" validate:"required,uuid"`
+	Role      string `json:"role" validate:"omitempty,oneof=STAFF ADMIN"`
 }
 
 func (s *Server) CreateStaff(ctx echo.Context) error {
 	var req CreateStaffRequest
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(400, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	err := s.validator.Struct(req)
 	if err != nil {
-		return ctx.JSON(422, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	libID, _ := uuid.Parse(req.LibraryID)
@@ -118,9 +125,10 @@ func (s *Server) CreateStaff(ctx echo.Context) error {
 		Name:      req.Name,
 		LibraryID: libID,
 		UserID:    uID,
+		Role:      req.Role,
 	})
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	return ctx.JSON(201, Res{Data: Staff{
@@ -128,6 +136,7 @@ func (s *Server) CreateStaff(ctx echo.Context) error {
 		Name:      st.Name,
 		LibraryID: st.LibraryID.String(),
 		UserID:    st.UserID.String(),
+		Role:      st.Role,
 		CreatedAt: st.CreatedAt.Format(time.RFC3339),
 		UpdatedAt: st.UpdatedAt.Format(time.RFC3339),
 	}})
@@ -137,7 +146,7 @@ func (s *Server) GetStaffByID(ctx echo.Context) error {
 	id := ctx.Param("id")
 	st, err := s.server.GetStaffByID(ctx.Request().Context(), id)
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	staff := Staff{
@@ -145,6 +154,7 @@ func (s *Server) GetStaffByID(ctx echo.Context) error {
 		Name:      st.Name,
 		LibraryID: st.LibraryID.String(),
 		UserID:    st.UserID.String(),
+		Role:      st.Role,
 		CreatedAt: st.CreatedAt.Format(time.RFC3339),
 		UpdatedAt: st.UpdatedAt.Format(time.RFC3339),
 	}
@@ -163,17 +173,18 @@ func (s *Server) GetStaffByID(ctx echo.Context) error {
 type UpdateStaffRequest struct {
 	ID   string `param:"id" validate:"required,uuid"`
 	Name string `json:"name"`
+	Role string `json:"role" validate:"omitempty,oneof=STAFF ADMIN"`
 }
 
 func (s *Server) UpdateStaff(ctx echo.Context) error {
 	var req UpdateStaffRequest
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(400, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	err := s.validator.Struct(req)
 	if err != nil {
-		return ctx.JSON(422, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	uid, _ := uuid.Parse(req.ID)
@@ -181,14 +192,28 @@ func (s *Server) UpdateStaff(ctx echo.Context) error {
 	st, err := s.server.UpdateStaff(ctx.Request().Context(), usecase.Staff{
 		ID:   uid,
 		Name: req.Name,
+		Role: req.Role,
 	})
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 	return ctx.JSON(200, Res{Data: Staff{
 		ID:        st.ID.String(),
 		Name:      st.Name,
+		Role:      st.Role,
 		CreatedAt: st.CreatedAt.Format(time.RFC3339),
 		UpdatedAt: st.UpdatedAt.Format(time.RFC3339),
 	}})
 }
+
+func (s *Server) DeleteStaff(ctx echo.Context) error {
+	return s.noContentByID(ctx, s.server.DeleteStaff)
+}
+
+func (s *Server) RestoreStaff(ctx echo.Context) error {
+	return s.noContentByID(ctx, s.server.RestoreStaff)
+}
+
+func (s *Server) PurgeStaff(ctx echo.Context) error {
+	return s.noContentByID(ctx, s.server.PurgeStaff)
+}
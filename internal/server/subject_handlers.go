@@ -0,0 +1,212 @@
+package server
+
+import (
+	"librarease/internal/usecase"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type Subject struct {
+	ID        string `json:"id"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	LibraryID string `json:"library_id"`
+	CreatedAt string `json:"created_at,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
+
+// Facet counts the books of a list result classified under a subject.
+type Facet struct {
+	SubjectID string `json:"subject_id"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Count     int    `json:"count"`
+}
+
+type ListSubjectsRequest struct {
+	LibraryID string `query:"library_id" validate:"omitempty,uuid"`
+	Kind      string `query:"kind" validate:"omitempty,oneof=SUBJECT GENRE TAG"`
+	Name      string `query:"name"`
+	Skip      int    `query:"skip"`
+	Limit     int    `query:"limit" validate:"required,gte=1,lte=100"`
+	SortBy    string `query:"sort_by" validate:"omitempty,oneof=created_at updated_at name kind"`
+	SortIn    string `query:"sort_in" validate:"omitempty,oneof=asc desc"`
+}
+
+func (s *Server) ListSubjects(ctx echo.Context) error {
+	var req ListSubjectsRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	var libIDs uuid.UUIDs
+	if req.LibraryID != "" {
+		id, _ := uuid.Parse(req.LibraryID)
+		libIDs = append(libIDs, id)
+	}
+	var kinds []string
+	if req.Kind != "" {
+		kinds = []string{req.Kind}
+	}
+
+	list, total, err := s.server.ListSubjects(ctx.Request().Context(), usecase.ListSubjectsOption{
+		Skip:       req.Skip,
+		Limit:      req.Limit,
+		LibraryIDs: libIDs,
+		Kinds:      kinds,
+		Name:       req.Name,
+		SortBy:     req.SortBy,
+		SortIn:     req.SortIn,
+	})
+	if err != nil {
+		return err
+	}
+
+	subjects := make([]Subject, 0, len(list))
+	for _, sub := range list {
+		subjects = append(subjects, ConvertSubjectFrom(sub))
+	}
+
+	return ctx.JSON(200, Res{
+		Data: subjects,
+		Meta: &Meta{
+			Total: total,
+			Skip:  req.Skip,
+			Limit: req.Limit,
+		},
+	})
+}
+
+type GetSubjectByIDRequest struct {
+	ID string `param:"id" validate:"required,uuid"`
+}
+
+func (s *Server) GetSubjectByID(ctx echo.Context) error {
+	var req GetSubjectByIDRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	id, _ := uuid.Parse(req.ID)
+	sub, err := s.server.GetSubjectByID(ctx.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(200, Res{Data: ConvertSubjectFrom(sub)})
+}
+
+type CreateSubjectRequest struct {
+	Kind      string `json:"kind" validate:"required,oneof=SUBJECT GENRE TAG"`
+	Name      string `json:"name" validate:"required,max=255"`
+	LibraryID string `json:"library_id" validate:"required,uuid"`
+}
+
+func (s *Server) CreateSubject(ctx echo.Context) error {
+	var req CreateSubjectRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	libID, _ := uuid.Parse(req.LibraryID)
+	sub, err := s.server.CreateSubject(ctx.Request().Context(), usecase.Subject{
+		Kind:      req.Kind,
+		Name:      req.Name,
+		LibraryID: libID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(201, Res{Data: ConvertSubjectFrom(sub)})
+}
+
+type UpdateSubjectRequest struct {
+	ID   string `param:"id" validate:"required,uuid"`
+	Kind string `json:"kind" validate:"omitempty,oneof=SUBJECT GENRE TAG"`
+	Name string `json:"name" validate:"max=255"`
+}
+
+func (s *Server) UpdateSubject(ctx echo.Context) error {
+	var req UpdateSubjectRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	id, _ := uuid.Parse(req.ID)
+	sub, err := s.server.UpdateSubject(ctx.Request().Context(), usecase.Subject{
+		ID:   id,
+		Kind: req.Kind,
+		Name: req.Name,
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(200, Res{Data: ConvertSubjectFrom(sub)})
+}
+
+func (s *Server) DeleteSubject(ctx echo.Context) error {
+	var req GetSubjectByIDRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	id, _ := uuid.Parse(req.ID)
+	if err := s.server.DeleteSubject(ctx.Request().Context(), id); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(204)
+}
+
+func ConvertSubjectFrom(sub usecase.Subject) Subject {
+	return Subject{
+		ID:        sub.ID.String(),
+		Kind:      sub.Kind,
+		Name:      sub.Name,
+		LibraryID: sub.LibraryID.String(),
+		CreatedAt: sub.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: sub.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func ConvertFacetFrom(f usecase.Facet) Facet {
+	return Facet{
+		SubjectID: f.Subject.ID.String(),
+		Kind:      f.Subject.Kind,
+		Name:      f.Subject.Name,
+		Count:     f.Count,
+	}
+}
+
+// parseUUIDs parses validated uuids, keeping a nil slice nil so that
+// "not given" stays apart from "none".
+func parseUUIDs(ss []string) uuid.UUIDs {
+	if ss == nil {
+		return nil
+	}
+	ids := make(uuid.UUIDs, 0, len(ss))
+	for _, s := range ss {
+		id, _ := uuid.Parse(s)
+		ids = append(ids, id)
+	}
+	return ids
+}
@@ -15,12 +15,15 @@ type Subscription struct {
 	CreatedAt    string      `json:"created_at,omitempty"`
 	UpdatedAt    string      `json:"updated_at,omitempty"`
 	DeletedAt    *string     `json:"deleted_at,omitempty"`
+	ExpiredAt    *string     `json:"expired_at,omitempty"`
+	SuspendedAt  *string     `json:"suspended_at,omitempty"`
 	User         *User       `json:"user,omitempty"`
 	Membership   *Membership `json:"membership,omitempty"`
 
 	// Granfathering the membership
 	ExpiresAt       string `json:"expires_at,omitempty"`
 	FinePerDay      int    `json:"fine_per_day,omitempty"`
+	MaxRenewals     int    `json:"max_renewals,omitempty"`
 	LoanPeriod      int    `json:"loan_period,omitempty"`
 	ActiveLoanLimit int    `json:"active_loan_limit,omitempty"`
 }
@@ -33,15 +36,17 @@ type ListSubscriptionsRequest struct {
 	LibraryID      string `query:"library_id" validate:"omitempty,uuid"`
 	MembershipName string `query:"membership_name" validate:"omitempty"`
 	IsActive       bool   `query:"is_active"`
+	IsSuspended    bool   `query:"is_suspended"`
+	IncludeDeleted bool   `query:"include_deleted"`
 }
 
 func (s *Server) ListSubscriptions(ctx echo.Context) error {
 	var req ListSubscriptionsRequest
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(400, map[string]string{"error": err.Error()})
+		return err
 	}
 	if err := s.validator.Struct(req); err != nil {
-		return ctx.JSON(422, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	subs, total, err := s.server.ListSubscriptions(ctx.Request().Context(), usecase.ListSubscriptionsOption{
@@ -52,9 +57,11 @@ func (s *Server) ListSubscriptions(ctx echo.Context) error {
 		LibraryID:      req.LibraryID,
 		MembershipName: req.MembershipName,
 		IsActive:       req.IsActive,
+		IsSuspended:    req.IsSuspended,
+		IncludeDeleted: req.IncludeDeleted,
 	})
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 	list := make([]Subscription, 0, len(subs))
 
@@ -71,8 +78,11 @@ func (s *Server) ListSubscriptions(ctx echo.Context) error {
 			CreatedAt:       sub.CreatedAt.Format(time.RFC3339),
 			UpdatedAt:       sub.UpdatedAt.Format(time.RFC3339),
 			DeletedAt:       d,
+			ExpiredAt:       optionalTime(sub.ExpiredAt),
+			SuspendedAt:     optionalTime(sub.SuspendedAt),
 			ExpiresAt:       sub.ExpiresAt.String(),
 			FinePerDay:      sub.FinePerDay,
+			MaxRenewals:     sub.MaxRenewals,
 			LoanPeriod:      sub.LoanPeriod,
 			ActiveLoanLimit: sub.ActiveLoanLimit,
 		}
@@ -116,17 +126,17 @@ type GetSubscriptionByIDRequest struct {
 func (s *Server) GetSubscriptionByID(ctx echo.Context) error {
 	var req GetSubscriptionByIDRequest
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(400, map[string]string{"error": err.Error()})
+		return err
 	}
 	if err := s.validator.Struct(req); err != nil {
-		return ctx.JSON(422, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	id, _ := uuid.Parse(req.ID)
 
 	sub, err := s.server.GetSubscriptionByID(ctx.Request().Context(), id)
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	var d *string
@@ -141,8 +151,11 @@ func (s *Server) GetSubscriptionByID(ctx echo.Context) error {
 		CreatedAt:       sub.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:       sub.UpdatedAt.Format(time.RFC3339),
 		DeletedAt:       d,
+		ExpiredAt:       optionalTime(sub.ExpiredAt),
+		SuspendedAt:     optionalTime(sub.SuspendedAt),
 		ExpiresAt:       sub.ExpiresAt.String(),
 		FinePerDay:      sub.FinePerDay,
+		MaxRenewals:     sub.MaxRenewals,
 		LoanPeriod:      sub.LoanPeriod,
 		ActiveLoanLimit: sub.ActiveLoanLimit,
 	}
@@ -161,6 +174,7 @@ func (s *Server) GetSubscriptionByID(ctx echo.Context) error {
 			ActiveLoanLimit: sub.Membership.ActiveLoanLimit,
 			LoanPeriod:      sub.Membership.LoanPeriod,
 			FinePerDay:      sub.Membership.FinePerDay,
+			MaxRenewals:     sub.Membership.MaxRenewals,
 			CreatedAt:       sub.Membership.CreatedAt.Format(time.RFC3339),
 			UpdatedAt:       sub.Membership.UpdatedAt.Format(time.RFC3339),
 		}
@@ -184,10 +198,10 @@ type CreateSubscriptionRequest struct {
 func (s *Server) CreateSubscription(ctx echo.Context) error {
 	var req CreateSubscriptionRequest
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(400, map[string]string{"error": err.Error()})
+		return err
 	}
 	if err := s.validator.Struct(req); err != nil {
-		return ctx.JSON(422, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	userID, _ := uuid.Parse(req.UserID)
@@ -198,7 +212,7 @@ func (s *Server) CreateSubscription(ctx echo.Context) error {
 		MembershipID: membershipID,
 	})
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	// FIXME: return the created subscription
@@ -207,10 +221,9 @@ func (s *Server) CreateSubscription(ctx echo.Context) error {
 
 type UpdateSubscriptionRequest struct {
 	ID              string `param:"id" validate:"required,uuid"`
-	UserID          string `json:"user_id" validate:"omitempty,uuid"`
-	MembershipID    string `json:"membership_id" validate:"omitempty,uuid"`
 	ExpiresAt       string `json:"expires_at" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
 	FinePerDay      int    `json:"fine_per_day" validate:"omitempty,number"`
+	MaxRenewals     int    `json:"max_renewals" validate:"omitempty,number"`
 	LoanPeriod      int    `json:"loan_period" validate:"omitempty,number"`
 	ActiveLoanLimit int    `json:"active_loan_limit" validate:"omitempty,number"`
 }
@@ -218,15 +231,13 @@ type UpdateSubscriptionRequest struct {
 func (s *Server) UpdateSubscription(ctx echo.Context) error {
 	var req UpdateSubscriptionRequest
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(400, map[string]string{"error": err.Error()})
+		return err
 	}
 	if err := s.validator.Struct(req); err != nil {
-		return ctx.JSON(422, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	id, _ := uuid.Parse(req.ID)
-	userID, _ := uuid.Parse(req.UserID)
-	membershipID, _ := uuid.Parse(req.MembershipID)
 
 	var (
 		exp time.Time
@@ -235,21 +246,208 @@ func (s *Server) UpdateSubscription(ctx echo.Context) error {
 	if req.ExpiresAt != "" {
 		exp, err = time.Parse(time.RFC3339, req.ExpiresAt)
 		if err != nil {
-			return ctx.JSON(422, map[string]string{"error": "invalid expires_at"})
+			return echo.NewHTTPError(422, "invalid expires_at")
 		}
 	}
 
 	sub, err := s.server.UpdateSubscription(ctx.Request().Context(), usecase.Subscription{
 		ID:              id,
-		UserID:          userID,
-		MembershipID:    membershipID,
 		ExpiresAt:       exp,
 		FinePerDay:      req.FinePerDay,
+		MaxRenewals:     req.MaxRenewals,
 		LoanPeriod:      req.LoanPeriod,
 		ActiveLoanLimit: req.ActiveLoanLimit,
 	})
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
+	}
+
+	return ctx.JSON(200, Res{Data: Subscription{
+		ID:              sub.ID.String(),
+		UserID:          sub.UserID.String(),
+		MembershipID:    sub.MembershipID.String(),
+		CreatedAt:       sub.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:       sub.UpdatedAt.Format(time.RFC3339),
+		ExpiresAt:       sub.ExpiresAt.String(),
+		FinePerDay:      sub.FinePerDay,
+		MaxRenewals:     sub.MaxRenewals,
+		LoanPeriod:      sub.LoanPeriod,
+		ActiveLoanLimit: sub.ActiveLoanLimit,
+	}})
+}
+
+type RenewSubscriptionRequest struct {
+	ID            string `param:"id" validate:"required,uuid"`
+	Regrandfather bool   `json:"regrandfather"`
+}
+
+func (s *Server) RenewSubscription(ctx echo.Context) error {
+	var req RenewSubscriptionRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	id, _ := uuid.Parse(req.ID)
+	staffID, err := s.callerStaffID(ctx, subscriptionLibrary)
+	if err != nil {
+		return err
+	}
+
+	sub, err := s.server.RenewSubscription(ctx.Request().Context(), usecase.RenewSubscriptionOption{
+		ID:            id,
+		StaffID:       &staffID,
+		Regrandfather: req.Regrandfather,
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(200, Res{Data: Subscription{
+		ID:              sub.ID.String(),
+		UserID:          sub.UserID.String(),
+		MembershipID:    sub.MembershipID.String(),
+		CreatedAt:       sub.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:       sub.UpdatedAt.Format(time.RFC3339),
+		ExpiredAt:       optionalTime(sub.ExpiredAt),
+		SuspendedAt:     optionalTime(sub.SuspendedAt),
+		ExpiresAt:       sub.ExpiresAt.String(),
+		FinePerDay:      sub.FinePerDay,
+		MaxRenewals:     sub.MaxRenewals,
+		LoanPeriod:      sub.LoanPeriod,
+		ActiveLoanLimit: sub.ActiveLoanLimit,
+	}})
+}
+
+type SubscriptionRenewal struct {
+	ID                string  `json:"id"`
+	SubscriptionID    string  `json:"subscription_id"`
+	StaffID           *string `json:"staff_id"`
+	PreviousExpiresAt string  `json:"previous_expires_at"`
+	ExpiresAt         string  `json:"expires_at"`
+	Regrandfathered   bool    `json:"regrandfathered"`
+	FinePerDay        int     `json:"fine_per_day"`
+	MaxRenewals       int     `json:"max_renewals"`
+	LoanPeriod        int     `json:"loan_period"`
+	ActiveLoanLimit   int     `json:"active_loan_limit"`
+	CreatedAt         string  `json:"created_at"`
+}
+
+func (s *Server) ListSubscriptionRenewals(ctx echo.Context) error {
+	var req GetSubscriptionByIDRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	id, _ := uuid.Parse(req.ID)
+	renewals, err := s.server.ListSubscriptionRenewals(ctx.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	list := make([]SubscriptionRenewal, 0, len(renewals))
+	for _, r := range renewals {
+		sr := SubscriptionRenewal{
+			ID:                r.ID.String(),
+			SubscriptionID:    r.SubscriptionID.String(),
+			PreviousExpiresAt: r.PreviousExpiresAt.Format(time.RFC3339),
+			ExpiresAt:         r.ExpiresAt.Format(time.RFC3339),
+			Regrandfathered:   r.Regrandfathered,
+			FinePerDay:        r.FinePerDay,
+			MaxRenewals:       r.MaxRenewals,
+			LoanPeriod:        r.LoanPeriod,
+			ActiveLoanLimit:   r.ActiveLoanLimit,
+			CreatedAt:         r.CreatedAt.Format(time.RFC3339),
+		}
+		if r.StaffID != nil {
+			sid := r.StaffID.String()
+			sr.StaffID = &sid
+		}
+		list = append(list, sr)
+	}
+
+	return ctx.JSON(200, Res{Data: list})
+}
+
+type SuspendSubscriptionRequest struct {
+	ID     string `param:"id" validate:"required,uuid"`
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// SuspendSubscription records the caller as the suspending staff.
+func (s *Server) SuspendSubscription(ctx echo.Context) error {
+	var req SuspendSubscriptionRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	id, _ := uuid.Parse(req.ID)
+	staffID, err := s.callerStaffID(ctx, subscriptionLibrary)
+	if err != nil {
+		return err
+	}
+
+	sub, err := s.server.SuspendSubscription(ctx.Request().Context(), usecase.SuspendSubscriptionOption{
+		ID:      id,
+		StaffID: &staffID,
+		Reason:  req.Reason,
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(200, Res{Data: Subscription{
+		ID:              sub.ID.String(),
+		UserID:          sub.UserID.String(),
+		MembershipID:    sub.MembershipID.String(),
+		CreatedAt:       sub.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:       sub.UpdatedAt.Format(time.RFC3339),
+		ExpiredAt:       optionalTime(sub.ExpiredAt),
+		SuspendedAt:     optionalTime(sub.SuspendedAt),
+		ExpiresAt:       sub.ExpiresAt.String(),
+		FinePerDay:      sub.FinePerDay,
+		MaxRenewals:     sub.MaxRenewals,
+		LoanPeriod:      sub.LoanPeriod,
+		ActiveLoanLimit: sub.ActiveLoanLimit,
+	}})
+}
+
+type ReinstateSubscriptionRequest struct {
+	ID           string `param:"id" validate:"required,uuid"`
+	ExtendExpiry bool   `json:"extend_expiry"`
+}
+
+// ReinstateSubscription records the caller as the reinstating staff.
+func (s *Server) ReinstateSubscription(ctx echo.Context) error {
+	var req ReinstateSubscriptionRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	id, _ := uuid.Parse(req.ID)
+	staffID, err := s.callerStaffID(ctx, subscriptionLibrary)
+	if err != nil {
+		return err
+	}
+
+	sub, err := s.server.ReinstateSubscription(ctx.Request().Context(), usecase.ReinstateSubscriptionOption{
+		ID:           id,
+		StaffID:      &staffID,
+		ExtendExpiry: req.ExtendExpiry,
+	})
+	if err != nil {
+		return err
 	}
 
 	return ctx.JSON(200, Res{Data: Subscription{
@@ -258,9 +456,84 @@ func (s *Server) UpdateSubscription(ctx echo.Context) error {
 		MembershipID:    sub.MembershipID.String(),
 		CreatedAt:       sub.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:       sub.UpdatedAt.Format(time.RFC3339),
+		ExpiredAt:       optionalTime(sub.ExpiredAt),
+		SuspendedAt:     optionalTime(sub.SuspendedAt),
 		ExpiresAt:       sub.ExpiresAt.String(),
 		FinePerDay:      sub.FinePerDay,
+		MaxRenewals:     sub.MaxRenewals,
 		LoanPeriod:      sub.LoanPeriod,
 		ActiveLoanLimit: sub.ActiveLoanLimit,
 	}})
 }
+
+type SubscriptionSuspension struct {
+	ID                string  `json:"id"`
+	SubscriptionID    string  `json:"subscription_id"`
+	StaffID           *string `json:"staff_id"`
+	Reason            string  `json:"reason"`
+	SuspendedAt       string  `json:"suspended_at"`
+	ReinstatedAt      *string `json:"reinstated_at"`
+	ReinstatedStaffID *string `json:"reinstated_staff_id"`
+	ExpiresAt         *string `json:"expires_at"`
+	ExcusedFine       int     `json:"excused_fine"`
+}
+
+func (s *Server) ListSubscriptionSuspensions(ctx echo.Context) error {
+	var req GetSubscriptionByIDRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	id, _ := uuid.Parse(req.ID)
+	suspensions, err := s.server.ListSubscriptionSuspensions(ctx.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	list := make([]SubscriptionSuspension, 0, len(suspensions))
+	for _, ss := range suspensions {
+		d := SubscriptionSuspension{
+			ID:             ss.ID.String(),
+			SubscriptionID: ss.SubscriptionID.String(),
+			Reason:         ss.Reason,
+			SuspendedAt:    ss.SuspendedAt.Format(time.RFC3339),
+			ReinstatedAt:   optionalTime(ss.ReinstatedAt),
+			ExpiresAt:      optionalTime(ss.ExpiresAt),
+			ExcusedFine:    ss.ExcusedFine,
+		}
+		if ss.StaffID != nil {
+			sid := ss.StaffID.String()
+			d.StaffID = &sid
+		}
+		if ss.ReinstatedStaffID != nil {
+			sid := ss.ReinstatedStaffID.String()
+			d.ReinstatedStaffID = &sid
+		}
+		list = append(list, d)
+	}
+
+	return ctx.JSON(200, Res{Data: list})
+}
+
+func (s *Server) DeleteSubscription(ctx echo.Context) error {
+	return s.noContentByID(ctx, s.server.DeleteSubscription)
+}
+
+func (s *Server) RestoreSubscription(ctx echo.Context) error {
+	return s.noContentByID(ctx, s.server.RestoreSubscription)
+}
+
+func (s *Server) PurgeSubscription(ctx echo.Context) error {
+	return s.noContentByID(ctx, s.server.PurgeSubscription)
+}
+
+func optionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.Format(time.RFC3339)
+	return &s
+}
@@ -1,7 +1,6 @@
 package server
 
 import (
-	"librarease/internal/config"
 	"librarease/internal/usecase"
 	"time"
 
@@ -15,35 +14,40 @@ type User struct {
 	Email     string  `json:"email,omitempty"`
 	CreatedAt string  `json:"created_at,omitempty"`
 	UpdatedAt string  `json:"updated_at,omitempty"`
+	DeletedAt *string `json:"deleted_at,omitempty"`
 	Staffs    []Staff `json:"staffs,omitempty"`
 }
 
 type ListUserRequest struct {
-	Skip   int    `query:"skip"`
-	Limit  int    `query:"limit" validate:"required,gte=1,lte=100"`
-	Name   string `query:"name" validate:"omitempty"`
-	SortBy string `query:"sort_by" validate:"omitempty,oneof=created_at updated_at name email"`
-	SortIn string `query:"sort_in" validate:"omitempty,oneof=asc desc"`
+	Skip           int    `query:"skip"`
+	Limit          int    `query:"limit" validate:"required,gte=1,lte=100"`
+	Name           string `query:"name" validate:"omitempty"`
+	LibraryID      string `query:"library_id" validate:"omitempty,uuid"`
+	SortBy         string `query:"sort_by" validate:"omitempty,oneof=created_at updated_at name email"`
+	SortIn         string `query:"sort_in" validate:"omitempty,oneof=asc desc"`
+	IncludeDeleted bool   `query:"include_deleted"`
 }
 
 func (s *Server) ListUsers(ctx echo.Context) error {
 	var req ListUserRequest
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(400, map[string]string{"error": err.Error()})
+		return err
 	}
 	if err := s.validator.Struct(req); err != nil {
-		return ctx.JSON(422, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	users, total, err := s.server.ListUsers(ctx.Request().Context(), usecase.ListUsersOption{
-		Skip:   req.Skip,
-		Limit:  req.Limit,
-		Name:   req.Name,
-		SortBy: req.SortBy,
-		SortIn: req.SortIn,
+		Skip:           req.Skip,
+		Limit:          req.Limit,
+		Name:           req.Name,
+		LibraryID:      req.LibraryID,
+		SortBy:         req.SortBy,
+		SortIn:         req.SortIn,
+		IncludeDeleted: req.IncludeDeleted,
 	})
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	list := make([]User, 0, len(users))
@@ -55,6 +59,7 @@ func (s *Server) ListUsers(ctx echo.Context) error {
 			Email:     u.Email,
 			CreatedAt: u.CreatedAt.Format(time.RFC3339),
 			UpdatedAt: u.UpdatedAt.Format(time.RFC3339),
+			DeletedAt: optionalTime(u.DeleteAt),
 		})
 	}
 
@@ -76,17 +81,17 @@ type GetUserByIDRequest struct {
 func (s *Server) GetUserByID(ctx echo.Context) error {
 	var req GetUserByIDRequest
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(400, map[string]string{"error": err.Error()})
+		return err
 	}
 	if err := s.validator.Struct(req); err != nil {
-		return ctx.JSON(422, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	u, err := s.server.GetUserByID(ctx.Request().Context(), req.ID, usecase.GetUserByIDOption{
 		IncludeStaffs: req.IncludeStaffs,
 	})
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	user := ConvertUserFrom(u)
@@ -132,12 +137,12 @@ func ConvertUserFrom(u usecase.User) User {
 func (s *Server) CreateUser(ctx echo.Context) error {
 	var user User
 	if err := ctx.Bind(&user); err != nil {
-		return ctx.JSON(400, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	err := s.validator.Struct(user)
 	if err != nil {
-		return ctx.JSON(422, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	u, err := s.server.CreateUser(ctx.Request().Context(), usecase.User{
@@ -145,7 +150,7 @@ func (s *Server) CreateUser(ctx echo.Context) error {
 		Email: user.Email,
 	})
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	return ctx.JSON(200, Res{Data: ConvertUserFrom(u)})
@@ -154,12 +159,12 @@ func (s *Server) CreateUser(ctx echo.Context) error {
 func (s *Server) UpdateUser(ctx echo.Context) error {
 	var user User
 	if err := ctx.Bind(&user); err != nil {
-		return ctx.JSON(400, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	err := s.validator.Struct(user)
 	if err != nil {
-		return ctx.JSON(422, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	id, _ := uuid.Parse(user.ID)
@@ -169,7 +174,7 @@ func (s *Server) UpdateUser(ctx echo.Context) error {
 		Name: user.Name,
 	})
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	return ctx.JSON(200, Res{Data: User{
@@ -187,29 +192,37 @@ type DeleteUserRequest struct {
 func (s *Server) DeleteUser(ctx echo.Context) error {
 	var req DeleteUserRequest
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(400, map[string]string{"error": err.Error()})
+		return err
 	}
 	if err := s.validator.Struct(req); err != nil {
-		return ctx.JSON(422, map[string]string{"error": err.Error()})
+		return err
 	}
 	err := s.server.DeleteUser(ctx.Request().Context(), req.ID)
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	return ctx.NoContent(204)
 }
 
+func (s *Server) RestoreUser(ctx echo.Context) error {
+	return s.noContentByID(ctx, s.server.RestoreUser)
+}
+
+func (s *Server) PurgeUser(ctx echo.Context) error {
+	return s.noContentByID(ctx, s.server.PurgeUser)
+}
+
 func (s *Server) GetMe(ctx echo.Context) error {
-	var id = ctx.Get(config.HEADER_KEY_X_USER_ID).(string)
-	if id == "" {
-		return ctx.JSON(400, map[string]string{"error": "user id is required"})
+	p, ok := GetPrincipal(ctx)
+	if !ok {
+		return ctx.JSON(401, Res{Error: "UNAUTHENTICATED"})
 	}
-	u, err := s.server.GetUserByID(ctx.Request().Context(), id, usecase.GetUserByIDOption{
+	u, err := s.server.GetUserByID(ctx.Request().Context(), p.UserID.String(), usecase.GetUserByIDOption{
 		IncludeStaffs: true,
 	})
 	if err != nil {
-		return ctx.JSON(500, map[string]string{"error": err.Error()})
+		return err
 	}
 	user := ConvertUserFrom(u)
 
@@ -0,0 +1,243 @@
+package server
+
+import (
+	"librarease/internal/usecase"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type Work struct {
+	ID               string    `json:"id"`
+	Title            string    `json:"title"`
+	Author           string    `json:"author,omitempty"`
+	Year             int       `json:"year,omitempty"`
+	ISBN13           string    `json:"isbn_13,omitempty"`
+	ISBN10           string    `json:"isbn_10,omitempty"`
+	CallNumber       string    `json:"call_number,omitempty"`
+	CallNumberScheme string    `json:"call_number_scheme,omitempty"`
+	Subjects         []Subject `json:"subjects"`
+	LibraryID        string    `json:"library_id"`
+	TotalCopies      int       `json:"total_copies"`
+	AvailableCopies  int       `json:"available_copies"`
+	CreatedAt        string    `json:"created_at"`
+	UpdatedAt        string    `json:"updated_at"`
+	DeletedAt        *string   `json:"deleted_at,omitempty"`
+	Library          *Library  `json:"library,omitempty"`
+}
+
+type ListWorksRequest struct {
+	LibraryID      string   `query:"library_id" validate:"omitempty,uuid"`
+	Skip           int      `query:"skip"`
+	Limit          int      `query:"limit" validate:"required,gte=1,lte=100"`
+	Title          string   `query:"title" validate:"omitempty"`
+	ISBN           string   `query:"isbn" validate:"omitempty,isbn"`
+	Q              string   `query:"q" validate:"omitempty,max=200"`
+	SubjectIDs     []string `query:"subject_id" validate:"omitempty,dive,uuid"`
+	SortBy         string   `query:"sort_by" validate:"omitempty,oneof=created_at updated_at title author year call_number"`
+	SortIn         string   `query:"sort_in" validate:"omitempty,oneof=asc desc"`
+	IncludeDeleted bool     `query:"include_deleted"`
+}
+
+func (s *Server) ListWorks(ctx echo.Context) error {
+	var req ListWorksRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	var libIDs uuid.UUIDs
+	if req.LibraryID != "" {
+		id, _ := uuid.Parse(req.LibraryID)
+		libIDs = append(libIDs, id)
+	}
+	var isbns []string
+	if req.ISBN != "" {
+		isbn13, _, _ := usecase.ParseISBN(req.ISBN)
+		isbns = append(isbns, isbn13)
+	}
+
+	list, total, err := s.server.ListWorks(ctx.Request().Context(), usecase.ListWorksOption{
+		Skip:           req.Skip,
+		Limit:          req.Limit,
+		LibraryIDs:     libIDs,
+		Title:          req.Title,
+		ISBN13s:        isbns,
+		Query:          req.Q,
+		SubjectIDs:     parseUUIDs(req.SubjectIDs),
+		SortBy:         req.SortBy,
+		SortIn:         req.SortIn,
+		IncludeDeleted: req.IncludeDeleted,
+	})
+	if err != nil {
+		return err
+	}
+
+	works := make([]Work, 0, len(list))
+	for _, w := range list {
+		works = append(works, ConvertWorkFrom(w))
+	}
+
+	return ctx.JSON(200, Res{
+		Data: works,
+		Meta: &Meta{
+			Total: total,
+			Skip:  req.Skip,
+			Limit: req.Limit,
+		},
+	})
+}
+
+type GetWorkByIDRequest struct {
+	ID string `param:"id" validate:"required,uuid"`
+}
+
+func (s *Server) GetWorkByID(ctx echo.Context) error {
+	var req GetWorkByIDRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	id, _ := uuid.Parse(req.ID)
+	w, err := s.server.GetWorkByID(ctx.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(200, Res{Data: ConvertWorkFrom(w)})
+}
+
+type CreateWorkRequest struct {
+	Title            string   `json:"title" validate:"required"`
+	Author           string   `json:"author"`
+	Year             int      `json:"year" validate:"omitempty,gte=1500"`
+	ISBN             string   `json:"isbn" validate:"omitempty,isbn"`
+	CallNumber       string   `json:"call_number" validate:"max=64"`
+	CallNumberScheme string   `json:"call_number_scheme" validate:"required_with=CallNumber,omitempty,oneof=DDC LCC"`
+	SubjectIDs       []string `json:"subject_ids" validate:"omitempty,dive,uuid"`
+	LibraryID        string   `json:"library_id" validate:"required,uuid"`
+}
+
+func (s *Server) CreateWork(ctx echo.Context) error {
+	var req CreateWorkRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	libID, _ := uuid.Parse(req.LibraryID)
+	isbn13, isbn10, _ := usecase.ParseISBN(req.ISBN)
+	w, err := s.server.CreateWork(ctx.Request().Context(), usecase.Work{
+		Title:     req.Title,
+		Author:    req.Author,
+		Year:      req.Year,
+		ISBN13:    isbn13,
+		ISBN10:    isbn10,
+		LibraryID: libID,
+
+		CallNumber:       req.CallNumber,
+		CallNumberScheme: req.CallNumberScheme,
+		SubjectIDs:       parseUUIDs(req.SubjectIDs),
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(201, Res{Data: ConvertWorkFrom(w)})
+}
+
+type UpdateWorkRequest struct {
+	ID               string   `param:"id" validate:"required,uuid"`
+	Title            string   `json:"title"`
+	Author           string   `json:"author"`
+	Year             int      `json:"year" validate:"omitempty,gte=1500"`
+	ISBN             string   `json:"isbn" validate:"omitempty,isbn"`
+	CallNumber       string   `json:"call_number" validate:"max=64"`
+	CallNumberScheme string   `json:"call_number_scheme" validate:"omitempty,oneof=DDC LCC"`
+	SubjectIDs       []string `json:"subject_ids" validate:"omitempty,dive,uuid"`
+}
+
+func (s *Server) UpdateWork(ctx echo.Context) error {
+	var req UpdateWorkRequest
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return err
+	}
+
+	id, _ := uuid.Parse(req.ID)
+	isbn13, isbn10, _ := usecase.ParseISBN(req.ISBN)
+	w, err := s.server.UpdateWork(ctx.Request().Context(), usecase.Work{
+		ID:     id,
+		Title:  req.Title,
+		Author: req.Author,
+		Year:   req.Year,
+		ISBN13: isbn13,
+		ISBN10: isbn10,
+
+		CallNumber:       req.CallNumber,
+		CallNumberScheme: req.CallNumberScheme,
+		SubjectIDs:       parseUUIDs(req.SubjectIDs),
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(200, Res{Data: ConvertWorkFrom(w)})
+}
+
+func ConvertWorkFrom(w usecase.Work) Work {
+	var d *string
+	if w.DeletedAt != nil {
+		ds := w.DeletedAt.Format(time.RFC3339)
+		d = &ds
+	}
+	work := Work{
+		ID:               w.ID.String(),
+		Title:            w.Title,
+		Author:           w.Author,
+		Year:             w.Year,
+		ISBN13:           w.ISBN13,
+		ISBN10:           w.ISBN10,
+		CallNumber:       w.CallNumber,
+		CallNumberScheme: w.CallNumberScheme,
+		Subjects:         make([]Subject, 0, len(w.Subjects)),
+		LibraryID:        w.LibraryID.String(),
+		TotalCopies:      w.TotalCopies,
+		AvailableCopies:  w.AvailableCopies,
+		CreatedAt:        w.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:        w.UpdatedAt.Format(time.RFC3339),
+		DeletedAt:        d,
+	}
+	for _, sub := range w.Subjects {
+		work.Subjects = append(work.Subjects, ConvertSubjectFrom(sub))
+	}
+	if w.Library != nil {
+		work.Library = &Library{
+			ID:   w.Library.ID.String(),
+			Name: w.Library.Name,
+		}
+	}
+	return work
+}
+
+func (s *Server) DeleteWork(ctx echo.Context) error {
+	return s.noContentByID(ctx, s.server.DeleteWork)
+}
+
+func (s *Server) RestoreWork(ctx echo.Context) error {
+	return s.noContentByID(ctx, s.server.RestoreWork)
+}
+
+func (s *Server) PurgeWork(ctx echo.Context) error {
+	return s.noContentByID(ctx, s.server.PurgeWork)
+}
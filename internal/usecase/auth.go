@@ -2,11 +2,26 @@ package usecase
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// Global roles of an auth user.
+const (
+	GlobalRoleSuperAdmin = "SUPERADMIN"
+	GlobalRoleAdmin      = "ADMIN"
+	GlobalRoleUser       = "USER"
+)
+
+const refreshTokenTTL = 30 * 24 * time.Hour
+
 type AuthUser struct {
 	UID        string
 	UserID     uuid.UUID
@@ -21,28 +36,191 @@ type RegisterUser struct {
 	Name     string
 	Email    string
 	Password string
+	// GlobalRole defaults to GlobalRoleUser. Only the admin CLI grants
+	// other roles, to bootstrap the first super admin.
+	GlobalRole string
 }
 
+// RegisterUser creates the identity provider account, then the user and
+// auth user rows in one transaction. If the database side fails, the
+// identity provider account is deleted again so the email can be reused.
 func (u Usecase) RegisterUser(ctx context.Context, ru RegisterUser) (User, error) {
-	uid, err := u.identityProvider.CreateUser(ctx, ru)
+	_, count, err := u.repo.ListUsers(ctx, ListUsersOption{
+		Email: ru.Email,
+		Limit: 1,
+	})
 	if err != nil {
 		return User{}, err
 	}
+	if count > 0 {
+		return User{}, ErrEmailAlreadyExists
+	}
 
-	user, err := u.CreateUser(ctx, User{
-		Name:  ru.Name,
-		Email: ru.Email,
-	})
+	uid, err := u.identityProvider.CreateUser(ctx, ru)
 	if err != nil {
 		return User{}, err
 	}
 
-	_, err = u.repo.CreateAuthUser(ctx, AuthUser{
-		UID:    uid,
-		UserID: user.ID,
+	var user User
+	err = u.repo.Transaction(ctx, func(tx Repository) error {
+		var err error
+		user, err = tx.CreateUser(ctx, User{
+			Name:  ru.Name,
+			Email: ru.Email,
+		})
+		// the unique index catches a concurrent sign-up the check above missed
+		if errors.Is(err, ErrAlreadyExists) {
+			return ErrEmailAlreadyExists
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.CreateAuthUser(ctx, AuthUser{
+			UID:        uid,
+			UserID:     user.ID,
+			GlobalRole: ru.GlobalRole,
+		})
+		return err
 	})
 	if err != nil {
+		// compensate even if the request was cancelled meanwhile
+		if derr := u.identityProvider.DeleteUser(context.WithoutCancel(ctx), uid); derr != nil {
+			return User{}, errors.Join(err, fmt.Errorf("delete identity %s: %w", uid, derr))
+		}
 		return User{}, err
 	}
 	return user, nil
 }
+
+// VerifyIDToken verifies the token with the identity provider and
+// resolves the auth user it belongs to.
+func (u Usecase) VerifyIDToken(ctx context.Context, token string) (AuthUser, error) {
+	uid, err := u.identityProvider.VerifyIDToken(ctx, token)
+	if err != nil {
+		return AuthUser{}, err
+	}
+	return u.repo.GetAuthUserByUID(ctx, uid)
+}
+
+func (u Usecase) GetAuthUserByUserID(ctx context.Context, id uuid.UUID) (AuthUser, error) {
+	return u.repo.GetAuthUserByUserID(ctx, id)
+}
+
+// PasswordCredential is a password hash kept by identity providers
+// that manage their own users.
+type PasswordCredential struct {
+	UID          string
+	Email        string
+	PasswordHash string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// RefreshToken is a server-side record of an issued refresh token.
+// Only the hash of the token is stored.
+type RefreshToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	UID       string
+	TokenHash string
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Session is the result of a login or a refresh.
+type Session struct {
+	User         User
+	AccessToken  string
+	RefreshToken string
+}
+
+func (u Usecase) Login(ctx context.Context, email, password string) (Session, error) {
+	uid, err := u.identityProvider.SignIn(ctx, email, password)
+	if err != nil {
+		return Session{}, err
+	}
+	au, err := u.repo.GetAuthUserByUID(ctx, uid)
+	if err != nil {
+		return Session{}, err
+	}
+	return u.newSession(ctx, au)
+}
+
+// RefreshSession exchanges a refresh token for a new access token.
+// The refresh token is rotated: the presented one is revoked and a
+// new one is returned.
+func (u Usecase) RefreshSession(ctx context.Context, refreshToken string) (Session, error) {
+	rt, err := u.repo.GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		return Session{}, ErrInvalidRefreshToken
+	}
+	if rt.RevokedAt != nil || rt.ExpiresAt.Before(time.Now()) {
+		return Session{}, ErrInvalidRefreshToken
+	}
+	if err := u.repo.RevokeRefreshToken(ctx, rt.ID); err != nil {
+		return Session{}, err
+	}
+
+	au, err := u.repo.GetAuthUserByUID(ctx, rt.UID)
+	if err != nil {
+		return Session{}, err
+	}
+	return u.newSession(ctx, au)
+}
+
+func (u Usecase) Logout(ctx context.Context, refreshToken string) error {
+	rt, err := u.repo.GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		return ErrInvalidRefreshToken
+	}
+	if rt.RevokedAt != nil {
+		return nil
+	}
+	return u.repo.RevokeRefreshToken(ctx, rt.ID)
+}
+
+func (u Usecase) newSession(ctx context.Context, au AuthUser) (Session, error) {
+	access, err := u.identityProvider.IssueToken(ctx, au.UID)
+	if err != nil {
+		return Session{}, err
+	}
+
+	refresh, err := newToken()
+	if err != nil {
+		return Session{}, err
+	}
+	_, err = u.repo.CreateRefreshToken(ctx, RefreshToken{
+		UserID:    au.UserID,
+		UID:       au.UID,
+		TokenHash: hashToken(refresh),
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+	})
+	if err != nil {
+		return Session{}, err
+	}
+
+	s := Session{
+		AccessToken:  access,
+		RefreshToken: refresh,
+	}
+	if au.User != nil {
+		s.User = *au.User
+	}
+	return s, nil
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
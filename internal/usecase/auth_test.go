@@ -0,0 +1,53 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// registerRepo has no users yet, but creating one fails the way a
+// concurrent sign-up with the same email does.
+type registerRepo struct {
+	Repository
+}
+
+func (r registerRepo) ListUsers(context.Context, ListUsersOption) ([]User, int, error) {
+	return nil, 0, nil
+}
+
+func (r registerRepo) Transaction(_ context.Context, fn func(Repository) error) error {
+	return fn(r)
+}
+
+func (r registerRepo) CreateUser(context.Context, User) (User, error) {
+	return User{}, ErrAlreadyExists
+}
+
+// recordingIdP creates identities with a fixed UID and records deletions.
+type recordingIdP struct {
+	IdentityProvider
+	deleted []string
+}
+
+func (p *recordingIdP) CreateUser(context.Context, RegisterUser) (string, error) {
+	return "uid-1", nil
+}
+
+func (p *recordingIdP) DeleteUser(_ context.Context, uid string) error {
+	p.deleted = append(p.deleted, uid)
+	return nil
+}
+
+func TestRegisterUserDeletesIdentityOnFailure(t *testing.T) {
+	idp := &recordingIdP{}
+	u := New(registerRepo{}, idp, nil)
+
+	_, err := u.RegisterUser(context.Background(), RegisterUser{Name: "Ada", Email: "ada@example.com", Password: "secret"})
+	if !errors.Is(err, ErrEmailAlreadyExists) {
+		t.Errorf("err = %v, want ErrEmailAlreadyExists", err)
+	}
+	if len(idp.deleted) != 1 || idp.deleted[0] != "uid-1" {
+		t.Errorf("deleted identities = %v, want [uid-1]", idp.deleted)
+	}
+}
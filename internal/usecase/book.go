@@ -2,22 +2,52 @@ package usecase
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const (
+	BookConditionNew     = "NEW"
+	BookConditionGood    = "GOOD"
+	BookConditionFair    = "FAIR"
+	BookConditionPoor    = "POOR"
+	BookConditionDamaged = "DAMAGED"
+	BookConditionLost    = "LOST"
+)
+
+const (
+	BookStatusAvailable = "AVAILABLE"
+	// BookStatusOnLoan books are lent out until DueAt.
+	BookStatusOnLoan = "ON_LOAN"
+	// BookStatusOnHold books are put aside for a ready hold.
+	BookStatusOnHold = "ON_HOLD"
+	BookStatusLost   = "LOST"
+)
+
+// Book is a physical copy of a Work. Title, Author, Year and the ISBNs
+// are read from the work.
 type Book struct {
 	ID        uuid.UUID
 	Title     string
 	Author    string
 	Year      int
+	ISBN13    string
+	ISBN10    string
 	Code      string
+	Location  string
+	Condition string
+	WorkID    uuid.UUID
 	LibraryID uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	DeletedAt *time.Time
 	Library   *Library
+
+	// Status and DueAt are set by ListBooks and GetBookByID.
+	Status string
+	DueAt  *time.Time
 }
 
 type ListBooksOption struct {
@@ -25,23 +55,141 @@ type ListBooksOption struct {
 	Limit      int
 	LibraryIDs uuid.UUIDs
 	IDs        uuid.UUIDs
+	WorkID     uuid.UUID
+	Codes      []string
 	Title      string
-	SortBy     string
-	SortIn     string
+	ISBN13     string
+	Query      string
+	// SubjectIDs lists the books whose work is classified under all of
+	// the subjects.
+	SubjectIDs uuid.UUIDs
+	// Available lists only books with BookStatusAvailable.
+	Available bool
+	SortBy    string
+	SortIn    string
+	// IncludeDeleted lists the soft-deleted books as well.
+	IncludeDeleted bool
 }
 
 func (u Usecase) ListBooks(ctx context.Context, opt ListBooksOption) ([]Book, int, error) {
 	return u.repo.ListBooks(ctx, opt)
 }
 
+// ExportBooks calls fn with every book matching the filters of opt. Skip,
+// Limit and the sort options are ignored.
+func (u Usecase) ExportBooks(ctx context.Context, opt ListBooksOption, fn func(Book) error) error {
+	return u.repo.ExportBooks(ctx, opt, fn)
+}
+
+// CreateBook adds a copy of the book's work. Without a work, one is
+// created from the book's title, author and year.
 func (u Usecase) CreateBook(ctx context.Context, book Book) (Book, error) {
-	return u.repo.CreateBook(ctx, book)
+	if book.Condition == "" {
+		book.Condition = BookConditionGood
+	}
+
+	var created Book
+	err := u.repo.Transaction(ctx, func(repo Repository) error {
+		if book.WorkID == uuid.Nil {
+			w, err := repo.CreateWork(ctx, Work{
+				Title:     book.Title,
+				Author:    book.Author,
+				Year:      book.Year,
+				ISBN13:    book.ISBN13,
+				ISBN10:    book.ISBN10,
+				LibraryID: book.LibraryID,
+			})
+			if err != nil {
+				return err
+			}
+			book.WorkID = w.ID
+		} else {
+			w, err := repo.GetWorkByID(ctx, book.WorkID)
+			if err != nil {
+				return err
+			}
+			if w.LibraryID != book.LibraryID {
+				return fmt.Errorf("%w: work belongs to another library", ErrInvalidArgument)
+			}
+		}
+
+		var err error
+		created, err = repo.CreateBook(ctx, book)
+		return err
+	})
+	if err != nil {
+		return Book{}, err
+	}
+
+	return created, nil
 }
 
 func (u Usecase) GetBookByID(ctx context.Context, id uuid.UUID) (Book, error) {
 	return u.repo.GetBookByID(ctx, id)
 }
 
+// UpdateBook updates the copy. An ISBN is set on its work, so scanning a
+// copy can fill in the ISBN of a work catalogued without one.
 func (u Usecase) UpdateBook(ctx context.Context, book Book) (Book, error) {
-	return u.repo.UpdateBook(ctx, book)
+	var updated Book
+	err := u.repo.Transaction(ctx, func(repo Repository) error {
+		b, err := repo.GetBookByID(ctx, book.ID)
+		if err != nil {
+			return err
+		}
+		if book.WorkID != uuid.Nil {
+			w, err := repo.GetWorkByID(ctx, book.WorkID)
+			if err != nil {
+				return err
+			}
+			if w.LibraryID != b.LibraryID {
+				return fmt.Errorf("%w: work belongs to another library", ErrInvalidArgument)
+			}
+			b.WorkID = w.ID
+		}
+		if book.ISBN13 != "" {
+			_, err := repo.UpdateWork(ctx, Work{ID: b.WorkID, ISBN13: book.ISBN13, ISBN10: book.ISBN10})
+			if err != nil {
+				return err
+			}
+		}
+
+		updated, err = repo.UpdateBook(ctx, book)
+		return err
+	})
+	if err != nil {
+		return Book{}, err
+	}
+
+	return updated, nil
+}
+
+// DeleteBook deletes the book unless it is on loan. The book is locked
+// first, so that it is not checked out meanwhile.
+func (u Usecase) DeleteBook(ctx context.Context, id uuid.UUID) error {
+	return u.repo.Transaction(ctx, func(repo Repository) error {
+		if err := repo.LockBook(ctx, id); err != nil {
+			return err
+		}
+		_, active, err := repo.ListBorrowings(ctx, ListBorrowingsOption{
+			Limit:    1,
+			BookID:   id.String(),
+			IsActive: true,
+		})
+		if err != nil {
+			return err
+		}
+		if active > 0 {
+			return fmt.Errorf("%w: book %s", ErrBookOnLoan, id)
+		}
+		return repo.DeleteBook(ctx, id)
+	})
+}
+
+func (u Usecase) RestoreBook(ctx context.Context, id uuid.UUID) error {
+	return u.repo.RestoreBook(ctx, id)
+}
+
+func (u Usecase) PurgeBook(ctx context.Context, id uuid.UUID) error {
+	return u.repo.PurgeBook(ctx, id)
 }
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,22 +10,35 @@ import (
 )
 
 type Borrowing struct {
-	ID             uuid.UUID
-	BookID         uuid.UUID
-	SubscriptionID uuid.UUID
-	StaffID        uuid.UUID
-	BorrowedAt     time.Time
-	DueAt          time.Time
-	ReturnedAt     *time.Time
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
-	DeletedAt      *time.Time
+	ID              uuid.UUID
+	BookID          uuid.UUID
+	SubscriptionID  uuid.UUID
+	StaffID         uuid.UUID
+	BorrowedAt      time.Time
+	DueAt           time.Time
+	ReturnedAt      *time.Time
+	ReturnedStaffID *uuid.UUID
+	Fine            int
+	RenewalCount    int
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeletedAt       *time.Time
 
 	Book         *Book
 	Subscription *Subscription
 	Staff        *Staff
 }
 
+// BorrowingRenewal records an extension of a borrowing's due date.
+type BorrowingRenewal struct {
+	ID            uuid.UUID
+	BorrowingID   uuid.UUID
+	StaffID       *uuid.UUID
+	PreviousDueAt time.Time
+	DueAt         time.Time
+	CreatedAt     time.Time
+}
+
 type ListBorrowingsOption struct {
 	Skip           int
 	Limit          int
@@ -37,11 +51,17 @@ type ListBorrowingsOption struct {
 	UserID       string
 	BorrowedAt   time.Time
 	DueAt        time.Time
-	ReturnedAt   *time.Time
-	IsActive     bool
-	IsExpired    bool
-	SortBy       string
-	SortIn       string
+	// DueBefore lists the borrowings due before it, overdue ones included.
+	DueBefore time.Time
+	// DueAfter lists the borrowings due after it.
+	DueAfter   time.Time
+	ReturnedAt *time.Time
+	IsActive   bool
+	IsExpired  bool
+	SortBy     string
+	SortIn     string
+	// IncludeDeleted lists the soft-deleted borrowings as well.
+	IncludeDeleted bool
 }
 
 func (u Usecase) ListBorrowings(ctx context.Context, opt ListBorrowingsOption) ([]Borrowing, int, error) {
@@ -52,85 +72,320 @@ func (u Usecase) GetBorrowingByID(ctx context.Context, id uuid.UUID) (Borrowing,
 	return u.repo.GetBorrowingByID(ctx, id)
 }
 
+// CreateBorrowing checks out a book in one transaction. The subscription
+// and the book are locked first, so concurrent checkouts of the same
+// subscription or book wait for each other instead of both passing the checks.
 func (u Usecase) CreateBorrowing(ctx context.Context, borrow Borrowing) (Borrowing, error) {
+	var bw Borrowing
+	err := u.repo.Transaction(ctx, func(repo Repository) error {
+		var err error
+		bw, err = createBorrowing(ctx, repo, borrow)
+		return err
+	})
+	if err != nil {
+		return Borrowing{}, err
+	}
+	return bw, nil
+}
+
+func createBorrowing(ctx context.Context, repo Repository, borrow Borrowing) (Borrowing, error) {
+	if err := repo.LockSubscription(ctx, borrow.SubscriptionID); err != nil {
+		return Borrowing{}, err
+	}
+	if err := repo.LockBook(ctx, borrow.BookID); err != nil {
+		return Borrowing{}, err
+	}
 
-	// 1. Check if the membership subscription is still active
-	s, err := u.repo.GetSubscriptionByID(ctx, borrow.SubscriptionID)
+	// 1. Check if the membership subscription is still active and not suspended
+	s, err := repo.GetSubscriptionByID(ctx, borrow.SubscriptionID)
 	if err != nil {
 		return Borrowing{}, err
 	}
-	// TODO: ErrMembershipExpired
 	if s.ExpiresAt.Before(time.Now()) {
-		fmt.Println(s.ExpiresAt, time.Now())
-		return Borrowing{}, fmt.Errorf("membership subscription %s expired", s.ID)
+		return Borrowing{}, fmt.Errorf("%w: subscription %s expired at %s", ErrMembershipExpired, s.ID, s.ExpiresAt.Format(time.RFC3339))
+	}
+	if s.SuspendedAt != nil {
+		return Borrowing{}, suspendedError(s)
 	}
 
 	// 2. Check if the user has reached the maximum borrowing limit
-	_, activeCount, err := u.repo.ListBorrowings(ctx, ListBorrowingsOption{
+	_, activeCount, err := repo.ListBorrowings(ctx, ListBorrowingsOption{
 		SubscriptionID: s.ID.String(),
 		IsActive:       true,
 	})
 	if err != nil {
 		return Borrowing{}, err
 	}
-	// TODO: ErrActiveLoanLimitReached
 	if s.ActiveLoanLimit <= activeCount {
-		return Borrowing{}, fmt.Errorf("user %s has reached the active loan limit", s.UserID)
+		return Borrowing{}, fmt.Errorf("%w: user %s has %d active loans", ErrActiveLoanLimitReached, s.UserID, activeCount)
 	}
 
 	// 3. Check if the book is available
-	_, count, err := u.repo.ListBorrowings(ctx, ListBorrowingsOption{
+	_, count, err := repo.ListBorrowings(ctx, ListBorrowingsOption{
 		BookID:   borrow.BookID.String(),
 		IsActive: true,
 	})
 	if err != nil {
 		return Borrowing{}, err
 	}
-	// TODO: ErrBookNotAvailable
 	if count > 0 {
-		return Borrowing{}, fmt.Errorf("book %s is not available", borrow.BookID)
+		return Borrowing{}, fmt.Errorf("%w: book %s is already borrowed", ErrBookNotAvailable, borrow.BookID)
+	}
+
+	// 4. Check if the book is put aside for another member
+	holds, _, err := repo.ListHolds(ctx, ListHoldsOption{
+		Limit:    1,
+		BookID:   borrow.BookID.String(),
+		Statuses: []string{HoldStatusReady},
+	})
+	if err != nil {
+		return Borrowing{}, err
+	}
+	if len(holds) > 0 && holds[0].SubscriptionID != borrow.SubscriptionID {
+		return Borrowing{}, fmt.Errorf("%w: book %s", ErrBookOnHold, borrow.BookID)
 	}
 
-	// 4. Check if the book is in the same library
-	book, err := u.repo.GetBookByID(ctx, borrow.BookID)
+	// 5. Check if the book is in the same library
+	book, err := repo.GetBookByID(ctx, borrow.BookID)
 	if err != nil {
 		return Borrowing{}, err
 	}
-	m, err := u.repo.GetMembershipByID(ctx, s.MembershipID)
+	m, err := repo.GetMembershipByID(ctx, s.MembershipID)
 	if err != nil {
 		return Borrowing{}, err
 	}
-	// TODO: ErrBookNotAvailable
 	if book.LibraryID != m.LibraryID {
-		return Borrowing{}, fmt.Errorf("book %s is not in library %s", book.ID, m.LibraryID)
+		return Borrowing{}, fmt.Errorf("%w: book %s is not in library %s", ErrBookNotAvailable, book.ID, m.LibraryID)
 	}
 
-	// 5. Check if staff exists
-	staff, err := u.repo.GetStaffByID(ctx, borrow.StaffID)
+	// 6. Check if staff exists
+	staff, err := repo.GetStaffByID(ctx, borrow.StaffID)
 	if err != nil {
 		return Borrowing{}, err
 	}
 	if staff.LibraryID != m.LibraryID {
-		return Borrowing{}, fmt.Errorf("staff %s is not from library %s", staff.ID, m.LibraryID)
+		return Borrowing{}, fmt.Errorf("%w: staff %s, library %s", ErrStaffNotInLibrary, staff.ID, m.LibraryID)
+	}
+
+	// 7. Check if the user owes the library more than it allows
+	setting, err := getSetting(ctx, repo, m.LibraryID)
+	if err != nil {
+		return Borrowing{}, err
+	}
+	if setting.MaxOutstandingFine != nil {
+		bal, err := repo.GetFineBalance(ctx, s.UserID, m.LibraryID)
+		if err != nil {
+			return Borrowing{}, err
+		}
+		if bal.Outstanding > *setting.MaxOutstandingFine {
+			return Borrowing{}, fmt.Errorf("%w: user %s owes %d, limit %d", ErrOutstandingFines, s.UserID, bal.Outstanding, *setting.MaxOutstandingFine)
+		}
 	}
 
-	// 6. All checks passed, create borrowing
+	// 8. All checks passed, create borrowing
 	// Set the borrowed at time if not set
 	if borrow.BorrowedAt.IsZero() {
 		borrow.BorrowedAt = time.Now()
 	}
-	// Set the due at time if not set
-	if borrow.DueAt.IsZero() {
-		borrow.DueAt = time.Now().AddDate(0, 0, s.LoanPeriod)
-	}
+	// The loan period is the subscription's, and loans are only closed
+	// by ReturnBorrowing
+	borrow.DueAt = time.Now().AddDate(0, 0, s.LoanPeriod)
+	borrow.ReturnedAt = nil
 
-	bw, err := u.repo.CreateBorrowing(ctx, borrow)
+	bw, err := repo.CreateBorrowing(ctx, borrow)
+	// the partial unique index on active borrowings of a book
+	if errors.Is(err, ErrAlreadyExists) {
+		return Borrowing{}, fmt.Errorf("%w: book %s is already borrowed", ErrBookNotAvailable, borrow.BookID)
+	}
 	if err != nil {
 		return Borrowing{}, err
 	}
+
+	// The member picked up the book put aside for them
+	if len(holds) > 0 {
+		if _, err := closeHold(ctx, repo, holds[0], HoldStatusFulfilled, &bw.ID); err != nil {
+			return Borrowing{}, err
+		}
+	}
 	return bw, nil
 }
 
+// UpdateBorrowing corrects the borrowed time of a borrowing. Its book,
+// subscription and staff are fixed at checkout, and its due date and
+// return only change through RenewBorrowing and ReturnBorrowing.
 func (u Usecase) UpdateBorrowing(ctx context.Context, borrow Borrowing) (Borrowing, error) {
-	return u.repo.UpdateBorrowing(ctx, borrow)
+	return u.repo.UpdateBorrowing(ctx, Borrowing{
+		ID:         borrow.ID,
+		BorrowedAt: borrow.BorrowedAt,
+	})
+}
+
+// DeleteBorrowing deletes a returned borrowing. Unreturned borrowings
+// must be returned first, as they keep their book on loan.
+func (u Usecase) DeleteBorrowing(ctx context.Context, id uuid.UUID) error {
+	b, err := u.repo.GetBorrowingByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if b.ReturnedAt == nil {
+		return fmt.Errorf("%w: borrowing %s", ErrBookOnLoan, b.ID)
+	}
+	return u.repo.DeleteBorrowing(ctx, id)
+}
+
+func (u Usecase) RestoreBorrowing(ctx context.Context, id uuid.UUID) error {
+	return u.repo.RestoreBorrowing(ctx, id)
+}
+
+func (u Usecase) PurgeBorrowing(ctx context.Context, id uuid.UUID) error {
+	return u.repo.PurgeBorrowing(ctx, id)
+}
+
+// ReturnBorrowing closes an active borrowing, received by the staff, and
+// charges the overdue fine at the subscription's FinePerDay to the ledger.
+func (u Usecase) ReturnBorrowing(ctx context.Context, id, staffID uuid.UUID) (Borrowing, error) {
+	b, err := u.repo.GetBorrowingByID(ctx, id)
+	if err != nil {
+		return Borrowing{}, err
+	}
+	if b.ReturnedAt != nil {
+		return Borrowing{}, fmt.Errorf("%w: borrowing %s returned at %s", ErrAlreadyReturned, b.ID, b.ReturnedAt.Format(time.RFC3339))
+	}
+
+	book, err := u.repo.GetBookByID(ctx, b.BookID)
+	if err != nil {
+		return Borrowing{}, err
+	}
+	staff, err := u.repo.GetStaffByID(ctx, staffID)
+	if err != nil {
+		return Borrowing{}, err
+	}
+	if staff.LibraryID != book.LibraryID {
+		return Borrowing{}, fmt.Errorf("%w: staff %s, library %s", ErrStaffNotInLibrary, staff.ID, book.LibraryID)
+	}
+
+	sub, err := u.repo.GetSubscriptionByID(ctx, b.SubscriptionID)
+	if err != nil {
+		return Borrowing{}, err
+	}
+
+	now := time.Now()
+	b.ReturnedAt = &now
+	b.ReturnedStaffID = &staff.ID
+	b.Fine = overdueFine(b.DueAt, now, sub.FinePerDay)
+
+	var returned Borrowing
+	err = u.repo.Transaction(ctx, func(repo Repository) error {
+		if err := repo.LockBook(ctx, b.BookID); err != nil {
+			return err
+		}
+		returned, err = repo.ReturnBorrowing(ctx, b)
+		if err != nil {
+			return err
+		}
+		if b.Fine > 0 {
+			if _, err := repo.UpsertFineCharge(ctx, fineCharge(b, book, sub, b.Fine)); err != nil {
+				return err
+			}
+		}
+		// put the book aside for the next member in line
+		_, err = promoteHold(ctx, repo, b.BookID)
+		return err
+	})
+	if err != nil {
+		return Borrowing{}, err
+	}
+	return returned, nil
+}
+
+// RenewBorrowing extends an active borrowing by the subscription's
+// LoanPeriod, up to its MaxRenewals. Overdue borrowings, borrowings of
+// suspended subscriptions and books other members wait for cannot be
+// renewed.
+// staffID is nil when members renew their own borrowing.
+func (u Usecase) RenewBorrowing(ctx context.Context, id uuid.UUID, staffID *uuid.UUID) (Borrowing, error) {
+	var renewed Borrowing
+	err := u.repo.Transaction(ctx, func(repo Repository) error {
+		if err := repo.LockBorrowing(ctx, id); err != nil {
+			return err
+		}
+		b, err := repo.GetBorrowingByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if b.ReturnedAt != nil {
+			return fmt.Errorf("%w: borrowing %s returned at %s", ErrAlreadyReturned, b.ID, b.ReturnedAt.Format(time.RFC3339))
+		}
+		now := time.Now()
+		if b.DueAt.Before(now) {
+			return fmt.Errorf("%w: borrowing %s was due at %s", ErrBorrowingOverdue, b.ID, b.DueAt.Format(time.RFC3339))
+		}
+
+		sub, err := repo.GetSubscriptionByID(ctx, b.SubscriptionID)
+		if err != nil {
+			return err
+		}
+		if sub.ExpiresAt.Before(now) {
+			return fmt.Errorf("%w: subscription %s expired at %s", ErrMembershipExpired, sub.ID, sub.ExpiresAt.Format(time.RFC3339))
+		}
+		if sub.SuspendedAt != nil {
+			return suspendedError(sub)
+		}
+		if b.RenewalCount >= sub.MaxRenewals {
+			return fmt.Errorf("%w: borrowing %s was renewed %d times", ErrRenewalLimitReached, b.ID, b.RenewalCount)
+		}
+		_, waiting, err := repo.ListHolds(ctx, ListHoldsOption{
+			BookID:   b.BookID.String(),
+			Statuses: []string{HoldStatusWaiting},
+		})
+		if err != nil {
+			return err
+		}
+		if waiting > 0 {
+			return fmt.Errorf("%w: %d members wait for book %s", ErrBookOnHold, waiting, b.BookID)
+		}
+
+		if staffID != nil {
+			book, err := repo.GetBookByID(ctx, b.BookID)
+			if err != nil {
+				return err
+			}
+			staff, err := repo.GetStaffByID(ctx, *staffID)
+			if err != nil {
+				return err
+			}
+			if staff.LibraryID != book.LibraryID {
+				return fmt.Errorf("%w: staff %s, library %s", ErrStaffNotInLibrary, staff.ID, book.LibraryID)
+			}
+		}
+
+		renewed, err = repo.RenewBorrowing(ctx, BorrowingRenewal{
+			BorrowingID:   b.ID,
+			StaffID:       staffID,
+			PreviousDueAt: b.DueAt,
+			DueAt:         b.DueAt.AddDate(0, 0, sub.LoanPeriod),
+		})
+		return err
+	})
+	if err != nil {
+		return Borrowing{}, err
+	}
+	return renewed, nil
+}
+
+func (u Usecase) ListBorrowingRenewals(ctx context.Context, id uuid.UUID) ([]BorrowingRenewal, error) {
+	if _, err := u.repo.GetBorrowingByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return u.repo.ListBorrowingRenewals(ctx, id)
+}
+
+// overdueFine charges finePerDay for every started day past dueAt.
+func overdueFine(dueAt, returnedAt time.Time, finePerDay int) int {
+	late := returnedAt.Sub(dueAt)
+	if late <= 0 {
+		return 0
+	}
+	day := 24 * time.Hour
+	return int((late+day-1)/day) * finePerDay
 }
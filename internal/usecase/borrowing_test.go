@@ -0,0 +1,31 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOverdueFine(t *testing.T) {
+	due := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		returned time.Time
+		want     int
+	}{
+		{"early", due.Add(-48 * time.Hour), 0},
+		{"on time", due, 0},
+		{"one minute late", due.Add(time.Minute), 500},
+		{"exactly one day late", due.Add(24 * time.Hour), 500},
+		{"a day and an hour late", due.Add(25 * time.Hour), 1000},
+		{"a week late", due.AddDate(0, 0, 7), 3500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := overdueFine(due, tt.returned, 500); got != tt.want {
+				t.Errorf("overdueFine() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned by the usecase layer and by Repository implementations.
+// Wrap them with fmt.Errorf("%w: ...") to add context and match them
+// with errors.Is.
+var (
+	ErrNotFound          = errors.New("not found")
+	ErrAlreadyExists     = errors.New("already exists")
+	ErrReferenceNotFound = errors.New("referenced record not found")
+	ErrInvalidArgument   = errors.New("invalid argument")
+	// ErrInUse is returned when records still reference the one to delete.
+	ErrInUse = errors.New("in use")
+
+	ErrEmailAlreadyExists  = fmt.Errorf("email %w", ErrAlreadyExists)
+	ErrInvalidISBN         = fmt.Errorf("%w: isbn is malformed or its check digit is wrong", ErrInvalidArgument)
+	ErrInvalidCredentials  = errors.New("invalid email or password")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrMembershipInUse     = fmt.Errorf("membership %w by active subscriptions", ErrInUse)
+	ErrWorkInUse           = fmt.Errorf("work %w by books", ErrInUse)
+	ErrBookOnLoan          = fmt.Errorf("book %w by an unreturned borrowing", ErrInUse)
+	ErrLibraryOnLoan       = fmt.Errorf("library %w by unreturned borrowings", ErrInUse)
+	ErrSubscriptionOnLoan  = fmt.Errorf("subscription %w by unreturned borrowings", ErrInUse)
+
+	ErrMembershipExpired      = errors.New("membership expired")
+	ErrActiveLoanLimitReached = errors.New("active loan limit reached")
+	ErrBookNotAvailable       = errors.New("book is not available")
+	ErrStaffNotInLibrary      = errors.New("staff is not from the library")
+	ErrAlreadyReturned        = errors.New("borrowing is already returned")
+	ErrOutstandingFines       = errors.New("outstanding fines exceed the library limit")
+	ErrBorrowingOverdue       = errors.New("borrowing is overdue")
+	ErrRenewalLimitReached    = errors.New("renewal limit reached")
+	ErrBookOnHold             = errors.New("book is on hold for another member")
+	ErrHoldClosed             = errors.New("hold is closed")
+	ErrSubscriptionSuspended  = errors.New("subscription is suspended")
+)
@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	FineKindCharge  = "CHARGE"
+	FineKindPayment = "PAYMENT"
+	FineKindWaiver  = "WAIVER"
+)
+
+// FineEntry is a line of the fines ledger. Charges add to what a user
+// owes a library, payments and waivers settle it. A borrowing has at
+// most one charge, updated as its fine grows.
+type FineEntry struct {
+	ID          uuid.UUID
+	Kind        string
+	Amount      int
+	UserID      uuid.UUID
+	LibraryID   uuid.UUID
+	BorrowingID *uuid.UUID
+	StaffID     *uuid.UUID
+	Note        string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+
+	User      *User
+	Library   *Library
+	Borrowing *Borrowing
+	Staff     *Staff
+}
+
+type ListFineEntriesOption struct {
+	Skip        int
+	Limit       int
+	UserID      string
+	LibraryID   string
+	BorrowingID string
+	Kind        string
+	SortBy      string
+	SortIn      string
+}
+
+// FineBalance sums the ledger of a user at a library.
+type FineBalance struct {
+	UserID      uuid.UUID
+	LibraryID   uuid.UUID
+	Charged     int
+	Paid        int
+	Waived      int
+	Outstanding int
+}
+
+func (u Usecase) ListFineEntries(ctx context.Context, opt ListFineEntriesOption) ([]FineEntry, int, error) {
+	return u.repo.ListFineEntries(ctx, opt)
+}
+
+func (u Usecase) GetFineBalance(ctx context.Context, userID, libraryID uuid.UUID) (FineBalance, error) {
+	return u.repo.GetFineBalance(ctx, userID, libraryID)
+}
+
+// PayFine records a payment taken by staff against the outstanding balance.
+func (u Usecase) PayFine(ctx context.Context, e FineEntry) (FineEntry, error) {
+	e.Kind = FineKindPayment
+	return u.settleFine(ctx, e)
+}
+
+// WaiveFine records a waiver granted by staff against the outstanding balance.
+func (u Usecase) WaiveFine(ctx context.Context, e FineEntry) (FineEntry, error) {
+	e.Kind = FineKindWaiver
+	return u.settleFine(ctx, e)
+}
+
+func (u Usecase) settleFine(ctx context.Context, e FineEntry) (FineEntry, error) {
+	if e.Amount <= 0 {
+		return FineEntry{}, fmt.Errorf("%w: amount must be positive", ErrInvalidArgument)
+	}
+	if e.StaffID == nil {
+		return FineEntry{}, fmt.Errorf("%w: staff is required", ErrInvalidArgument)
+	}
+	staff, err := u.repo.GetStaffByID(ctx, *e.StaffID)
+	if err != nil {
+		return FineEntry{}, err
+	}
+	if staff.LibraryID != e.LibraryID {
+		return FineEntry{}, fmt.Errorf("%w: staff %s, library %s", ErrStaffNotInLibrary, staff.ID, e.LibraryID)
+	}
+
+	var entry FineEntry
+	err = u.repo.Transaction(ctx, func(repo Repository) error {
+		// concurrent settlements would both fit in the balance otherwise
+		if err := repo.LockFineBalance(ctx, e.UserID, e.LibraryID); err != nil {
+			return err
+		}
+		bal, err := repo.GetFineBalance(ctx, e.UserID, e.LibraryID)
+		if err != nil {
+			return err
+		}
+		if e.Amount > bal.Outstanding {
+			return fmt.Errorf("%w: amount %d exceeds the outstanding balance %d", ErrInvalidArgument, e.Amount, bal.Outstanding)
+		}
+		entry, err = repo.CreateFineEntry(ctx, e)
+		return err
+	})
+	if err != nil {
+		return FineEntry{}, err
+	}
+	return entry, nil
+}
+
+// AccrueFines brings the charge of every overdue, unreturned borrowing
+// up to date and returns how many were charged.
+func (u Usecase) AccrueFines(ctx context.Context) (int, error) {
+	const pageSize = 100
+	now := time.Now()
+
+	var charged int
+	for skip := 0; ; skip += pageSize {
+		borrows, _, err := u.repo.ListBorrowings(ctx, ListBorrowingsOption{
+			Skip:      skip,
+			Limit:     pageSize,
+			IsExpired: true,
+			SortBy:    "due_at",
+			SortIn:    "asc",
+		})
+		if err != nil {
+			return charged, err
+		}
+
+		for _, b := range borrows {
+			if b.Book == nil || b.Subscription == nil {
+				continue
+			}
+			amount := overdueFine(b.DueAt, now, b.Subscription.FinePerDay)
+			if amount == 0 {
+				continue
+			}
+			_, err := u.repo.UpsertFineCharge(ctx, fineCharge(b, *b.Book, *b.Subscription, amount))
+			if err != nil {
+				return charged, err
+			}
+			charged++
+		}
+
+		if len(borrows) < pageSize {
+			return charged, nil
+		}
+	}
+}
+
+func fineCharge(b Borrowing, book Book, sub Subscription, amount int) FineEntry {
+	return FineEntry{
+		Kind:        FineKindCharge,
+		Amount:      amount,
+		UserID:      sub.UserID,
+		LibraryID:   book.LibraryID,
+		BorrowingID: &b.ID,
+	}
+}
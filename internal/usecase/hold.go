@@ -0,0 +1,279 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// HoldStatusWaiting holds are queued behind the current borrowing.
+	HoldStatusWaiting = "WAITING"
+	// HoldStatusReady holds have the book put aside until ExpiresAt.
+	HoldStatusReady     = "READY"
+	HoldStatusFulfilled = "FULFILLED"
+	HoldStatusCancelled = "CANCELLED"
+	HoldStatusExpired   = "EXPIRED"
+
+	defaultHoldPickupDays = 3
+)
+
+// Hold is a member's reservation of a book. Holds on a book are served
+// first come, first served.
+type Hold struct {
+	ID             uuid.UUID
+	BookID         uuid.UUID
+	SubscriptionID uuid.UUID
+	// StaffID is set when staff placed the hold on behalf of the member.
+	StaffID *uuid.UUID
+	Status  string
+	// ReadyAt is when the book was put aside, ExpiresAt the pickup deadline.
+	ReadyAt     *time.Time
+	ExpiresAt   *time.Time
+	ClosedAt    *time.Time
+	BorrowingID *uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+
+	Book         *Book
+	Subscription *Subscription
+}
+
+type ListHoldsOption struct {
+	Skip           int
+	Limit          int
+	BookID         string
+	SubscriptionID string
+	UserID         string
+	LibraryID      string
+	Statuses       []string
+	// IsExpired lists ready holds past their pickup deadline.
+	IsExpired bool
+	// SubscriptionExpired lists holds of subscriptions that expired.
+	SubscriptionExpired bool
+	SortBy              string
+	SortIn              string
+}
+
+func (u Usecase) ListHolds(ctx context.Context, opt ListHoldsOption) ([]Hold, int, error) {
+	return u.repo.ListHolds(ctx, opt)
+}
+
+func (u Usecase) GetHoldByID(ctx context.Context, id uuid.UUID) (Hold, error) {
+	return u.repo.GetHoldByID(ctx, id)
+}
+
+// PlaceHold queues the member for the book. When nobody has the book,
+// the hold is ready for pickup right away.
+func (u Usecase) PlaceHold(ctx context.Context, h Hold) (Hold, error) {
+	var hold Hold
+	err := u.repo.Transaction(ctx, func(repo Repository) error {
+		if err := repo.LockSubscription(ctx, h.SubscriptionID); err != nil {
+			return err
+		}
+		if err := repo.LockBook(ctx, h.BookID); err != nil {
+			return err
+		}
+
+		s, err := repo.GetSubscriptionByID(ctx, h.SubscriptionID)
+		if err != nil {
+			return err
+		}
+		if s.ExpiresAt.Before(time.Now()) {
+			return fmt.Errorf("%w: subscription %s expired at %s", ErrMembershipExpired, s.ID, s.ExpiresAt.Format(time.RFC3339))
+		}
+		if s.SuspendedAt != nil {
+			return suspendedError(s)
+		}
+		book, err := repo.GetBookByID(ctx, h.BookID)
+		if err != nil {
+			return err
+		}
+		m, err := repo.GetMembershipByID(ctx, s.MembershipID)
+		if err != nil {
+			return err
+		}
+		if book.LibraryID != m.LibraryID {
+			return fmt.Errorf("%w: book %s is not in library %s", ErrBookNotAvailable, book.ID, m.LibraryID)
+		}
+		if h.StaffID != nil {
+			staff, err := repo.GetStaffByID(ctx, *h.StaffID)
+			if err != nil {
+				return err
+			}
+			if staff.LibraryID != m.LibraryID {
+				return fmt.Errorf("%w: staff %s, library %s", ErrStaffNotInLibrary, staff.ID, m.LibraryID)
+			}
+		}
+
+		_, count, err := repo.ListBorrowings(ctx, ListBorrowingsOption{
+			BookID:         h.BookID.String(),
+			SubscriptionID: h.SubscriptionID.String(),
+			IsActive:       true,
+		})
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("%w: subscription %s already borrows book %s", ErrInvalidArgument, h.SubscriptionID, h.BookID)
+		}
+
+		h.Status = HoldStatusWaiting
+		hold, err = repo.CreateHold(ctx, h)
+		if err != nil {
+			return err
+		}
+		promoted, err := promoteHold(ctx, repo, h.BookID)
+		if err != nil {
+			return err
+		}
+		if promoted.ID == hold.ID {
+			hold = promoted
+		}
+		return nil
+	})
+	if err != nil {
+		return Hold{}, err
+	}
+	return hold, nil
+}
+
+// CancelHold withdraws a waiting or ready hold and passes the book on
+// to the next member in line.
+func (u Usecase) CancelHold(ctx context.Context, id uuid.UUID) (Hold, error) {
+	var hold Hold
+	err := u.repo.Transaction(ctx, func(repo Repository) error {
+		h, err := repo.GetHoldByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := repo.LockBook(ctx, h.BookID); err != nil {
+			return err
+		}
+		// read again, it may have changed while waiting for the lock
+		h, err = repo.GetHoldByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		hold, err = closeHold(ctx, repo, h, HoldStatusCancelled, nil)
+		if err != nil {
+			return err
+		}
+		_, err = promoteHold(ctx, repo, h.BookID)
+		return err
+	})
+	if err != nil {
+		return Hold{}, err
+	}
+	return hold, nil
+}
+
+// ExpireHolds closes the ready holds whose pickup deadline passed and
+// advances their queues. It returns how many holds expired.
+func (u Usecase) ExpireHolds(ctx context.Context) (int, error) {
+	const pageSize = 100
+
+	var expired int
+	for {
+		holds, _, err := u.repo.ListHolds(ctx, ListHoldsOption{
+			Limit:     pageSize,
+			IsExpired: true,
+			SortBy:    "expires_at",
+			SortIn:    "asc",
+		})
+		if err != nil {
+			return expired, err
+		}
+
+		for _, h := range holds {
+			err := u.repo.Transaction(ctx, func(repo Repository) error {
+				if err := repo.LockBook(ctx, h.BookID); err != nil {
+					return err
+				}
+				h, err := repo.GetHoldByID(ctx, h.ID)
+				if err != nil {
+					return err
+				}
+				// picked up or cancelled in the meantime
+				if h.Status != HoldStatusReady || h.ExpiresAt.After(time.Now()) {
+					return nil
+				}
+				if _, err := closeHold(ctx, repo, h, HoldStatusExpired, nil); err != nil {
+					return err
+				}
+				expired++
+				_, err = promoteHold(ctx, repo, h.BookID)
+				return err
+			})
+			if err != nil {
+				return expired, err
+			}
+		}
+
+		if len(holds) < pageSize {
+			return expired, nil
+		}
+	}
+}
+
+// promoteHold puts the book aside for the first waiting hold, unless it
+// is lent or already ready for someone. It returns the promoted hold,
+// if any. Callers hold the book lock.
+func promoteHold(ctx context.Context, repo Repository, bookID uuid.UUID) (Hold, error) {
+	_, lent, err := repo.ListBorrowings(ctx, ListBorrowingsOption{
+		BookID:   bookID.String(),
+		IsActive: true,
+	})
+	if err != nil || lent > 0 {
+		return Hold{}, err
+	}
+
+	holds, _, err := repo.ListHolds(ctx, ListHoldsOption{
+		Limit:    1,
+		BookID:   bookID.String(),
+		Statuses: []string{HoldStatusWaiting, HoldStatusReady},
+		SortBy:   "created_at",
+		SortIn:   "asc",
+	})
+	if err != nil || len(holds) == 0 {
+		return Hold{}, err
+	}
+	// the first hold in line is either waiting for the book or has it
+	h := holds[0]
+	if h.Status == HoldStatusReady {
+		return Hold{}, nil
+	}
+
+	book, err := repo.GetBookByID(ctx, bookID)
+	if err != nil {
+		return Hold{}, err
+	}
+	setting, err := getSetting(ctx, repo, book.LibraryID)
+	if err != nil {
+		return Hold{}, err
+	}
+	days := defaultHoldPickupDays
+	if setting.HoldPickupDays != nil {
+		days = *setting.HoldPickupDays
+	}
+
+	now := time.Now()
+	expiresAt := now.AddDate(0, 0, days)
+	h.Status = HoldStatusReady
+	h.ReadyAt = &now
+	h.ExpiresAt = &expiresAt
+	return repo.UpdateHold(ctx, h)
+}
+
+func closeHold(ctx context.Context, repo Repository, h Hold, status string, borrowingID *uuid.UUID) (Hold, error) {
+	if h.Status != HoldStatusWaiting && h.Status != HoldStatusReady {
+		return Hold{}, fmt.Errorf("%w: hold %s is %s", ErrHoldClosed, h.ID, h.Status)
+	}
+	now := time.Now()
+	h.Status = status
+	h.ClosedAt = &now
+	h.BorrowingID = borrowingID
+	return repo.UpdateHold(ctx, h)
+}
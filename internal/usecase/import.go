@@ -0,0 +1,219 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ImportRow is a book as read from a catalogue file, before validation.
+// Row is its line or record number in the file.
+type ImportRow struct {
+	Row       int
+	Title     string
+	Author    string
+	Year      string
+	ISBN      string
+	Code      string
+	Location  string
+	Condition string
+}
+
+type ImportBooksOption struct {
+	LibraryID uuid.UUID
+	// DryRun validates the rows without creating anything.
+	DryRun bool
+}
+
+type ImportError struct {
+	Row     int
+	Field   string
+	Message string
+}
+
+// ImportResult reports what an import created, or would create on a dry
+// run. Nothing is created when any row has errors.
+type ImportResult struct {
+	Rows         int
+	WorksCreated int
+	BooksCreated int
+	DryRun       bool
+	Errors       []ImportError
+}
+
+var bookConditions = []string{
+	BookConditionNew,
+	BookConditionGood,
+	BookConditionFair,
+	BookConditionPoor,
+	BookConditionDamaged,
+	BookConditionLost,
+}
+
+// importLookupSize bounds the codes and ISBNs looked up per query.
+const importLookupSize = 1000
+
+// ImportBooks validates every row and, unless it is a dry run or a row is
+// invalid, creates the copies in one transaction. A row is added as a
+// copy of the library's work with the same ISBN; other rows become new
+// works, one per ISBN, or per title, author and year when there is none.
+func (u Usecase) ImportBooks(ctx context.Context, opt ImportBooksOption, rows []ImportRow) (ImportResult, error) {
+	res := ImportResult{Rows: len(rows), DryRun: opt.DryRun}
+
+	if _, err := u.repo.GetLibraryByID(ctx, opt.LibraryID.String()); err != nil {
+		return ImportResult{}, err
+	}
+
+	fail := func(row int, field, msg string) {
+		res.Errors = append(res.Errors, ImportError{Row: row, Field: field, Message: msg})
+	}
+
+	var (
+		books    = make([]Book, len(rows))
+		codeRows = make(map[string]int)
+		codes    []string
+		isbns    []string
+	)
+	for i, r := range rows {
+		b := Book{
+			Title:     r.Title,
+			Author:    r.Author,
+			Code:      r.Code,
+			Location:  r.Location,
+			Condition: strings.ToUpper(r.Condition),
+			LibraryID: opt.LibraryID,
+		}
+
+		if r.Year != "" {
+			year, err := strconv.Atoi(r.Year)
+			if err != nil || year < 1500 {
+				fail(r.Row, "year", "must be a year from 1500")
+			}
+			b.Year = year
+		}
+		if r.ISBN != "" {
+			isbn13, isbn10, err := ParseISBN(r.ISBN)
+			if err != nil {
+				fail(r.Row, "isbn", "is malformed or its check digit is wrong")
+			}
+			b.ISBN13, b.ISBN10 = isbn13, isbn10
+		}
+		if b.Condition == "" {
+			b.Condition = BookConditionGood
+		}
+		if !slices.Contains(bookConditions, b.Condition) {
+			fail(r.Row, "condition", "must be one of "+strings.Join(bookConditions, ", "))
+		}
+		switch prev, dup := codeRows[r.Code]; {
+		case r.Code == "":
+			fail(r.Row, "code", "is required")
+		case dup:
+			fail(r.Row, "code", fmt.Sprintf("is used by row %d too", prev))
+		default:
+			codeRows[r.Code] = r.Row
+			codes = append(codes, r.Code)
+		}
+
+		books[i] = b
+		if b.ISBN13 != "" {
+			isbns = append(isbns, b.ISBN13)
+		}
+	}
+
+	for chunk := range slices.Chunk(codes, importLookupSize) {
+		existing, _, err := u.repo.ListBooks(ctx, ListBooksOption{
+			LibraryIDs: uuid.UUIDs{opt.LibraryID},
+			Codes:      chunk,
+			Limit:      len(chunk),
+		})
+		if err != nil {
+			return ImportResult{}, err
+		}
+		for _, b := range existing {
+			fail(codeRows[b.Code], "code", "is already used in the library")
+		}
+	}
+
+	workIDs := make(map[string]uuid.UUID)
+	for chunk := range slices.Chunk(isbns, importLookupSize) {
+		existing, _, err := u.repo.ListWorks(ctx, ListWorksOption{
+			LibraryIDs: uuid.UUIDs{opt.LibraryID},
+			ISBN13s:    chunk,
+			Limit:      len(chunk),
+		})
+		if err != nil {
+			return ImportResult{}, err
+		}
+		for _, w := range existing {
+			workIDs[w.ISBN13] = w.ID
+		}
+	}
+
+	var (
+		works    []Work
+		workKeys = make(map[string]int)
+		bookWork = make([]int, len(rows))
+	)
+	for i, b := range books {
+		bookWork[i] = -1
+		if id, ok := workIDs[b.ISBN13]; ok {
+			books[i].WorkID = id
+			continue
+		}
+		if b.Title == "" {
+			fail(rows[i].Row, "title", "is required unless the isbn matches a work of the library")
+			continue
+		}
+
+		key := b.ISBN13
+		if key == "" {
+			key = strings.ToLower(b.Title) + "\x00" + strings.ToLower(b.Author) + "\x00" + strconv.Itoa(b.Year)
+		}
+		n, ok := workKeys[key]
+		if !ok {
+			n = len(works)
+			workKeys[key] = n
+			works = append(works, Work{
+				Title:     b.Title,
+				Author:    b.Author,
+				Year:      b.Year,
+				ISBN13:    b.ISBN13,
+				ISBN10:    b.ISBN10,
+				LibraryID: opt.LibraryID,
+			})
+		}
+		bookWork[i] = n
+	}
+
+	if len(res.Errors) > 0 {
+		slices.SortStableFunc(res.Errors, func(a, b ImportError) int { return a.Row - b.Row })
+		return res, nil
+	}
+	res.WorksCreated = len(works)
+	res.BooksCreated = len(books)
+	if opt.DryRun {
+		return res, nil
+	}
+
+	err := u.repo.Transaction(ctx, func(repo Repository) error {
+		created, err := repo.CreateWorks(ctx, works)
+		if err != nil {
+			return err
+		}
+		for i := range books {
+			if n := bookWork[i]; n >= 0 {
+				books[i].WorkID = created[n].ID
+			}
+		}
+		return repo.CreateBooks(ctx, books)
+	})
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// importRepo knows one library with one book and one work; any other
+// call panics.
+type importRepo struct {
+	Repository
+	book Book
+	work Work
+}
+
+func (r importRepo) GetLibraryByID(_ context.Context, id string) (Library, error) {
+	if id != r.book.LibraryID.String() {
+		return Library{}, ErrNotFound
+	}
+	return Library{ID: r.book.LibraryID}, nil
+}
+
+func (r importRepo) ListBooks(_ context.Context, opt ListBooksOption) ([]Book, int, error) {
+	if slices.Contains(opt.Codes, r.book.Code) {
+		return []Book{r.book}, 1, nil
+	}
+	return nil, 0, nil
+}
+
+func (r importRepo) ListWorks(_ context.Context, opt ListWorksOption) ([]Work, int, error) {
+	if slices.Contains(opt.ISBN13s, r.work.ISBN13) {
+		return []Work{r.work}, 1, nil
+	}
+	return nil, 0, nil
+}
+
+func TestImportBooksDryRun(t *testing.T) {
+	libID := uuid.New()
+	repo := importRepo{
+		book: Book{Code: "B-001", LibraryID: libID},
+		work: Work{ID: uuid.New(), ISBN13: "9780441172719", LibraryID: libID},
+	}
+	u := New(repo, nil, nil)
+	opt := ImportBooksOption{LibraryID: libID, DryRun: true}
+
+	res, err := u.ImportBooks(context.Background(), opt, []ImportRow{
+		{Row: 2, ISBN: "0441172717", Code: "B-002"},
+		{Row: 3, Title: "Emma", Author: "Jane Austen", Code: "B-003"},
+		{Row: 4, Title: "emma", Author: "jane austen", Code: "B-004", Condition: "fair"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Errors) != 0 || res.WorksCreated != 1 || res.BooksCreated != 3 {
+		t.Errorf("result = %+v, want 1 work and 3 books", res)
+	}
+
+	res, err = u.ImportBooks(context.Background(), opt, []ImportRow{
+		{Row: 2, Title: "Dune", Code: "B-001"},
+		{Row: 3, Title: "Dune", Code: "B-005", ISBN: "0441172718"},
+		{Row: 4, Code: "B-005", Year: "19x5", Condition: "torn"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []ImportError
+	for _, e := range res.Errors {
+		got = append(got, ImportError{Row: e.Row, Field: e.Field})
+	}
+	want := []ImportError{
+		{Row: 2, Field: "code"},
+		{Row: 3, Field: "isbn"},
+		{Row: 4, Field: "year"},
+		{Row: 4, Field: "condition"},
+		{Row: 4, Field: "code"},
+		{Row: 4, Field: "title"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("errors = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	"strings"
+)
+
+// ParseISBN accepts an ISBN-10 or ISBN-13, with or without hyphens and
+// spaces, checks its check digit and returns it in both forms. isbn10 is
+// empty for ISBN-13s in the 979 range, which have no ISBN-10.
+func ParseISBN(s string) (isbn13, isbn10 string, err error) {
+	s = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(s))
+
+	switch len(s) {
+	case 10:
+		if !isDigits(s[:9]) || isbn10CheckDigit(s[:9]) != s[9] {
+			return "", "", ErrInvalidISBN
+		}
+		isbn13 = "978" + s[:9]
+		return isbn13 + string(isbn13CheckDigit(isbn13)), s, nil
+	case 13:
+		if !isDigits(s) || isbn13CheckDigit(s[:12]) != s[12] {
+			return "", "", ErrInvalidISBN
+		}
+		if strings.HasPrefix(s, "978") {
+			isbn10 = s[3:12] + string(isbn10CheckDigit(s[3:12]))
+		}
+		return s, isbn10, nil
+	}
+
+	return "", "", ErrInvalidISBN
+}
+
+func isbn10CheckDigit(digits string) byte {
+	var sum int
+	for i, c := range digits {
+		sum += (10 - i) * int(c-'0')
+	}
+	switch d := (11 - sum%11) % 11; d {
+	case 10:
+		return 'X'
+	default:
+		return byte('0' + d)
+	}
+}
+
+func isbn13CheckDigit(digits string) byte {
+	var sum int
+	for i, c := range digits {
+		w := 1
+		if i%2 == 1 {
+			w = 3
+		}
+		sum += w * int(c-'0')
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,36 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseISBN(t *testing.T) {
+	tests := []struct {
+		in     string
+		isbn13 string
+		isbn10 string
+		err    error
+	}{
+		{"0-306-40615-2", "9780306406157", "0306406152", nil},
+		{"978-0-306-40615-7", "9780306406157", "0306406152", nil},
+		{"080442957x", "9780804429573", "080442957X", nil},
+		{"979 10 90636 07 1", "9791090636071", "", nil},
+		{"0-306-40615-3", "", "", ErrInvalidISBN},
+		{"9780306406158", "", "", ErrInvalidISBN},
+		{"97803064061", "", "", ErrInvalidISBN},
+		{"X306406152", "", "", ErrInvalidISBN},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			isbn13, isbn10, err := ParseISBN(tt.in)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if isbn13 != tt.isbn13 || isbn10 != tt.isbn10 {
+				t.Errorf("ParseISBN(%q) = %q, %q, want %q, %q", tt.in, isbn13, isbn10, tt.isbn13, tt.isbn10)
+			}
+		})
+	}
+}
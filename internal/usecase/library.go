@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,6 +22,8 @@ type ListLibrariesOption struct {
 	IDs    uuid.UUIDs
 	SortBy string
 	SortIn string
+	// IncludeDeleted lists the soft-deleted libraries as well.
+	IncludeDeleted bool
 }
 
 func (u Usecase) ListLibraries(ctx context.Context, opt ListLibrariesOption) ([]Library, int, error) {
@@ -46,7 +49,7 @@ func (u Usecase) ListLibraries(ctx context.Context, opt ListLibrariesOption) ([]
 func (u Usecase) GetLibraryByID(ctx context.Context, id string) (Library, error) {
 	err := uuid.Validate(id)
 	if err != nil {
-		return Library{}, err
+		return Library{}, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
 	}
 	lib, err := u.repo.GetLibraryByID(ctx, id)
 	if err != nil {
@@ -90,15 +93,45 @@ func (u Usecase) UpdateLibrary(ctx context.Context, library Library) (Library, e
 	}, nil
 }
 
+// DeleteLibrary deletes the library along with its staffs, memberships,
+// subscriptions, works, books, borrowings, subjects, settings and fines,
+// and cancels its open holds, all or nothing. Libraries with books on loan cannot be deleted.
 func (u Usecase) DeleteLibrary(ctx context.Context, id string) error {
-	err := uuid.Validate(id)
+	lid, err := uuid.Parse(id)
 	if err != nil {
-		return err
-	}
-	err = u.repo.DeleteLibrary(ctx, id)
-	if err != nil {
-		return err
+		return fmt.Errorf("%w: %v", ErrInvalidArgument, err)
 	}
 
-	return nil
+	return u.repo.Transaction(ctx, func(repo Repository) error {
+		// nothing is borrowed between the check and the delete
+		if err := repo.LockLibrary(ctx, lid); err != nil {
+			return err
+		}
+		_, onLoan, err := repo.ListBorrowings(ctx, ListBorrowingsOption{
+			Limit:     1,
+			LibraryID: id,
+			IsActive:  true,
+		})
+		if err != nil {
+			return err
+		}
+		if onLoan > 0 {
+			return fmt.Errorf("%w: library %s has %d", ErrLibraryOnLoan, id, onLoan)
+		}
+		return repo.DeleteLibrary(ctx, id)
+	})
+}
+
+// RestoreLibrary restores the library and what was deleted along with it.
+func (u Usecase) RestoreLibrary(ctx context.Context, id uuid.UUID) error {
+	return u.repo.Transaction(ctx, func(repo Repository) error {
+		return repo.RestoreLibrary(ctx, id)
+	})
+}
+
+// PurgeLibrary deletes the library, its settings and subjects for good.
+// Libraries that still have staff, works, books, memberships or fines,
+// deleted or not, cannot be purged.
+func (u Usecase) PurgeLibrary(ctx context.Context, id uuid.UUID) error {
+	return u.repo.PurgeLibrary(ctx, id)
 }
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,6 +16,7 @@ type Membership struct {
 	ActiveLoanLimit int
 	LoanPeriod      int
 	FinePerDay      int
+	MaxRenewals     int
 	CreatedAt       time.Time
 	UpdatedAt       time.Time
 	DeletedAt       *time.Time
@@ -26,6 +28,8 @@ type ListMembershipsOption struct {
 	Skip      int
 	Limit     int
 	LibraryID string
+	// IncludeDeleted lists the soft-deleted memberships as well.
+	IncludeDeleted bool
 }
 
 func (u Usecase) ListMemberships(ctx context.Context, opt ListMembershipsOption) ([]Membership, int, error) {
@@ -39,7 +43,7 @@ func (u Usecase) CreateMembership(ctx context.Context, membership Membership) (M
 func (u Usecase) GetMembershipByID(ctx context.Context, id string) (Membership, error) {
 	mid, err := uuid.Parse(id)
 	if err != nil {
-		return Membership{}, err
+		return Membership{}, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
 	}
 	return u.repo.GetMembershipByID(ctx, mid)
 }
@@ -48,10 +52,33 @@ func (u Usecase) UpdateMembership(ctx context.Context, membership Membership) (M
 	return u.repo.UpdateMembership(ctx, membership)
 }
 
-// func (u Usecase) DeleteMembership(ctx context.Context, id string) error {
-// 	mid, err := uuid.Parse(id)
-// 	if err != nil {
-// 		return err
-// 	}
-// 	return u.repo.DeleteMembership(ctx, mid)
-// }
+// DeleteMembership deletes the membership unless members hold active
+// subscriptions to it. The membership is locked first, so that no one
+// subscribes meanwhile.
+func (u Usecase) DeleteMembership(ctx context.Context, id uuid.UUID) error {
+	return u.repo.Transaction(ctx, func(repo Repository) error {
+		if err := repo.LockMembership(ctx, id); err != nil {
+			return err
+		}
+		_, active, err := repo.ListSubscriptions(ctx, ListSubscriptionsOption{
+			Limit:        1,
+			MembershipID: id.String(),
+			IsActive:     true,
+		})
+		if err != nil {
+			return err
+		}
+		if active > 0 {
+			return fmt.Errorf("%w: membership %s has %d", ErrMembershipInUse, id, active)
+		}
+		return repo.DeleteMembership(ctx, id)
+	})
+}
+
+func (u Usecase) RestoreMembership(ctx context.Context, id uuid.UUID) error {
+	return u.repo.RestoreMembership(ctx, id)
+}
+
+func (u Usecase) PurgeMembership(ctx context.Context, id uuid.UUID) error {
+	return u.repo.PurgeMembership(ctx, id)
+}
@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// ReminderKindDueSoon reminders are sent a few days before the due date.
+	ReminderKindDueSoon = "DUE_SOON"
+	ReminderKindOverdue = "OVERDUE"
+	// ReminderKindSubscriptionExpiring reminders are sent a few days
+	// before a subscription expires.
+	ReminderKindSubscriptionExpiring = "SUB_EXPIRING"
+	ReminderKindSubscriptionExpired  = "SUB_EXPIRED"
+)
+
+// Reminder records that a member was reminded of a loan or subscription,
+// so that each kind of reminder is sent once per due date. Renewing a
+// loan or subscription moves its due date and so allows new reminders.
+type Reminder struct {
+	ID             uuid.UUID
+	BorrowingID    *uuid.UUID
+	SubscriptionID *uuid.UUID
+	Kind           string
+	// DueAt is the due date of the borrowing or the expiry of the subscription.
+	DueAt     time.Time
+	CreatedAt time.Time
+}
+
+// Notification is a message to a user.
+type Notification struct {
+	Email   string
+	Name    string
+	Subject string
+	Body    string
+}
+
+type Notifier interface {
+	Notify(context.Context, Notification) error
+}
+
+// errNotifyFailed marks the errors of a notifier, which fail one reminder
+// rather than the whole run.
+var errNotifyFailed = errors.New("notification failed")
+
+// SendReminders reminds members of their loans due within the days and of
+// their overdue loans, skipping the reminders sent already. A reminder that
+// cannot be sent is logged and tried again on the next run, without
+// holding back the others. It returns how many were sent, and the errors
+// of those that were not.
+func (u Usecase) SendReminders(ctx context.Context, dueSoonDays int) (int, error) {
+	const pageSize = 100
+	if u.notifier == nil {
+		return 0, errors.New("no notifier configured")
+	}
+	now := time.Now()
+	dueBefore := now.AddDate(0, 0, dueSoonDays)
+
+	var (
+		sent   int
+		failed []error
+	)
+	for skip := 0; ; skip += pageSize {
+		borrows, _, err := u.repo.ListBorrowings(ctx, ListBorrowingsOption{
+			Skip:      skip,
+			Limit:     pageSize,
+			IsActive:  true,
+			DueBefore: dueBefore,
+			SortBy:    "due_at",
+			SortIn:    "asc",
+		})
+		if err != nil {
+			return sent, err
+		}
+
+		for _, b := range borrows {
+			if b.Book == nil || b.Subscription == nil || b.Subscription.User == nil {
+				continue
+			}
+			n := reminderNotification(b, now)
+			kind := ReminderKindDueSoon
+			if b.DueAt.Before(now) {
+				kind = ReminderKindOverdue
+			}
+
+			// the claim is rolled back if the notification fails, so that
+			// the next run tries again
+			err := u.repo.Transaction(ctx, func(repo Repository) error {
+				claimed, err := repo.ClaimReminder(ctx, Reminder{
+					BorrowingID: &b.ID,
+					Kind:        kind,
+					DueAt:       b.DueAt,
+				})
+				if err != nil || !claimed {
+					return err
+				}
+				if err := u.notifier.Notify(ctx, n); err != nil {
+					return fmt.Errorf("%w: remind %s of borrowing %s: %w", errNotifyFailed, n.Email, b.ID, err)
+				}
+				sent++
+				return nil
+			})
+			if errors.Is(err, errNotifyFailed) {
+				log.Printf("send reminders: %v", err)
+				failed = append(failed, err)
+				continue
+			}
+			if err != nil {
+				return sent, err
+			}
+		}
+
+		if len(borrows) < pageSize {
+			return sent, errors.Join(failed...)
+		}
+	}
+}
+
+func reminderNotification(b Borrowing, now time.Time) Notification {
+	user := b.Subscription.User
+	library := "the library"
+	if m := b.Subscription.Membership; m != nil && m.Library != nil {
+		library = m.Library.Name
+	}
+	due := b.DueAt.Format("Monday, 2 January 2006")
+
+	n := Notification{
+		Email: user.Email,
+		Name:  user.Name,
+	}
+	if b.DueAt.Before(now) {
+		n.Subject = fmt.Sprintf("%q is overdue", b.Book.Title)
+		n.Body = fmt.Sprintf("Hello %s,\n\n%q, borrowed from %s, was due on %s. Please return it as soon as you can; fines accrue for every day it is late.\n",
+			user.Name, b.Book.Title, library, due)
+	} else {
+		n.Subject = fmt.Sprintf("%q is due on %s", b.Book.Title, due)
+		n.Body = fmt.Sprintf("Hello %s,\n\n%q, borrowed from %s, is due on %s. Please return or renew it by then.\n",
+			user.Name, b.Book.Title, library, due)
+	}
+	return n
+}
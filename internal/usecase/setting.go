@@ -0,0 +1,49 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Setting is the configuration of a library.
+type Setting struct {
+	LibraryID uuid.UUID
+	// MaxOutstandingFine is the unpaid balance above which a member may
+	// not borrow. Nil means no limit.
+	MaxOutstandingFine *int
+	// HoldPickupDays is how long a book stays on the hold shelf. Nil
+	// means the default of 3 days.
+	HoldPickupDays *int
+	// AutoSuspendFine is the unpaid balance at which members are
+	// suspended automatically. Nil disables it.
+	AutoSuspendFine *int
+	// AutoSuspendOverdue is the number of overdue loans at which members
+	// are suspended automatically. Nil disables it.
+	AutoSuspendOverdue *int
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+}
+
+// GetSetting returns the settings of the library, or the defaults when
+// the library has none stored.
+func (u Usecase) GetSetting(ctx context.Context, libraryID uuid.UUID) (Setting, error) {
+	return getSetting(ctx, u.repo, libraryID)
+}
+
+func (u Usecase) UpdateSetting(ctx context.Context, s Setting) (Setting, error) {
+	if _, err := u.repo.GetLibraryByID(ctx, s.LibraryID.String()); err != nil {
+		return Setting{}, err
+	}
+	return u.repo.UpsertSetting(ctx, s)
+}
+
+func getSetting(ctx context.Context, repo Repository, libraryID uuid.UUID) (Setting, error) {
+	s, err := repo.GetSetting(ctx, libraryID)
+	if errors.Is(err, ErrNotFound) {
+		return Setting{LibraryID: libraryID}, nil
+	}
+	return s, err
+}
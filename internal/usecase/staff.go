@@ -2,16 +2,24 @@ package usecase
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// Roles of a staff within its library.
+const (
+	StaffRoleStaff = "STAFF"
+	StaffRoleAdmin = "ADMIN"
+)
+
 type Staff struct {
 	ID        uuid.UUID
 	Name      string
 	LibraryID uuid.UUID
 	UserID    uuid.UUID
+	Role      string
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	DeleteAt  *time.Time
@@ -32,6 +40,8 @@ type ListStaffsOption struct {
 	LibraryID string
 	UserID    string
 	Name      string
+	// IncludeDeleted lists the soft-deleted staffs as well.
+	IncludeDeleted bool
 }
 
 func (u Usecase) CreateStaff(ctx context.Context, staff Staff) (Staff, error) {
@@ -41,7 +51,7 @@ func (u Usecase) CreateStaff(ctx context.Context, staff Staff) (Staff, error) {
 func (u Usecase) GetStaffByID(ctx context.Context, id string) (Staff, error) {
 	sid, err := uuid.Parse(id)
 	if err != nil {
-		return Staff{}, err
+		return Staff{}, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
 	}
 	return u.repo.GetStaffByID(ctx, sid)
 }
@@ -49,3 +59,15 @@ func (u Usecase) GetStaffByID(ctx context.Context, id string) (Staff, error) {
 func (u Usecase) UpdateStaff(ctx context.Context, staff Staff) (Staff, error) {
 	return u.repo.UpdateStaff(ctx, staff)
 }
+
+func (u Usecase) DeleteStaff(ctx context.Context, id uuid.UUID) error {
+	return u.repo.DeleteStaff(ctx, id)
+}
+
+func (u Usecase) RestoreStaff(ctx context.Context, id uuid.UUID) error {
+	return u.repo.RestoreStaff(ctx, id)
+}
+
+func (u Usecase) PurgeStaff(ctx context.Context, id uuid.UUID) error {
+	return u.repo.PurgeStaff(ctx, id)
+}
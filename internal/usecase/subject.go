@@ -0,0 +1,101 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// SubjectKindSubject is a subject heading, such as "World War, 1939-1945".
+	SubjectKindSubject = "SUBJECT"
+	SubjectKindGenre   = "GENRE"
+	// SubjectKindTag is a free tag staff make up for their library.
+	SubjectKindTag = "TAG"
+)
+
+// Subject classifies the works of a library. Works can be classified
+// under any number of subjects of each kind.
+type Subject struct {
+	ID        uuid.UUID
+	Kind      string
+	Name      string
+	LibraryID uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type ListSubjectsOption struct {
+	Skip       int
+	Limit      int
+	LibraryIDs uuid.UUIDs
+	IDs        uuid.UUIDs
+	Kinds      []string
+	Name       string
+	SortBy     string
+	SortIn     string
+}
+
+// Facet counts the books of a list classified under the subject.
+type Facet struct {
+	Subject Subject
+	Count   int
+}
+
+func (u Usecase) ListSubjects(ctx context.Context, opt ListSubjectsOption) ([]Subject, int, error) {
+	return u.repo.ListSubjects(ctx, opt)
+}
+
+func (u Usecase) GetSubjectByID(ctx context.Context, id uuid.UUID) (Subject, error) {
+	return u.repo.GetSubjectByID(ctx, id)
+}
+
+func (u Usecase) CreateSubject(ctx context.Context, s Subject) (Subject, error) {
+	return u.repo.CreateSubject(ctx, s)
+}
+
+func (u Usecase) UpdateSubject(ctx context.Context, s Subject) (Subject, error) {
+	return u.repo.UpdateSubject(ctx, s)
+}
+
+// DeleteSubject deletes the subject and unclassifies its works.
+func (u Usecase) DeleteSubject(ctx context.Context, id uuid.UUID) error {
+	return u.repo.DeleteSubject(ctx, id)
+}
+
+// BookFacets counts the books matching the filters of opt under each
+// subject they are classified under, most books first.
+func (u Usecase) BookFacets(ctx context.Context, opt ListBooksOption) ([]Facet, error) {
+	return u.repo.BookFacets(ctx, opt)
+}
+
+// setWorkSubjects classifies the work under exactly the subjects, which
+// must be of the work's library.
+func setWorkSubjects(ctx context.Context, repo Repository, work Work, ids uuid.UUIDs) error {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make(uuid.UUIDs, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	if len(unique) > 0 {
+		_, n, err := repo.ListSubjects(ctx, ListSubjectsOption{
+			LibraryIDs: uuid.UUIDs{work.LibraryID},
+			IDs:        unique,
+			Limit:      len(unique),
+		})
+		if err != nil {
+			return err
+		}
+		if n != len(unique) {
+			return fmt.Errorf("%w: subjects must be of the work's library", ErrInvalidArgument)
+		}
+	}
+
+	return repo.SetWorkSubjects(ctx, work.ID, unique)
+}
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -15,10 +16,17 @@ type Subscription struct {
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	DeletedAt    *time.Time
+	// ExpiredAt is when the subscription was found expired, nil until then
+	// and again once renewed.
+	ExpiredAt *time.Time
+	// SuspendedAt is when the subscription was suspended, nil unless it
+	// is suspended now.
+	SuspendedAt *time.Time
 
 	// Granfathering the membership
 	ExpiresAt       time.Time
 	FinePerDay      int
+	MaxRenewals     int
 	LoanPeriod      int
 	ActiveLoanLimit int
 
@@ -34,34 +42,87 @@ type ListSubscriptionsOption struct {
 	LibraryID      string
 	MembershipName string
 	IsActive       bool
+	IsSuspended    bool
+	// ExpiresBefore lists the subscriptions expiring before it.
+	ExpiresBefore time.Time
+	// PendingExpiry lists the subscriptions past their expiry that are
+	// not marked expired yet.
+	PendingExpiry bool
+	// ExpiredUnnotified lists the subscriptions marked expired whose
+	// members were not told yet.
+	ExpiredUnnotified bool
+	// IncludeDeleted lists the soft-deleted subscriptions as well.
+	IncludeDeleted bool
+}
+
+// SubscriptionRenewal is a period a subscription was extended by, with
+// the terms it runs on from then.
+type SubscriptionRenewal struct {
+	ID                uuid.UUID
+	SubscriptionID    uuid.UUID
+	StaffID           *uuid.UUID
+	PreviousExpiresAt time.Time
+	ExpiresAt         time.Time
+	// Regrandfathered renewals took the terms of the current membership.
+	Regrandfathered bool
+	FinePerDay      int
+	MaxRenewals     int
+	LoanPeriod      int
+	ActiveLoanLimit int
+	CreatedAt       time.Time
+}
+
+type RenewSubscriptionOption struct {
+	ID      uuid.UUID
+	StaffID *uuid.UUID
+	// Regrandfather takes the limits of the membership as it is now
+	// instead of keeping those the subscription was granted.
+	Regrandfather bool
 }
 
 func (u Usecase) ListSubscriptions(ctx context.Context, opt ListSubscriptionsOption) ([]Subscription, int, error) {
 	return u.repo.ListSubscriptions(ctx, opt)
 }
 
+// CreateSubscription subscribes the user to the membership on its current
+// terms. The membership is locked, so that it is not deleted meanwhile.
 func (u Usecase) CreateSubscription(ctx context.Context, sub Subscription) (Subscription, error) {
-	m, err := u.GetMembershipByID(ctx, sub.MembershipID.String())
+	var created Subscription
+	err := u.repo.Transaction(ctx, func(repo Repository) error {
+		if err := repo.LockMembership(ctx, sub.MembershipID); err != nil {
+			return err
+		}
+		m, err := repo.GetMembershipByID(ctx, sub.MembershipID)
+		if err != nil {
+			return err
+		}
+		if m.DeletedAt != nil {
+			return fmt.Errorf("%w: membership %s is deleted", ErrNotFound, m.ID)
+		}
+		// Granfathering the membership
+		sub.ExpiresAt = time.Now().AddDate(0, 0, m.Duration)
+		sub.LoanPeriod = m.LoanPeriod
+		sub.FinePerDay = m.FinePerDay
+		sub.MaxRenewals = m.MaxRenewals
+		sub.ActiveLoanLimit = m.ActiveLoanLimit
+
+		created, err = repo.CreateSubscription(ctx, sub)
+		return err
+	})
 	if err != nil {
 		return Subscription{}, err
 	}
-	if m.DeletedAt != nil {
-		return Subscription{}, fmt.Errorf("membership %s is deleted", m.ID)
-	}
-	// Granfathering the membership
-	sub.ExpiresAt = time.Now().AddDate(0, 0, m.Duration)
-	sub.LoanPeriod = m.LoanPeriod
-	sub.FinePerDay = m.FinePerDay
-	sub.ActiveLoanLimit = m.ActiveLoanLimit
-
-	return u.repo.CreateSubscription(ctx, sub)
+	return created, nil
 }
 
 func (u Usecase) GetSubscriptionByID(ctx context.Context, id uuid.UUID) (Subscription, error) {
 	return u.repo.GetSubscriptionByID(ctx, id)
 }
 
+// UpdateSubscription changes the expiry and terms of a subscription. Its
+// user and membership are fixed when it is created.
 func (u Usecase) UpdateSubscription(ctx context.Context, sub Subscription) (Subscription, error) {
+	sub.UserID, sub.MembershipID = uuid.Nil, uuid.Nil
 	if sub.ExpiresAt.IsZero() {
 		s, err := u.GetSubscriptionByID(ctx, sub.ID)
 		if err != nil {
@@ -71,3 +132,382 @@ func (u Usecase) UpdateSubscription(ctx context.Context, sub Subscription) (Subs
 	}
 	return u.repo.UpdateSubscription(ctx, sub)
 }
+
+// DeleteSubscription deletes the subscription unless it has books on
+// loan, and cancels its waiting and ready holds, passing their books on to
+// the next member in line.
+func (u Usecase) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	const pageSize = 100
+
+	return u.repo.Transaction(ctx, func(repo Repository) error {
+		if err := repo.LockSubscription(ctx, id); err != nil {
+			return err
+		}
+		_, onLoan, err := repo.ListBorrowings(ctx, ListBorrowingsOption{
+			Limit:          1,
+			SubscriptionID: id.String(),
+			IsActive:       true,
+		})
+		if err != nil {
+			return err
+		}
+		if onLoan > 0 {
+			return fmt.Errorf("%w: subscription %s has %d", ErrSubscriptionOnLoan, id, onLoan)
+		}
+
+		for {
+			holds, _, err := repo.ListHolds(ctx, ListHoldsOption{
+				Limit:          pageSize,
+				SubscriptionID: id.String(),
+				Statuses:       []string{HoldStatusWaiting, HoldStatusReady},
+			})
+			if err != nil {
+				return err
+			}
+			for _, h := range holds {
+				if err := repo.LockBook(ctx, h.BookID); err != nil {
+					return err
+				}
+				// read again, it may have changed while waiting for the lock
+				h, err := repo.GetHoldByID(ctx, h.ID)
+				if err != nil {
+					return err
+				}
+				if h.Status != HoldStatusWaiting && h.Status != HoldStatusReady {
+					continue
+				}
+				if _, err := closeHold(ctx, repo, h, HoldStatusCancelled, nil); err != nil {
+					return err
+				}
+				if _, err := promoteHold(ctx, repo, h.BookID); err != nil {
+					return err
+				}
+			}
+			if len(holds) < pageSize {
+				break
+			}
+		}
+
+		return repo.DeleteSubscription(ctx, id)
+	})
+}
+
+func (u Usecase) RestoreSubscription(ctx context.Context, id uuid.UUID) error {
+	return u.repo.RestoreSubscription(ctx, id)
+}
+
+func (u Usecase) PurgeSubscription(ctx context.Context, id uuid.UUID) error {
+	return u.repo.PurgeSubscription(ctx, id)
+}
+
+// RenewSubscription extends the subscription by the duration of its
+// membership, from its expiry or from now if it expired already.
+func (u Usecase) RenewSubscription(ctx context.Context, opt RenewSubscriptionOption) (Subscription, error) {
+	var renewed Subscription
+	err := u.repo.Transaction(ctx, func(repo Repository) error {
+		if err := repo.LockSubscription(ctx, opt.ID); err != nil {
+			return err
+		}
+		sub, err := repo.GetSubscriptionByID(ctx, opt.ID)
+		if err != nil {
+			return err
+		}
+		m, err := repo.GetMembershipByID(ctx, sub.MembershipID)
+		if err != nil {
+			return err
+		}
+		if m.DeletedAt != nil {
+			return fmt.Errorf("%w: membership %s is deleted", ErrNotFound, m.ID)
+		}
+		if sub.SuspendedAt != nil {
+			return suspendedError(sub)
+		}
+		if opt.StaffID != nil {
+			staff, err := repo.GetStaffByID(ctx, *opt.StaffID)
+			if err != nil {
+				return err
+			}
+			if staff.LibraryID != m.LibraryID {
+				return fmt.Errorf("%w: staff %s, library %s", ErrStaffNotInLibrary, staff.ID, m.LibraryID)
+			}
+		}
+
+		start := time.Now()
+		if sub.ExpiresAt.After(start) {
+			start = sub.ExpiresAt
+		}
+		r := SubscriptionRenewal{
+			SubscriptionID:    sub.ID,
+			StaffID:           opt.StaffID,
+			PreviousExpiresAt: sub.ExpiresAt,
+			ExpiresAt:         start.AddDate(0, 0, m.Duration),
+			Regrandfathered:   opt.Regrandfather,
+			FinePerDay:        sub.FinePerDay,
+			MaxRenewals:       sub.MaxRenewals,
+			LoanPeriod:        sub.LoanPeriod,
+			ActiveLoanLimit:   sub.ActiveLoanLimit,
+		}
+		if opt.Regrandfather {
+			r.FinePerDay = m.FinePerDay
+			r.MaxRenewals = m.MaxRenewals
+			r.LoanPeriod = m.LoanPeriod
+			r.ActiveLoanLimit = m.ActiveLoanLimit
+		}
+
+		renewed, err = repo.RenewSubscription(ctx, r)
+		return err
+	})
+	if err != nil {
+		return Subscription{}, err
+	}
+	return renewed, nil
+}
+
+func (u Usecase) ListSubscriptionRenewals(ctx context.Context, id uuid.UUID) ([]SubscriptionRenewal, error) {
+	if _, err := u.repo.GetSubscriptionByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return u.repo.ListSubscriptionRenewals(ctx, id)
+}
+
+// NotifyExpiringSubscriptions tells members whose subscription expires
+// within the days, once per expiry. A notice that cannot be sent is tried
+// again on the next run, without holding back the others. It returns how
+// many were told, and the errors of the notices that were not sent.
+func (u Usecase) NotifyExpiringSubscriptions(ctx context.Context, days int) (int, error) {
+	const pageSize = 100
+	if u.notifier == nil {
+		return 0, errors.New("no notifier configured")
+	}
+	now := time.Now()
+
+	var (
+		sent   int
+		failed []error
+	)
+	for skip := 0; ; skip += pageSize {
+		subs, _, err := u.repo.ListSubscriptions(ctx, ListSubscriptionsOption{
+			Skip:          skip,
+			Limit:         pageSize,
+			IsActive:      true,
+			ExpiresBefore: now.AddDate(0, 0, days),
+		})
+		if err != nil {
+			return sent, err
+		}
+
+		for _, sub := range subs {
+			err := u.repo.Transaction(ctx, func(repo Repository) error {
+				ok, err := notifySubscription(ctx, repo, u.notifier, sub, ReminderKindSubscriptionExpiring)
+				if ok {
+					sent++
+				}
+				return err
+			})
+			if errors.Is(err, errNotifyFailed) {
+				failed = append(failed, err)
+				continue
+			}
+			if err != nil {
+				return sent, err
+			}
+		}
+
+		if len(subs) < pageSize {
+			return sent, errors.Join(failed...)
+		}
+	}
+}
+
+// ExpireSubscriptions marks the subscriptions past their expiry expired,
+// closes their waiting and ready holds, passing the books on to the next
+// member in line, and tells their members when a notifier is set. Notices
+// go out after the expiry, so one that cannot be sent does not hold the
+// expiry back and is tried again on the next run. It returns how many
+// subscriptions expired, and the errors of the notices that were not sent.
+func (u Usecase) ExpireSubscriptions(ctx context.Context) (int, error) {
+	const pageSize = 100
+
+	var expired int
+	for {
+		subs, _, err := u.repo.ListSubscriptions(ctx, ListSubscriptionsOption{
+			Limit:         pageSize,
+			PendingExpiry: true,
+		})
+		if err != nil {
+			return expired, err
+		}
+
+		for _, sub := range subs {
+			err := u.repo.Transaction(ctx, func(repo Repository) error {
+				if err := repo.LockSubscription(ctx, sub.ID); err != nil {
+					return err
+				}
+				sub, err := repo.GetSubscriptionByID(ctx, sub.ID)
+				if err != nil {
+					return err
+				}
+				// marked or renewed in the meantime
+				if sub.ExpiredAt != nil || sub.ExpiresAt.After(time.Now()) {
+					return nil
+				}
+				if err := repo.MarkSubscriptionExpired(ctx, sub.ID); err != nil {
+					return err
+				}
+				expired++
+				return nil
+			})
+			if err != nil {
+				return expired, err
+			}
+		}
+
+		if len(subs) < pageSize {
+			break
+		}
+	}
+
+	if err := u.closeExpiredHolds(ctx); err != nil {
+		return expired, err
+	}
+	if u.notifier == nil {
+		return expired, nil
+	}
+	return expired, u.notifyExpiredSubscriptions(ctx)
+}
+
+// notifyExpiredSubscriptions tells the members of the expired
+// subscriptions who were not told yet, those whose notice failed on an
+// earlier run included.
+func (u Usecase) notifyExpiredSubscriptions(ctx context.Context) error {
+	const pageSize = 100
+
+	var failed []error
+	// the members told drop out of the list, the others stay ahead
+	for skip := 0; ; {
+		subs, _, err := u.repo.ListSubscriptions(ctx, ListSubscriptionsOption{
+			Skip:              skip,
+			Limit:             pageSize,
+			ExpiredUnnotified: true,
+		})
+		if err != nil {
+			return errors.Join(append(failed, err)...)
+		}
+
+		for _, sub := range subs {
+			var told bool
+			err := u.repo.Transaction(ctx, func(repo Repository) error {
+				var err error
+				told, err = notifySubscription(ctx, repo, u.notifier, sub, ReminderKindSubscriptionExpired)
+				return err
+			})
+			if errors.Is(err, errNotifyFailed) {
+				failed = append(failed, err)
+			} else if err != nil {
+				return errors.Join(append(failed, err)...)
+			}
+			if !told {
+				skip++
+			}
+		}
+
+		if len(subs) < pageSize {
+			return errors.Join(failed...)
+		}
+	}
+}
+
+// closeExpiredHolds closes the waiting and ready holds of expired
+// subscriptions and advances their queues.
+func (u Usecase) closeExpiredHolds(ctx context.Context) error {
+	const pageSize = 100
+
+	for {
+		holds, _, err := u.repo.ListHolds(ctx, ListHoldsOption{
+			Limit:               pageSize,
+			Statuses:            []string{HoldStatusWaiting, HoldStatusReady},
+			SubscriptionExpired: true,
+			SortBy:              "created_at",
+			SortIn:              "asc",
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, h := range holds {
+			err := u.repo.Transaction(ctx, func(repo Repository) error {
+				if err := repo.LockBook(ctx, h.BookID); err != nil {
+					return err
+				}
+				h, err := repo.GetHoldByID(ctx, h.ID)
+				if err != nil {
+					return err
+				}
+				s, err := repo.GetSubscriptionByID(ctx, h.SubscriptionID)
+				if err != nil {
+					return err
+				}
+				// closed or renewed in the meantime
+				if h.Status != HoldStatusWaiting && h.Status != HoldStatusReady || s.ExpiresAt.After(time.Now()) {
+					return nil
+				}
+				if _, err := closeHold(ctx, repo, h, HoldStatusExpired, nil); err != nil {
+					return err
+				}
+				_, err = promoteHold(ctx, repo, h.BookID)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(holds) < pageSize {
+			return nil
+		}
+	}
+}
+
+// notifySubscription tells the member of the subscription it expires or
+// expired, unless they were told already, reporting whether they were.
+// Callers run it in a transaction, so that the reminder is not recorded
+// if the notification fails.
+func notifySubscription(ctx context.Context, repo Repository, notifier Notifier, sub Subscription, kind string) (bool, error) {
+	if sub.User == nil {
+		return false, nil
+	}
+	claimed, err := repo.ClaimReminder(ctx, Reminder{
+		SubscriptionID: &sub.ID,
+		Kind:           kind,
+		DueAt:          sub.ExpiresAt,
+	})
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	name, library := "your membership", "the library"
+	if m := sub.Membership; m != nil {
+		name = m.Name
+		if m.Library != nil {
+			library = m.Library.Name
+		}
+	}
+	expires := sub.ExpiresAt.Format("Monday, 2 January 2006")
+	n := Notification{
+		Email: sub.User.Email,
+		Name:  sub.User.Name,
+	}
+	if kind == ReminderKindSubscriptionExpired {
+		n.Subject = fmt.Sprintf("Your %s subscription expired", name)
+		n.Body = fmt.Sprintf("Hello %s,\n\nYour %s subscription at %s expired on %s, and your holds were released. Renew it at the library to borrow again.\n",
+			sub.User.Name, name, library, expires)
+	} else {
+		n.Subject = fmt.Sprintf("Your %s subscription expires on %s", name, expires)
+		n.Body = fmt.Sprintf("Hello %s,\n\nYour %s subscription at %s expires on %s. Renew it at the library to keep borrowing.\n",
+			sub.User.Name, name, library, expires)
+	}
+	if err := notifier.Notify(ctx, n); err != nil {
+		return false, fmt.Errorf("%w: notify %s of subscription %s: %w", errNotifyFailed, n.Email, sub.ID, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,305 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SubscriptionSuspension is a period a subscription was suspended for.
+// Suspended members may not borrow, renew or place holds.
+type SubscriptionSuspension struct {
+	ID             uuid.UUID
+	SubscriptionID uuid.UUID
+	// StaffID is nil for the suspensions made by the library thresholds.
+	StaffID     *uuid.UUID
+	Reason      string
+	SuspendedAt time.Time
+	// ReinstatedAt is nil while the suspension is in force.
+	ReinstatedAt      *time.Time
+	ReinstatedStaffID *uuid.UUID
+	// ExpiresAt is the expiry the reinstatement moved the subscription to
+	// by adding back the suspended time, nil when it was not moved.
+	ExpiresAt *time.Time
+	// ExcusedFine is what the member owed the library when reinstated,
+	// kept for the history. The library thresholds excuse it by leaving
+	// out the charges of loans that fell due before the reinstatement.
+	ExcusedFine int
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type SuspendSubscriptionOption struct {
+	ID      uuid.UUID
+	StaffID *uuid.UUID
+	Reason  string
+}
+
+type ReinstateSubscriptionOption struct {
+	ID      uuid.UUID
+	StaffID *uuid.UUID
+	// ExtendExpiry adds the time the subscription was suspended for to
+	// its expiry.
+	ExtendExpiry bool
+}
+
+func (u Usecase) SuspendSubscription(ctx context.Context, opt SuspendSubscriptionOption) (Subscription, error) {
+	if opt.Reason == "" {
+		return Subscription{}, fmt.Errorf("%w: a reason is required", ErrInvalidArgument)
+	}
+
+	var suspended Subscription
+	err := u.repo.Transaction(ctx, func(repo Repository) error {
+		if err := repo.LockSubscription(ctx, opt.ID); err != nil {
+			return err
+		}
+		sub, err := repo.GetSubscriptionByID(ctx, opt.ID)
+		if err != nil {
+			return err
+		}
+		if sub.SuspendedAt != nil {
+			return suspendedError(sub)
+		}
+		if opt.StaffID != nil {
+			if err := checkSubscriptionStaff(ctx, repo, sub, *opt.StaffID); err != nil {
+				return err
+			}
+		}
+
+		suspended, err = repo.SuspendSubscription(ctx, SubscriptionSuspension{
+			SubscriptionID: sub.ID,
+			StaffID:        opt.StaffID,
+			Reason:         opt.Reason,
+			SuspendedAt:    time.Now(),
+		})
+		return err
+	})
+	if err != nil {
+		return Subscription{}, err
+	}
+	return suspended, nil
+}
+
+// ReinstateSubscription lifts the suspension of the subscription.
+func (u Usecase) ReinstateSubscription(ctx context.Context, opt ReinstateSubscriptionOption) (Subscription, error) {
+	var reinstated Subscription
+	err := u.repo.Transaction(ctx, func(repo Repository) error {
+		if err := repo.LockSubscription(ctx, opt.ID); err != nil {
+			return err
+		}
+		sub, err := repo.GetSubscriptionByID(ctx, opt.ID)
+		if err != nil {
+			return err
+		}
+		if sub.SuspendedAt == nil {
+			return fmt.Errorf("%w: subscription %s is not suspended", ErrInvalidArgument, sub.ID)
+		}
+		if opt.StaffID != nil {
+			if err := checkSubscriptionStaff(ctx, repo, sub, *opt.StaffID); err != nil {
+				return err
+			}
+		}
+		m, err := repo.GetMembershipByID(ctx, sub.MembershipID)
+		if err != nil {
+			return err
+		}
+		bal, err := repo.GetFineBalance(ctx, sub.UserID, m.LibraryID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		r := SubscriptionSuspension{
+			SubscriptionID:    sub.ID,
+			ReinstatedAt:      &now,
+			ReinstatedStaffID: opt.StaffID,
+			ExcusedFine:       max(bal.Outstanding, 0),
+		}
+		if opt.ExtendExpiry {
+			exp := sub.ExpiresAt.Add(now.Sub(*sub.SuspendedAt))
+			r.ExpiresAt = &exp
+		}
+
+		reinstated, err = repo.ReinstateSubscription(ctx, r)
+		return err
+	})
+	if err != nil {
+		return Subscription{}, err
+	}
+	return reinstated, nil
+}
+
+func (u Usecase) ListSubscriptionSuspensions(ctx context.Context, id uuid.UUID) ([]SubscriptionSuspension, error) {
+	if _, err := u.repo.GetSubscriptionByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return u.repo.ListSubscriptionSuspensions(ctx, id)
+}
+
+// SuspendDelinquentSubscriptions suspends the active subscriptions whose
+// members owe the library or have as many overdue loans as its
+// AutoSuspendFine and AutoSuspendOverdue settings allow. A reinstatement
+// excuses what the member owed and the loans overdue at the time, so
+// that staff can make an exception: only fines beyond the excused ones
+// and loans falling due later count towards the thresholds.
+// It returns how many subscriptions were suspended.
+func (u Usecase) SuspendDelinquentSubscriptions(ctx context.Context) (int, error) {
+	const pageSize = 100
+
+	var suspended int
+	for skip := 0; ; skip += pageSize {
+		libs, _, err := u.repo.ListLibraries(ctx, ListLibrariesOption{
+			Skip:  skip,
+			Limit: pageSize,
+		})
+		if err != nil {
+			return suspended, err
+		}
+
+		for _, lib := range libs {
+			setting, err := getSetting(ctx, u.repo, lib.ID)
+			if err != nil {
+				return suspended, err
+			}
+			if setting.AutoSuspendFine == nil && setting.AutoSuspendOverdue == nil {
+				continue
+			}
+			n, err := u.suspendDelinquent(ctx, setting)
+			suspended += n
+			if err != nil {
+				return suspended, err
+			}
+		}
+
+		if len(libs) < pageSize {
+			return suspended, nil
+		}
+	}
+}
+
+func (u Usecase) suspendDelinquent(ctx context.Context, setting Setting) (int, error) {
+	const pageSize = 100
+
+	var suspended int
+	for skip := 0; ; skip += pageSize {
+		subs, _, err := u.repo.ListSubscriptions(ctx, ListSubscriptionsOption{
+			Skip:      skip,
+			Limit:     pageSize,
+			LibraryID: setting.LibraryID.String(),
+			IsActive:  true,
+		})
+		if err != nil {
+			return suspended, err
+		}
+
+		for _, sub := range subs {
+			if sub.SuspendedAt != nil {
+				continue
+			}
+			err := u.repo.Transaction(ctx, func(repo Repository) error {
+				if err := repo.LockSubscription(ctx, sub.ID); err != nil {
+					return err
+				}
+				sub, err := repo.GetSubscriptionByID(ctx, sub.ID)
+				if err != nil {
+					return err
+				}
+				// suspended in the meantime
+				if sub.SuspendedAt != nil {
+					return nil
+				}
+				reason, err := delinquency(ctx, repo, sub, setting)
+				if err != nil || reason == "" {
+					return err
+				}
+				if _, err := repo.SuspendSubscription(ctx, SubscriptionSuspension{
+					SubscriptionID: sub.ID,
+					Reason:         reason,
+					SuspendedAt:    time.Now(),
+				}); err != nil {
+					return err
+				}
+				suspended++
+				return nil
+			})
+			if err != nil {
+				return suspended, err
+			}
+		}
+
+		if len(subs) < pageSize {
+			return suspended, nil
+		}
+	}
+}
+
+// delinquency returns why the member of the subscription crossed a
+// threshold of the library since their last reinstatement, or "" when
+// they did not.
+func delinquency(ctx context.Context, repo Repository, sub Subscription, setting Setting) (string, error) {
+	var dueAfter time.Time
+	suspensions, err := repo.ListSubscriptionSuspensions(ctx, sub.ID)
+	if err != nil {
+		return "", err
+	}
+	if n := len(suspensions); n > 0 && suspensions[n-1].ReinstatedAt != nil {
+		dueAfter = *suspensions[n-1].ReinstatedAt
+	}
+
+	if setting.AutoSuspendFine != nil {
+		bal, err := repo.GetFineBalance(ctx, sub.UserID, setting.LibraryID)
+		if err != nil {
+			return "", err
+		}
+		owed := bal.Outstanding
+		if !dueAfter.IsZero() {
+			// Payments settle the oldest charges first, so the member owes
+			// at most the charges of the loans that fell due since.
+			charged, err := repo.SumFineCharges(ctx, sub.UserID, setting.LibraryID, dueAfter)
+			if err != nil {
+				return "", err
+			}
+			owed = min(owed, charged)
+		}
+		if owed >= *setting.AutoSuspendFine {
+			return fmt.Sprintf("owes %d, the library suspends at %d", owed, *setting.AutoSuspendFine), nil
+		}
+	}
+	if setting.AutoSuspendOverdue != nil {
+		_, overdue, err := repo.ListBorrowings(ctx, ListBorrowingsOption{
+			SubscriptionID: sub.ID.String(),
+			IsExpired:      true,
+			DueAfter:       dueAfter,
+		})
+		if err != nil {
+			return "", err
+		}
+		if overdue >= *setting.AutoSuspendOverdue {
+			return fmt.Sprintf("has %d overdue loans, the library suspends at %d", overdue, *setting.AutoSuspendOverdue), nil
+		}
+	}
+	return "", nil
+}
+
+// checkSubscriptionStaff checks the staff works at the library of the
+// subscription.
+func checkSubscriptionStaff(ctx context.Context, repo Repository, sub Subscription, staffID uuid.UUID) error {
+	m, err := repo.GetMembershipByID(ctx, sub.MembershipID)
+	if err != nil {
+		return err
+	}
+	staff, err := repo.GetStaffByID(ctx, staffID)
+	if err != nil {
+		return err
+	}
+	if staff.LibraryID != m.LibraryID {
+		return fmt.Errorf("%w: staff %s, library %s", ErrStaffNotInLibrary, staff.ID, m.LibraryID)
+	}
+	return nil
+}
+
+func suspendedError(sub Subscription) error {
+	return fmt.Errorf("%w: subscription %s suspended at %s", ErrSubscriptionSuspended, sub.ID, sub.SuspendedAt.Format(time.RFC3339))
+}
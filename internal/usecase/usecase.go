@@ -2,14 +2,16 @@ package usecase
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
 
-func New(repo Repository, ip IdentityProvider) Usecase {
+func New(repo Repository, ip IdentityProvider, n Notifier) Usecase {
 	return Usecase{
 		repo:             repo,
 		identityProvider: ip,
+		notifier:         n,
 	}
 }
 
@@ -17,62 +19,194 @@ type Repository interface {
 	Health() map[string]string
 	Close() error
 
+	// Transaction runs fn with a Repository bound to a single database
+	// transaction, committed when fn returns nil and rolled back otherwise.
+	Transaction(context.Context, func(Repository) error) error
+
 	// user
 	ListUsers(context.Context, ListUsersOption) ([]User, int, error)
 	GetUserByID(context.Context, string, GetUserByIDOption) (User, error)
 	CreateUser(context.Context, User) (User, error)
 	UpdateUser(context.Context, User) (User, error)
 	DeleteUser(context.Context, string) error
+	RestoreUser(context.Context, uuid.UUID) error
+	// PurgeUser deletes the user for good along with their sign-in records.
+	PurgeUser(context.Context, uuid.UUID) error
 
 	// library
 	ListLibraries(context.Context, ListLibrariesOption) ([]Library, int, error)
 	GetLibraryByID(context.Context, string) (Library, error)
 	CreateLibrary(context.Context, Library) (Library, error)
 	UpdateLibrary(context.Context, Library) (Library, error)
+	// LockLibrary locks the library row, then its subscriptions and books,
+	// until the transaction ends.
+	LockLibrary(context.Context, uuid.UUID) error
+	// DeleteLibrary soft-deletes the library with its staffs, memberships,
+	// subscriptions, works, books and returned borrowings, and cancels its
+	// open holds.
 	DeleteLibrary(context.Context, string) error
+	// RestoreLibrary restores the library with the rows DeleteLibrary
+	// deleted along with it.
+	RestoreLibrary(context.Context, uuid.UUID) error
+	// PurgeLibrary deletes the library for good along with its settings
+	// and subjects.
+	PurgeLibrary(context.Context, uuid.UUID) error
+
+	// work
+	ListWorks(context.Context, ListWorksOption) ([]Work, int, error)
+	GetWorkByID(context.Context, uuid.UUID) (Work, error)
+	CreateWork(context.Context, Work) (Work, error)
+	// CreateWorks returns the works with their ids, in the same order.
+	CreateWorks(context.Context, []Work) ([]Work, error)
+	UpdateWork(context.Context, Work) (Work, error)
+	// SetWorkSubjects replaces the subjects the work is classified under.
+	SetWorkSubjects(ctx context.Context, workID uuid.UUID, subjectIDs uuid.UUIDs) error
+	DeleteWork(context.Context, uuid.UUID) error
+	RestoreWork(context.Context, uuid.UUID) error
+	// PurgeWork deletes the work for good along with its classifications.
+	PurgeWork(context.Context, uuid.UUID) error
+
+	// subject
+	ListSubjects(context.Context, ListSubjectsOption) ([]Subject, int, error)
+	GetSubjectByID(context.Context, uuid.UUID) (Subject, error)
+	CreateSubject(context.Context, Subject) (Subject, error)
+	UpdateSubject(context.Context, Subject) (Subject, error)
+	// DeleteSubject deletes the subject along with its classifications.
+	DeleteSubject(context.Context, uuid.UUID) error
 
 	// book
 	ListBooks(context.Context, ListBooksOption) ([]Book, int, error)
 	GetBookByID(context.Context, uuid.UUID) (Book, error)
 	CreateBook(context.Context, Book) (Book, error)
+	CreateBooks(context.Context, []Book) error
+	// ExportBooks calls the func with every book matching the filters,
+	// without holding them all in memory.
+	ExportBooks(context.Context, ListBooksOption, func(Book) error) error
 	UpdateBook(context.Context, Book) (Book, error)
+	// LockBook locks the book row until the transaction ends.
+	LockBook(context.Context, uuid.UUID) error
+	// BookFacets counts the books matching the filters under each of
+	// their subjects, most books first.
+	BookFacets(context.Context, ListBooksOption) ([]Facet, error)
+	DeleteBook(context.Context, uuid.UUID) error
+	RestoreBook(context.Context, uuid.UUID) error
+	PurgeBook(context.Context, uuid.UUID) error
 
 	// staff
 	ListStaffs(context.Context, ListStaffsOption) ([]Staff, int, error)
 	CreateStaff(context.Context, Staff) (Staff, error)
 	GetStaffByID(context.Context, uuid.UUID) (Staff, error)
 	UpdateStaff(context.Context, Staff) (Staff, error)
+	DeleteStaff(context.Context, uuid.UUID) error
+	RestoreStaff(context.Context, uuid.UUID) error
+	PurgeStaff(context.Context, uuid.UUID) error
 
 	// membership
 	ListMemberships(context.Context, ListMembershipsOption) ([]Membership, int, error)
+	// LockMembership locks the membership row until the transaction ends.
+	LockMembership(context.Context, uuid.UUID) error
 	GetMembershipByID(context.Context, uuid.UUID) (Membership, error)
 	CreateMembership(context.Context, Membership) (Membership, error)
 	UpdateMembership(context.Context, Membership) (Membership, error)
-	// DeleteMembership(context.Context, string) error
+	DeleteMembership(context.Context, uuid.UUID) error
+	RestoreMembership(context.Context, uuid.UUID) error
+	PurgeMembership(context.Context, uuid.UUID) error
 
 	// subscription
 	ListSubscriptions(context.Context, ListSubscriptionsOption) ([]Subscription, int, error)
 	GetSubscriptionByID(context.Context, uuid.UUID) (Subscription, error)
+	// LockSubscription locks the subscription row until the transaction ends.
+	LockSubscription(context.Context, uuid.UUID) error
 	CreateSubscription(context.Context, Subscription) (Subscription, error)
 	UpdateSubscription(context.Context, Subscription) (Subscription, error)
+	// RenewSubscription moves the expiry of the subscription, sets its
+	// terms and records the renewal.
+	RenewSubscription(context.Context, SubscriptionRenewal) (Subscription, error)
+	ListSubscriptionRenewals(context.Context, uuid.UUID) ([]SubscriptionRenewal, error)
+	MarkSubscriptionExpired(context.Context, uuid.UUID) error
+	// SuspendSubscription marks the subscription suspended and records
+	// the suspension.
+	SuspendSubscription(context.Context, SubscriptionSuspension) (Subscription, error)
+	// ReinstateSubscription closes the suspension in force, moving the
+	// expiry when its ExpiresAt is set.
+	ReinstateSubscription(context.Context, SubscriptionSuspension) (Subscription, error)
+	ListSubscriptionSuspensions(context.Context, uuid.UUID) ([]SubscriptionSuspension, error)
+	DeleteSubscription(context.Context, uuid.UUID) error
+	RestoreSubscription(context.Context, uuid.UUID) error
+	PurgeSubscription(context.Context, uuid.UUID) error
 
 	// borrowing
 	ListBorrowings(context.Context, ListBorrowingsOption) ([]Borrowing, int, error)
 	GetBorrowingByID(context.Context, uuid.UUID) (Borrowing, error)
 	CreateBorrowing(context.Context, Borrowing) (Borrowing, error)
 	UpdateBorrowing(context.Context, Borrowing) (Borrowing, error)
+	// ReturnBorrowing stores the return of an active borrowing, failing
+	// with ErrAlreadyReturned if it was returned already.
+	ReturnBorrowing(context.Context, Borrowing) (Borrowing, error)
+	// LockBorrowing locks the borrowing row until the transaction ends.
+	LockBorrowing(context.Context, uuid.UUID) error
+	// RenewBorrowing moves the due date of the borrowing and records the renewal.
+	RenewBorrowing(context.Context, BorrowingRenewal) (Borrowing, error)
+	ListBorrowingRenewals(context.Context, uuid.UUID) ([]BorrowingRenewal, error)
+	DeleteBorrowing(context.Context, uuid.UUID) error
+	RestoreBorrowing(context.Context, uuid.UUID) error
+	PurgeBorrowing(context.Context, uuid.UUID) error
+
+	// fine
+	ListFineEntries(context.Context, ListFineEntriesOption) ([]FineEntry, int, error)
+	CreateFineEntry(context.Context, FineEntry) (FineEntry, error)
+	// UpsertFineCharge creates the charge of a borrowing or updates its amount.
+	UpsertFineCharge(context.Context, FineEntry) (FineEntry, error)
+	GetFineBalance(ctx context.Context, userID, libraryID uuid.UUID) (FineBalance, error)
+	// SumFineCharges sums the charges of the user's loans at the library
+	// that fell due after dueAfter.
+	SumFineCharges(ctx context.Context, userID, libraryID uuid.UUID, dueAfter time.Time) (int, error)
+	// LockFineBalance serialises the settlements of the user at the
+	// library until the transaction ends.
+	LockFineBalance(ctx context.Context, userID, libraryID uuid.UUID) error
+
+	// reminder
+	// ClaimReminder records the reminder unless one of its kind was
+	// recorded for the borrowing and due date, reporting whether it was.
+	ClaimReminder(context.Context, Reminder) (bool, error)
+
+	// hold
+	ListHolds(context.Context, ListHoldsOption) ([]Hold, int, error)
+	GetHoldByID(context.Context, uuid.UUID) (Hold, error)
+	CreateHold(context.Context, Hold) (Hold, error)
+	UpdateHold(context.Context, Hold) (Hold, error)
+
+	// setting
+	GetSetting(ctx context.Context, libraryID uuid.UUID) (Setting, error)
+	UpsertSetting(context.Context, Setting) (Setting, error)
 
 	// auth user
 	CreateAuthUser(context.Context, AuthUser) (AuthUser, error)
+	GetAuthUserByUID(context.Context, string) (AuthUser, error)
+	GetAuthUserByUserID(context.Context, uuid.UUID) (AuthUser, error)
+
+	// refresh token
+	CreateRefreshToken(context.Context, RefreshToken) (RefreshToken, error)
+	GetRefreshTokenByHash(context.Context, string) (RefreshToken, error)
+	RevokeRefreshToken(context.Context, uuid.UUID) error
 }
 
 type IdentityProvider interface {
 	CreateUser(context.Context, RegisterUser) (string, error)
+	// VerifyIDToken checks the signature and expiry of an ID token
+	// and returns the UID of the identity it was issued for.
+	VerifyIDToken(context.Context, string) (string, error)
+	// SignIn checks the email and password and returns the UID.
+	SignIn(ctx context.Context, email, password string) (string, error)
+	// IssueToken returns a fresh ID token for the UID.
+	IssueToken(context.Context, string) (string, error)
+	DeleteUser(context.Context, string) error
 }
 
 type Usecase struct {
 	repo             Repository
 	identityProvider IdentityProvider
+	notifier         Notifier
 }
 
 func (u Usecase) Health() map[string]string {
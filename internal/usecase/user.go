@@ -2,6 +2,8 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,14 +22,18 @@ type User struct {
 }
 
 type ListUsersOption struct {
-	Skip   int
-	Limit  int
-	Name   string
-	Email  string
-	Phone  string
-	IDs    uuid.UUIDs
-	SortBy string
-	SortIn string
+	Skip  int
+	Limit int
+	Name  string
+	Email string
+	Phone string
+	IDs   uuid.UUIDs
+	// LibraryID lists only the users subscribed to, or staff of, the library.
+	LibraryID string
+	SortBy    string
+	SortIn    string
+	// IncludeDeleted lists the soft-deleted users as well.
+	IncludeDeleted bool
 }
 
 func (u Usecase) ListUsers(ctx context.Context, opt ListUsersOption) ([]User, int, error) {
@@ -111,7 +117,7 @@ func (u Usecase) UpdateUser(ctx context.Context, user User) (User, error) {
 func (u Usecase) DeleteUser(ctx context.Context, id string) error {
 	err := uuid.Validate(id)
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: %v", ErrInvalidArgument, err)
 	}
 	err = u.repo.DeleteUser(ctx, id)
 	if err != nil {
@@ -120,3 +126,28 @@ func (u Usecase) DeleteUser(ctx context.Context, id string) error {
 
 	return nil
 }
+
+func (u Usecase) RestoreUser(ctx context.Context, id uuid.UUID) error {
+	return u.repo.RestoreUser(ctx, id)
+}
+
+// PurgeUser deletes the user for good, along with their identity at the
+// identity provider. Users with subscriptions, staff roles or fines
+// cannot be purged.
+func (u Usecase) PurgeUser(ctx context.Context, id uuid.UUID) error {
+	au, err := u.repo.GetAuthUserByUserID(ctx, id)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	if err := u.repo.PurgeUser(ctx, id); err != nil {
+		return err
+	}
+
+	if au.UID != "" && u.identityProvider != nil {
+		if err := u.identityProvider.DeleteUser(ctx, au.UID); err != nil {
+			return fmt.Errorf("delete identity %s: %w", au.UID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// CallNumberSchemeDDC is the Dewey Decimal Classification.
+	CallNumberSchemeDDC = "DDC"
+	// CallNumberSchemeLCC is the Library of Congress Classification.
+	CallNumberSchemeLCC = "LCC"
+)
+
+// Work is a bibliographic record of a library, of which it may own
+// several copies (Book).
+type Work struct {
+	ID     uuid.UUID
+	Title  string
+	Author string
+	Year   int
+	ISBN13 string
+	ISBN10 string
+	// CallNumber is the shelf mark of the work in CallNumberScheme.
+	CallNumber       string
+	CallNumberScheme string
+	LibraryID        uuid.UUID
+	// SubjectIDs, when not nil, sets the subjects of the work on create
+	// and update; Subjects are the ones it is classified under.
+	SubjectIDs uuid.UUIDs
+	Subjects   []Subject
+	// TotalCopies and AvailableCopies count the copies of the work, and
+	// those neither lent out nor lost.
+	TotalCopies     int
+	AvailableCopies int
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeletedAt       *time.Time
+	Library         *Library
+}
+
+type ListWorksOption struct {
+	Skip       int
+	Limit      int
+	LibraryIDs uuid.UUIDs
+	IDs        uuid.UUIDs
+	Title      string
+	ISBN13s    []string
+	Query      string
+	// SubjectIDs lists the works classified under all of the subjects.
+	SubjectIDs uuid.UUIDs
+	SortBy     string
+	SortIn     string
+	// IncludeDeleted lists the soft-deleted works as well.
+	IncludeDeleted bool
+}
+
+func (u Usecase) ListWorks(ctx context.Context, opt ListWorksOption) ([]Work, int, error) {
+	return u.repo.ListWorks(ctx, opt)
+}
+
+func (u Usecase) GetWorkByID(ctx context.Context, id uuid.UUID) (Work, error) {
+	return u.repo.GetWorkByID(ctx, id)
+}
+
+func (u Usecase) CreateWork(ctx context.Context, work Work) (Work, error) {
+	var id uuid.UUID
+	err := u.repo.Transaction(ctx, func(repo Repository) error {
+		w, err := repo.CreateWork(ctx, work)
+		if err != nil {
+			return err
+		}
+		id = w.ID
+		if work.SubjectIDs != nil {
+			return setWorkSubjects(ctx, repo, w, work.SubjectIDs)
+		}
+		return nil
+	})
+	if err != nil {
+		return Work{}, err
+	}
+	return u.repo.GetWorkByID(ctx, id)
+}
+
+func (u Usecase) UpdateWork(ctx context.Context, work Work) (Work, error) {
+	if work.SubjectIDs == nil {
+		return u.repo.UpdateWork(ctx, work)
+	}
+
+	err := u.repo.Transaction(ctx, func(repo Repository) error {
+		w, err := repo.UpdateWork(ctx, work)
+		if err != nil {
+			return err
+		}
+		return setWorkSubjects(ctx, repo, w, work.SubjectIDs)
+	})
+	if err != nil {
+		return Work{}, err
+	}
+	return u.repo.GetWorkByID(ctx, work.ID)
+}
+
+// DeleteWork deletes the work unless books are copies of it.
+func (u Usecase) DeleteWork(ctx context.Context, id uuid.UUID) error {
+	_, books, err := u.repo.ListBooks(ctx, ListBooksOption{
+		Limit:  1,
+		WorkID: id,
+	})
+	if err != nil {
+		return err
+	}
+	if books > 0 {
+		return fmt.Errorf("%w: work %s has %d", ErrWorkInUse, id, books)
+	}
+	return u.repo.DeleteWork(ctx, id)
+}
+
+func (u Usecase) RestoreWork(ctx context.Context, id uuid.UUID) error {
+	return u.repo.RestoreWork(ctx, id)
+}
+
+// PurgeWork deletes the work and its classifications for good.
+func (u Usecase) PurgeWork(ctx context.Context, id uuid.UUID) error {
+	return u.repo.PurgeWork(ctx, id)
+}